@@ -0,0 +1,63 @@
+package otp
+
+import (
+	"encoding/base32"
+	"strings"
+	"sync"
+
+	"github.com/ecnepsnai/otp/internal"
+)
+
+// SecretAlphabet represents the base32 alphabet a secret is written in.
+type SecretAlphabet string
+
+const (
+	// SecretAlphabetRFC4648 is the standard base32 alphabet described by RFC 4648, used by almost
+	// every authenticator.
+	SecretAlphabetRFC4648 SecretAlphabet = ""
+	// SecretAlphabetZBase32 is the human-oriented z-base-32 alphabet.
+	SecretAlphabetZBase32 SecretAlphabet = "zbase32"
+	// SecretAlphabetCrockford is Douglas Crockford's base32 alphabet, which excludes I, L, O and U.
+	SecretAlphabetCrockford SecretAlphabet = "crockford"
+)
+
+var (
+	zBase32Encoding = sync.OnceValue(func() *base32.Encoding {
+		return base32.NewEncoding("ybndrfg8ejkmcpqxot1uwisza345h769").WithPadding(base32.NoPadding)
+	})
+	crockfordEncoding = sync.OnceValue(func() *base32.Encoding {
+		return base32.NewEncoding("0123456789ABCDEFGHJKMNPQRSTVWXYZ").WithPadding(base32.NoPadding)
+	})
+	crockfordReplacer = sync.OnceValue(func() *strings.Replacer {
+		return strings.NewReplacer("-", "", "O", "0", "I", "1", "L", "1")
+	})
+)
+
+// DecodeSecret converts a secret written in this alphabet into raw bytes. Like standard secrets,
+// surrounding whitespace, padding and the case of letters are ignored. Crockford secrets may also
+// contain hyphens and the commonly confused letters I, L and O.
+func (a SecretAlphabet) DecodeSecret(secret string) ([]byte, error) {
+	switch a {
+	case SecretAlphabetRFC4648:
+		return internal.DecodeSecret(secret)
+	case SecretAlphabetZBase32:
+		secret = strings.ToLower(strings.TrimRight(strings.TrimSpace(secret), "="))
+		return zBase32Encoding().DecodeString(secret)
+	case SecretAlphabetCrockford:
+		secret = strings.ToUpper(strings.TrimRight(strings.TrimSpace(secret), "="))
+		return crockfordEncoding().DecodeString(crockfordReplacer().Replace(secret))
+	}
+	return nil, ErrValidateSecretInvalidBase32
+}
+
+// EncodeSecret encodes raw secret bytes in this alphabet without padding.
+func (a SecretAlphabet) EncodeSecret(secret []byte) string {
+	switch a {
+	case SecretAlphabetZBase32:
+		return zBase32Encoding().EncodeToString(secret)
+	case SecretAlphabetCrockford:
+		return crockfordEncoding().EncodeToString(secret)
+	default:
+		return internal.EncodeSecret(secret)
+	}
+}
@@ -0,0 +1,62 @@
+package otp
+
+import (
+	"testing"
+)
+
+func TestSecretAlphabet(t *testing.T) {
+	secret := []byte("helloworld!")
+
+	tests := []struct {
+		alphabet SecretAlphabet
+		encoded  string
+		input    string
+	}{
+		{SecretAlphabetRFC4648, "NBSWY3DPO5XXE3DEEE", " nbswy3dpo5xxe3deee====== "},
+		{SecretAlphabetZBase32, "pb1sa5dxq7zzr5drrr", "PB1SA5DXQ7ZZR5DRRR"},
+		{SecretAlphabetCrockford, "D1JPRV3FEXQQ4V3444", "d1jp-rv3f-exqq-4v34-44"},
+	}
+	for _, test := range tests {
+		if test.encoded != test.alphabet.EncodeSecret(secret) {
+			t.Fatalf("Unexpected %q secret '%s'", test.alphabet, test.alphabet.EncodeSecret(secret))
+		}
+		b, err := test.alphabet.DecodeSecret(test.input)
+		if err != nil {
+			t.Fatalf("Error decoding %q secret: %s", test.alphabet, err.Error())
+		}
+		if string(secret) != string(b) {
+			t.Fatalf("Unexpected %q decoded secret '%s'", test.alphabet, b)
+		}
+	}
+
+	// Crockford treats O, I and L as the digits they resemble.
+	b, err := SecretAlphabetCrockford.DecodeSecret("DIJPRV3FEXQQ4V3444")
+	if err != nil || string(secret) != string(b) {
+		t.Fatalf("Expected I to decode as 1")
+	}
+
+	if _, err := SecretAlphabetZBase32.DecodeSecret("0000"); err == nil {
+		t.Fatalf("Expected error for characters outside the alphabet")
+	}
+	if _, err := SecretAlphabet("base36").DecodeSecret("NBSWY3DP"); ErrValidateSecretInvalidBase32 != err {
+		t.Fatalf("Expected error for unknown alphabet")
+	}
+}
+
+func TestKeySecretAlphabet(t *testing.T) {
+	k, err := NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=pb1sa5dxq7zzr5drrr&alphabet=zbase32")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if SecretAlphabetZBase32 != k.SecretAlphabet() {
+		t.Fatalf("Unexpected alphabet %q", k.SecretAlphabet())
+	}
+
+	std, _ := NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=NBSWY3DPO5XXE3DEEE")
+	if SecretAlphabetRFC4648 != std.SecretAlphabet() {
+		t.Fatalf("Unexpected alphabet %q", std.SecretAlphabet())
+	}
+	if std.Fingerprint() != k.Fingerprint() {
+		t.Fatalf("Expected the same secret in different alphabets to share a fingerprint")
+	}
+}
@@ -0,0 +1,158 @@
+// Package assertion mints short-lived signed statements that a user has just passed OTP validation,
+// so downstream services can trust that step-up authentication happened without validating a
+// passcode again. Assertions are JSON Web Tokens (RFC 7519) signed with HMAC-SHA256, and carry the
+// standard "amr" claim (RFC 8176) together with the fingerprint of the key that was used.
+package assertion
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// ErrTokenInvalid is returned when a token is malformed, has the wrong signature or is not an OTP
+// assertion, and when minting an assertion for a result without OTP.
+var ErrTokenInvalid = otp.NewCodedError("OTP_ASSERTION_INVALID", "Assertion is not valid")
+
+// ErrTokenExpired is returned when a token has expired or is not valid yet.
+var ErrTokenExpired = otp.NewCodedError("OTP_ASSERTION_EXPIRED", "Assertion has expired")
+
+// ErrKeyTooShort is returned when the Key of a Signer is shorter than 32 bytes.
+var ErrKeyTooShort = otp.NewCodedError("OTP_ASSERTION_KEY_TOO_SHORT", "Assertion key must be at least 32 bytes")
+
+// AMROTP is the authentication method reference for a one-time password, see RFC 8176.
+const AMROTP = "otp"
+
+// Claims are the contents of an assertion.
+type Claims struct {
+	// Issuer of the assertion.
+	Issuer string `json:"iss,omitempty"`
+	// Subject is the user that was validated.
+	Subject string `json:"sub"`
+	// Audience the assertion is intended for.
+	Audience string `json:"aud,omitempty"`
+	// IssuedAt is the time the assertion was minted, in seconds since the epoch.
+	IssuedAt int64 `json:"iat"`
+	// Expires is the time after which the assertion must not be accepted, in seconds since the epoch.
+	Expires int64 `json:"exp"`
+	// AuthTime is the time the passcode was validated, in seconds since the epoch.
+	AuthTime int64 `json:"auth_time"`
+	// AMR lists the methods used to authenticate the user, including AMROTP.
+	AMR []string `json:"amr"`
+	// ACR is the authentication context class, such as ACRMultiFactor.
+	ACR string `json:"acr,omitempty"`
+	// KeyFingerprint identifies the key that was validated (see otp.Key.Fingerprint).
+	KeyFingerprint string `json:"otp_fp,omitempty"`
+}
+
+// Signer mints and verifies assertions.
+type Signer struct {
+	// Key used to sign assertions, shared with the services that verify them. It must be at least 32
+	// random bytes.
+	Key []byte
+	// Issuer recorded in assertions and required when verifying them. Defaults to none.
+	Issuer string
+	// Audience recorded in assertions and required when verifying them. Defaults to none.
+	Audience string
+	// How long an assertion is valid for. Defaults to 5 minutes.
+	TTL time.Duration
+}
+
+// minKeySize is the length of the shortest Key a Signer accepts, the size of a SHA-256 hash.
+const minKeySize = 32
+
+// header is the encoded JOSE header of every assertion, {"alg":"HS256","typ":"JWT"}.
+const header = "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"
+
+// Mint returns an assertion that subject passed OTP validation with the key identified by
+// fingerprint at t.
+func (s *Signer) Mint(subject string, fingerprint string, t time.Time) (string, error) {
+	return s.Sign(s.claims(subject, fingerprint, []string{AMROTP}, "", t))
+}
+
+// claims returns the claims of an assertion minted at t.
+func (s *Signer) claims(subject string, fingerprint string, amr []string, acr string, t time.Time) Claims {
+	ttl := s.TTL
+	if ttl == 0 {
+		ttl = 5 * time.Minute
+	}
+
+	return Claims{
+		Issuer:         s.Issuer,
+		Subject:        subject,
+		Audience:       s.Audience,
+		IssuedAt:       t.Unix(),
+		Expires:        t.Add(ttl).Unix(),
+		AuthTime:       t.Unix(),
+		AMR:            amr,
+		ACR:            acr,
+		KeyFingerprint: fingerprint,
+	}
+}
+
+// Sign returns an assertion holding claims, for callers that need to set the claims themselves.
+func (s *Signer) Sign(claims Claims) (string, error) {
+	if len(s.Key) < minKeySize {
+		return "", ErrKeyTooShort
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(s.mac(signed)), nil
+}
+
+// Verify checks the signature, issuer, audience and expiry of token at t and returns its claims.
+func (s *Signer) Verify(token string, t time.Time) (*Claims, error) {
+	if len(s.Key) < minKeySize {
+		return nil, ErrKeyTooShort
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != header {
+		return nil, ErrTokenInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, s.mac(parts[0]+"."+parts[1])) {
+		return nil, ErrTokenInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	claims := &Claims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if claims.Issuer != s.Issuer || claims.Audience != s.Audience || !claims.HasAMR(AMROTP) {
+		return nil, ErrTokenInvalid
+	}
+	if t.Unix() >= claims.Expires || t.Unix() < claims.IssuedAt-60 {
+		return nil, ErrTokenExpired
+	}
+
+	return claims, nil
+}
+
+// HasAMR returns true if method is one of the authentication methods in the claims.
+func (c *Claims) HasAMR(method string) bool {
+	for _, m := range c.AMR {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Signer) mac(signed string) []byte {
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
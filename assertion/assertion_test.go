@@ -0,0 +1,79 @@
+package assertion
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMintVerify(t *testing.T) {
+	s := &Signer{Key: []byte("01234567890123456789012345678901"), Issuer: "otp.example.com"}
+	now := time.Unix(1700000000, 0)
+
+	token, err := s.Mint("alice", "0123456789abcdef", now)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 3 != len(strings.Split(token, ".")) {
+		t.Fatalf("Unexpected token '%s'", token)
+	}
+
+	claims, err := s.Verify(token, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "alice" != claims.Subject || "0123456789abcdef" != claims.KeyFingerprint || !claims.HasAMR(AMROTP) || now.Unix() != claims.AuthTime {
+		t.Fatalf("Unexpected claims %+v", claims)
+	}
+
+	if _, err := s.Verify(token, now.Add(5*time.Minute)); ErrTokenExpired != err {
+		t.Fatalf("Expected expired error.")
+	}
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	s := &Signer{Key: []byte("01234567890123456789012345678901")}
+	now := time.Unix(1700000000, 0)
+	token, _ := s.Mint("alice", "", now)
+
+	others := []*Signer{
+		{Key: []byte("another key that is long enough!")},
+		{Key: s.Key, Issuer: "otp.example.com"},
+		{Key: s.Key, Audience: "api"},
+	}
+	for _, other := range others {
+		if _, err := other.Verify(token, now); ErrTokenInvalid != err {
+			t.Fatalf("Expected invalid error for %+v.", other)
+		}
+	}
+
+	parts := strings.Split(token, ".")
+	for _, bad := range []string{"", "a.b", parts[0] + ".e30." + parts[2], token + "x"} {
+		if _, err := s.Verify(bad, now); ErrTokenInvalid != err {
+			t.Fatalf("Expected invalid error for '%s'.", bad)
+		}
+	}
+
+	// Only OTP assertions are accepted.
+	password, _ := s.Sign(Claims{Subject: "alice", IssuedAt: now.Unix(), Expires: now.Unix() + 60, AMR: []string{"pwd"}})
+	if _, err := s.Verify(password, now); ErrTokenInvalid != err {
+		t.Fatalf("Expected invalid error without the otp method.")
+	}
+}
+
+func TestHeader(t *testing.T) {
+	if base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) != header {
+		t.Fatalf("Unexpected header '%s'", header)
+	}
+}
+
+func TestSignerKeyTooShort(t *testing.T) {
+	s := &Signer{Key: []byte("short key")}
+	if _, err := s.Mint("alice", "", time.Now()); ErrKeyTooShort != err {
+		t.Fatalf("Expected key too short error, got %v", err)
+	}
+	if _, err := (&Signer{}).Verify("a.b.c", time.Now()); ErrKeyTooShort != err {
+		t.Fatalf("Expected key too short error without a key, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package assertion
+
+import (
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// ErrStepUpRequired is returned by RequireStepUp when an assertion does not show that the user
+// recently authenticated with all of the required methods.
+var ErrStepUpRequired = otp.NewCodedError("OTP_STEP_UP_REQUIRED", "Step-up authentication is required")
+
+// Authentication method references used in OpenID Connect "amr" claims, see RFC 8176.
+const (
+	// AMRPassword is a password or PIN the user knows.
+	AMRPassword = "pwd"
+	// AMRMultiFactor is authentication with more than one factor.
+	AMRMultiFactor = "mfa"
+	// AMRHardwareKey is proof of possession of a hardware key, such as a hardware OTP token.
+	AMRHardwareKey = "hwk"
+	// AMRSoftwareKey is proof of possession of a software key, such as an authenticator app.
+	AMRSoftwareKey = "swk"
+)
+
+// ACRMultiFactor is the OpenID Connect "acr" value for multi-factor authentication, from the OpenID
+// Provider Authentication Policy Extension.
+const ACRMultiFactor = "http://schemas.openid.net/pape/policies/2007/06/multi-factor"
+
+// Result describes how a user was authenticated, for identity providers that report it in the "amr"
+// and "acr" claims of the tokens they issue.
+type Result struct {
+	// OTP is true if a passcode was validated.
+	OTP bool
+	// Password is true if the user's password was also checked, such as with
+	// totp.ValidatePasswordAndCode.
+	Password bool
+	// HardwareToken is true if the passcode came from a hardware token rather than an app.
+	HardwareToken bool
+}
+
+// AMR returns the "amr" values for the result, in a fixed order.
+func (r Result) AMR() []string {
+	amr := []string{}
+	if r.Password {
+		amr = append(amr, AMRPassword)
+	}
+	if r.OTP {
+		amr = append(amr, AMROTP)
+		if r.HardwareToken {
+			amr = append(amr, AMRHardwareKey)
+		} else {
+			amr = append(amr, AMRSoftwareKey)
+		}
+	}
+	if r.Password && r.OTP {
+		amr = append(amr, AMRMultiFactor)
+	}
+	return amr
+}
+
+// ACR returns the "acr" value for the result, or "" if it was not multi-factor authentication.
+func (r Result) ACR() string {
+	if r.Password && r.OTP {
+		return ACRMultiFactor
+	}
+	return ""
+}
+
+// MintResult is Mint with the "amr" and "acr" claims taken from result, which must include OTP.
+func (s *Signer) MintResult(subject string, fingerprint string, result Result, t time.Time) (string, error) {
+	if !result.OTP {
+		return "", ErrTokenInvalid
+	}
+
+	return s.Sign(s.claims(subject, fingerprint, result.AMR(), result.ACR(), t))
+}
+
+// RequireStepUp is used by resource servers to check that claims, from an assertion or an OpenID
+// Connect ID token, show the user authenticated with every one of methods no longer than maxAge
+// before t. It returns ErrStepUpRequired if not.
+func RequireStepUp(claims *Claims, t time.Time, maxAge time.Duration, methods ...string) error {
+	if claims == nil || claims.AuthTime == 0 || t.Sub(time.Unix(claims.AuthTime, 0)) > maxAge {
+		return ErrStepUpRequired
+	}
+	for _, method := range methods {
+		if !claims.HasAMR(method) {
+			return ErrStepUpRequired
+		}
+	}
+	return nil
+}
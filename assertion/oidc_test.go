@@ -0,0 +1,67 @@
+package assertion
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultAMR(t *testing.T) {
+	tests := []struct {
+		result Result
+		amr    string
+		acr    string
+	}{
+		{Result{OTP: true}, "otp swk", ""},
+		{Result{OTP: true, HardwareToken: true}, "otp hwk", ""},
+		{Result{OTP: true, Password: true}, "pwd otp swk mfa", ACRMultiFactor},
+		{Result{Password: true}, "pwd", ""},
+	}
+	for _, test := range tests {
+		if amr := strings.Join(test.result.AMR(), " "); test.amr != amr {
+			t.Fatalf("Unexpected amr '%s' for %+v", amr, test.result)
+		}
+		if test.acr != test.result.ACR() {
+			t.Fatalf("Unexpected acr '%s' for %+v", test.result.ACR(), test.result)
+		}
+	}
+}
+
+func TestMintResult(t *testing.T) {
+	s := &Signer{Key: []byte("01234567890123456789012345678901")}
+	now := time.Unix(1700000000, 0)
+
+	token, err := s.MintResult("alice", "", Result{OTP: true, Password: true}, now)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	claims, err := s.Verify(token, now)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if ACRMultiFactor != claims.ACR || !claims.HasAMR(AMRMultiFactor) {
+		t.Fatalf("Unexpected claims %+v", claims)
+	}
+
+	if _, err := s.MintResult("alice", "", Result{Password: true}, now); ErrTokenInvalid != err {
+		t.Fatalf("Expected invalid error for a result without OTP.")
+	}
+}
+
+func TestRequireStepUp(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	claims := &Claims{AuthTime: now.Unix(), AMR: Result{OTP: true, Password: true}.AMR()}
+
+	if err := RequireStepUp(claims, now.Add(time.Minute), 5*time.Minute, AMROTP, AMRPassword); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := RequireStepUp(claims, now.Add(10*time.Minute), 5*time.Minute, AMROTP); ErrStepUpRequired != err {
+		t.Fatalf("Expected step-up required for an old authentication.")
+	}
+	if err := RequireStepUp(claims, now, 5*time.Minute, AMRHardwareKey); ErrStepUpRequired != err {
+		t.Fatalf("Expected step-up required for a missing method.")
+	}
+	if err := RequireStepUp(nil, now, 5*time.Minute); ErrStepUpRequired != err {
+		t.Fatalf("Expected step-up required without claims.")
+	}
+}
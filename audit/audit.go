@@ -0,0 +1,119 @@
+// Package audit produces tamper-evident records of OTP validations. Each record holds the time step
+// or counter, the result and the fingerprint of the key, but never the passcode, and is chained to the
+// previous record with an HMAC so that removed, reordered or altered records can be detected later.
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// ErrChainBroken is returned by Verify when a record does not follow from the one before it.
+var ErrChainBroken = otp.NewCodedError("OTP_AUDIT_CHAIN_BROKEN", "Audit chain is broken")
+
+// Record is a single validation in an audit chain.
+type Record struct {
+	// Sequence is the position of this record in the chain, starting at 1.
+	Sequence uint64
+	// Time of the validation.
+	Time time.Time
+	// KeyFingerprint identifies the key that was validated (see otp.Key.Fingerprint).
+	KeyFingerprint string
+	// Step is the TOTP time step or HOTP counter the passcode was checked against.
+	Step uint64
+	// Accepted is true if the passcode was valid.
+	Accepted bool
+	// Previous is the MAC of the previous record, or nil for the first record.
+	Previous []byte
+	// MAC authenticates this record and Previous.
+	MAC []byte
+}
+
+// Chain appends records to an audit chain. It is safe for concurrent use.
+type Chain struct {
+	key      []byte
+	lock     sync.Mutex
+	sequence uint64
+	last     []byte
+}
+
+// NewChain returns a chain that authenticates records with key. To continue an existing chain, pass
+// its last record, otherwise pass nil.
+func NewChain(key []byte, last *Record) *Chain {
+	c := &Chain{key: key}
+	if last != nil {
+		c.sequence = last.Sequence
+		c.last = last.MAC
+	}
+	return c
+}
+
+// Append adds a record of a validation to the chain and returns it. The record should be persisted
+// by the caller.
+func (c *Chain) Append(t time.Time, keyFingerprint string, step uint64, accepted bool) Record {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.sequence++
+	r := Record{
+		Sequence:       c.sequence,
+		Time:           t,
+		KeyFingerprint: keyFingerprint,
+		Step:           step,
+		Accepted:       accepted,
+		Previous:       c.last,
+	}
+	r.MAC = r.sum(c.key)
+	c.last = r.MAC
+
+	return r
+}
+
+// Verify checks that records form an unbroken chain authenticated by key. If the records do not
+// start at the beginning of the chain, previous must be the record immediately before the first one.
+func Verify(key []byte, records []Record, previous *Record) error {
+	var last []byte
+	sequence := uint64(0)
+	if previous != nil {
+		last = previous.MAC
+		sequence = previous.Sequence
+	}
+
+	for i, r := range records {
+		sequence++
+		if r.Sequence != sequence || !hmac.Equal(r.Previous, last) || !hmac.Equal(r.MAC, r.sum(key)) {
+			return fmt.Errorf("%w at record %d", ErrChainBroken, i)
+		}
+		last = r.MAC
+	}
+
+	return nil
+}
+
+// sum computes the MAC over a fixed binary encoding of the record.
+func (r Record) sum(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+
+	buf := make([]byte, 0, 64+len(r.KeyFingerprint))
+	buf = binary.BigEndian.AppendUint64(buf, r.Sequence)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(r.Time.UnixNano()))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(r.KeyFingerprint)))
+	buf = append(buf, r.KeyFingerprint...)
+	buf = binary.BigEndian.AppendUint64(buf, r.Step)
+	if r.Accepted {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+	buf = append(buf, byte(len(r.Previous)))
+	buf = append(buf, r.Previous...)
+
+	mac.Write(buf)
+	return mac.Sum(nil)
+}
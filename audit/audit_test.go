@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var testKey = []byte("audit key")
+
+func testRecords() []Record {
+	c := NewChain(testKey, nil)
+	now := time.Unix(1111111109, 0)
+	return []Record{
+		c.Append(now, "a1b2c3d4e5f60718", 37037036, true),
+		c.Append(now.Add(time.Second), "a1b2c3d4e5f60718", 37037036, false),
+		c.Append(now.Add(time.Minute), "0011223344556677", 37037038, true),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	records := testRecords()
+	if err := Verify(testKey, records, nil); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := Verify(testKey, records[1:], &records[0]); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := Verify([]byte("other key"), records, nil); !errors.Is(err, ErrChainBroken) {
+		t.Fatalf("Expected chain broken error with the wrong key.")
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	records := testRecords()
+	records[1].Accepted = true
+	if err := Verify(testKey, records, nil); !errors.Is(err, ErrChainBroken) {
+		t.Fatalf("Expected chain broken error for an altered record.")
+	}
+
+	records = testRecords()
+	if err := Verify(testKey, []Record{records[0], records[2]}, nil); !errors.Is(err, ErrChainBroken) {
+		t.Fatalf("Expected chain broken error for a removed record.")
+	}
+
+	records = testRecords()
+	if err := Verify(testKey, []Record{records[1], records[0], records[2]}, nil); !errors.Is(err, ErrChainBroken) {
+		t.Fatalf("Expected chain broken error for reordered records.")
+	}
+}
+
+func TestContinueChain(t *testing.T) {
+	records := testRecords()
+	c := NewChain(testKey, &records[2])
+	next := c.Append(time.Unix(1111111200, 0), "a1b2c3d4e5f60718", 37037040, true)
+	if 4 != next.Sequence {
+		t.Fatalf("Unexpected sequence %d", next.Sequence)
+	}
+	if err := Verify(testKey, append(records, next), nil); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+}
@@ -0,0 +1,201 @@
+// Package bypass issues short-lived emergency codes that let a user sign in once without their
+// authenticator, for help-desk flows where a user has lost their device. Unlike temporarily turning off
+// two-factor authentication for the account, a bypass code is bound to a single user, expires on its
+// own, and can only be used once.
+package bypass
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// ErrCodeInvalid is returned when a code is malformed, was not issued by this Bypass, or was issued
+// for a different user.
+var ErrCodeInvalid = otp.NewCodedError("OTP_BYPASS_CODE_INVALID", "Bypass code is not valid")
+
+// ErrCodeExpired is returned when a code has passed its expiry time.
+var ErrCodeExpired = otp.NewCodedError("OTP_BYPASS_CODE_EXPIRED", "Bypass code has expired")
+
+// ErrCodeUsed is returned when a code has already been used.
+var ErrCodeUsed = otp.NewCodedError("OTP_REPLAYED", "Bypass code has already been used")
+
+// ErrKeyTooShort is returned when the Key of a Bypass is shorter than 32 bytes.
+var ErrKeyTooShort = otp.NewCodedError("OTP_BYPASS_KEY_TOO_SHORT", "Bypass key must be at least 32 bytes")
+
+// ErrStoreMissing is returned when a Bypass has no Store.
+var ErrStoreMissing = otp.NewCodedError("OTP_BYPASS_STORE_MISSING", "Bypass has no store")
+
+// Store records which codes have been used. Use must atomically mark the code as used so that it can
+// only ever be accepted once, even when called concurrently.
+type Store interface {
+	// Use marks the code identified by id as used at t. ok is false if it was already used. The
+	// record is only needed until expires, after which the code is rejected anyway.
+	Use(id string, t time.Time, expires time.Time) (ok bool, err error)
+}
+
+// Bypass issues and verifies bypass codes.
+type Bypass struct {
+	// Key used to sign codes. It must be kept secret and be at least 32 random bytes.
+	Key []byte
+	// Store used to record used codes. Required.
+	Store Store
+	// How long a code can be used for. Defaults to 1 hour.
+	TTL time.Duration
+	// Reader to use for generating codes. Defaults to crypto/rand.
+	Rand io.Reader
+}
+
+const (
+	nonceSize  = 8
+	macSize    = 10
+	codeSize   = nonceSize + 8 + macSize
+	minKeySize = 32
+)
+
+// Issue returns a new code for user that can be used once until the returned expiry time. The code
+// should be read to the user over an already verified channel.
+func (b *Bypass) Issue(user string, t time.Time) (string, time.Time, error) {
+	if err := b.check(); err != nil {
+		return "", time.Time{}, err
+	}
+	ttl := b.TTL
+	if ttl == 0 {
+		ttl = time.Hour
+	}
+	r := b.Rand
+	if r == nil {
+		r = rand.Reader
+	}
+
+	code := make([]byte, codeSize)
+	if _, err := io.ReadFull(r, code[:nonceSize]); err != nil {
+		return "", time.Time{}, err
+	}
+	expires := t.Add(ttl).Truncate(time.Second)
+	binary.BigEndian.PutUint64(code[nonceSize:], uint64(expires.Unix()))
+	copy(code[nonceSize+8:], b.sign(user, code[:nonceSize+8]))
+
+	return formatCode(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(code)), expires.UTC(), nil
+}
+
+// Verify checks that code was issued to user and has not expired or been used before at t, and marks
+// it as used. It returns nil if the user may proceed.
+func (b *Bypass) Verify(user string, code string, t time.Time) error {
+	id, expires, err := b.open(user, code, t)
+	if err != nil {
+		return err
+	}
+	ok, err := b.Store.Use(id, t, expires)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrCodeUsed
+	}
+	return nil
+}
+
+// open checks that code was issued to user and has not expired at t, and returns the id it is
+// recorded in the store under and its expiry time.
+func (b *Bypass) open(user string, code string, t time.Time) (string, time.Time, error) {
+	if err := b.check(); err != nil {
+		return "", time.Time{}, err
+	}
+	raw, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalizeCode(code))
+	if err != nil || len(raw) != codeSize {
+		return "", time.Time{}, ErrCodeInvalid
+	}
+	if !hmac.Equal(raw[nonceSize+8:], b.sign(user, raw[:nonceSize+8])) {
+		return "", time.Time{}, ErrCodeInvalid
+	}
+
+	expires := time.Unix(int64(binary.BigEndian.Uint64(raw[nonceSize:])), 0)
+	if !t.Before(expires) {
+		return "", time.Time{}, ErrCodeExpired
+	}
+	return hex.EncodeToString(raw[:nonceSize]), expires, nil
+}
+
+// check returns an error if b is missing its key or store.
+func (b *Bypass) check() error {
+	if len(b.Key) < minKeySize {
+		return ErrKeyTooShort
+	}
+	if b.Store == nil {
+		return ErrStoreMissing
+	}
+	return nil
+}
+
+// sign returns the truncated MAC binding the nonce and expiry in data to user.
+func (b *Bypass) sign(user string, data []byte) []byte {
+	mac := hmac.New(sha256.New, b.Key)
+	mac.Write([]byte("otp-bypass\x00"))
+	mac.Write([]byte(user))
+	mac.Write([]byte{0})
+	mac.Write(data)
+	return mac.Sum(nil)[:macSize]
+}
+
+// formatCode splits a code into groups of 6 characters to make it easier to read aloud.
+func formatCode(s string) string {
+	groups := []string{}
+	for len(s) > 6 {
+		groups = append(groups, s[:6])
+		s = s[6:]
+	}
+	return strings.Join(append(groups, s), "-")
+}
+
+// normalizeCode removes the separators and case a user might enter a code with.
+func normalizeCode(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', ' ', '\t':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(s)))
+}
+
+// MemoryStore is a Store that keeps used codes in memory. It is only suitable for a single process.
+type MemoryStore struct {
+	lock sync.Mutex
+	used map[string]time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		used: map[string]time.Time{},
+	}
+}
+
+// Use marks the code identified by id as used, discarding any records of codes that have expired
+// at t.
+func (s *MemoryStore) Use(id string, t time.Time, expires time.Time) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// id is looked up before anything is discarded, so that its own record can't be lost first.
+	if e, used := s.used[id]; used && t.Before(e) {
+		return false, nil
+	}
+	for i, e := range s.used {
+		if !t.Before(e) {
+			delete(s.used, i)
+		}
+	}
+	s.used[id] = expires
+	return true, nil
+}
@@ -0,0 +1,98 @@
+package bypass
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+var testKey = []byte("01234567890123456789012345678901")
+
+func TestIssueVerify(t *testing.T) {
+	b := &Bypass{Key: testKey, Store: NewMemoryStore()}
+	now := time.Now()
+
+	code, expires, err := b.Issue("alice", now)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 48 != len(code) {
+		t.Fatalf("Unexpected code '%s'", code)
+	}
+	if expires.Before(now.Add(59*time.Minute)) || expires.After(now.Add(time.Hour)) {
+		t.Fatalf("Unexpected expiry %s", expires)
+	}
+
+	if err := b.Verify("bob", code, now); ErrCodeInvalid != err {
+		t.Fatalf("Expected invalid code error for another user.")
+	}
+
+	// Codes are accepted regardless of case and separators.
+	if err := b.Verify("alice", strings.ToLower(strings.ReplaceAll(code, "-", " ")), now); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "OTP_REPLAYED" != otp.ErrorCode(ErrCodeUsed) {
+		t.Fatalf("Unexpected code '%s'", otp.ErrorCode(ErrCodeUsed))
+	}
+	if err := b.Verify("alice", code, now); ErrCodeUsed != err {
+		t.Fatalf("Expected used code error on second use.")
+	}
+}
+
+func TestVerifyExpired(t *testing.T) {
+	b := &Bypass{Key: testKey, Store: NewMemoryStore(), TTL: 10 * time.Minute}
+	now := time.Now()
+
+	code, _, _ := b.Issue("alice", now)
+	if err := b.Verify("alice", code, now.Add(11*time.Minute)); ErrCodeExpired != err {
+		t.Fatalf("Expected expired code error.")
+	}
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	b := &Bypass{Key: testKey, Store: NewMemoryStore()}
+	now := time.Now()
+	code, _, _ := b.Issue("alice", now)
+
+	other := &Bypass{Key: []byte("another key that is long enough!"), Store: NewMemoryStore()}
+	if err := other.Verify("alice", code, now); ErrCodeInvalid != err {
+		t.Fatalf("Expected invalid code error for another key.")
+	}
+
+	for _, code := range []string{"", "not a code", code[:20]} {
+		if err := b.Verify("alice", code, now); ErrCodeInvalid != err {
+			t.Fatalf("Expected invalid code error for '%s'.", code)
+		}
+	}
+}
+
+func TestBypassMisconfigured(t *testing.T) {
+	now := time.Now()
+	if _, _, err := (&Bypass{Key: testKey[:31], Store: NewMemoryStore()}).Issue("alice", now); ErrKeyTooShort != err {
+		t.Fatalf("Expected key too short error, got %v", err)
+	}
+	if err := (&Bypass{Store: NewMemoryStore()}).Verify("alice", "code", now); ErrKeyTooShort != err {
+		t.Fatalf("Expected key too short error without a key, got %v", err)
+	}
+	if _, _, err := (&Bypass{Key: testKey}).Issue("alice", now); ErrStoreMissing != err {
+		t.Fatalf("Expected store missing error, got %v", err)
+	}
+	if err := (&Bypass{Key: testKey}).Verify("alice", "code", now); ErrStoreMissing != err {
+		t.Fatalf("Expected store missing error, got %v", err)
+	}
+}
+
+func TestVerifyPinnedTime(t *testing.T) {
+	b := &Bypass{Key: testKey, Store: NewMemoryStore()}
+	now := time.Unix(1111111109, 0)
+
+	code, _, _ := b.Issue("alice", now)
+	if err := b.Verify("alice", code, now); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := b.Verify("alice", code, now); ErrCodeUsed != err {
+		t.Fatalf("Expected used code error on second use at a past time.")
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !otp_unsafe
+
+package bypass
+
+import "time"
+
+// These declarations stop the package from compiling if the dry-run modes are ever built without the
+// otp_unsafe build tag, since each would then be declared twice.
+
+var ErrDryRunUnsupported error
+
+type DryRunStore interface{}
+
+func (b *Bypass) VerifyDryRun(user string, code string, t time.Time) error { return nil }
+
+func (s *MemoryStore) Used(id string, t time.Time) (bool, error) { return false, nil }
@@ -0,0 +1,50 @@
+//go:build otp_unsafe
+
+package bypass
+
+import (
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// ErrDryRunUnsupported is returned by VerifyDryRun when the Store of a Bypass is not a DryRunStore.
+var ErrDryRunUnsupported = otp.NewCodedError("OTP_BYPASS_DRY_RUN_UNSUPPORTED", "Bypass store does not support dry runs")
+
+// DryRunStore is a Store that can report whether a code has been used without marking it as used.
+type DryRunStore interface {
+	Store
+	// Used returns true if the code identified by id was used and its record has not expired at t.
+	Used(id string, t time.Time) (bool, error)
+}
+
+// VerifyDryRun runs the same checks as Verify, including the store lookup, without marking code as
+// used. It is meant for canary testing new stores and for support tooling, and must never be used to
+// decide whether to let a user sign in, because the code can be accepted again. It is only built
+// with the otp_unsafe build tag.
+func (b *Bypass) VerifyDryRun(user string, code string, t time.Time) error {
+	id, _, err := b.open(user, code, t)
+	if err != nil {
+		return err
+	}
+	s, ok := b.Store.(DryRunStore)
+	if !ok {
+		return ErrDryRunUnsupported
+	}
+	used, err := s.Used(id, t)
+	if err != nil {
+		return err
+	}
+	if used {
+		return ErrCodeUsed
+	}
+	return nil
+}
+
+// Used returns true if the code identified by id was used and its record has not expired at t.
+func (s *MemoryStore) Used(id string, t time.Time) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	e, used := s.used[id]
+	return used && t.Before(e), nil
+}
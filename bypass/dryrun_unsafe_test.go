@@ -0,0 +1,40 @@
+//go:build otp_unsafe
+
+package bypass
+
+import (
+	"testing"
+	"time"
+)
+
+type storeOnly struct{ Store }
+
+func TestVerifyDryRun(t *testing.T) {
+	b := &Bypass{Key: testKey, Store: NewMemoryStore()}
+	now := time.Unix(1111111109, 0)
+	code, _, err := b.Issue("alice", now)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	if err := b.VerifyDryRun("bob", code, now); ErrCodeInvalid != err {
+		t.Fatalf("Expected invalid code error for another user.")
+	}
+	for i := 0; i < 2; i++ {
+		if err := b.VerifyDryRun("alice", code, now); err != nil {
+			t.Fatalf("Error: %s", err.Error())
+		}
+	}
+	// The dry runs left the code unused.
+	if err := b.Verify("alice", code, now); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := b.VerifyDryRun("alice", code, now); ErrCodeUsed != err {
+		t.Fatalf("Expected used code error after use.")
+	}
+
+	b.Store = storeOnly{NewMemoryStore()}
+	if err := b.VerifyDryRun("alice", code, now); ErrDryRunUnsupported != err {
+		t.Fatalf("Expected dry run unsupported error.")
+	}
+}
@@ -0,0 +1,32 @@
+package otp
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ecnepsnai/otp/internal"
+)
+
+// CanonicalURL returns the otpauth URL of this Key in a canonical form, so that the same key always
+// yields the same string, for caches, duplicate detection and signatures over provisioning URLs.
+// The type is lower case, the label is escaped consistently, parameters are sorted by name and
+// encoded with %20 for spaces, and the secret is written in upper case without padding or
+// separators. Keys with the same canonical URL are interchangeable.
+func (k *Key) CanonicalURL() string {
+	q := k.url.Query()
+	if secret := q.Get("secret"); secret != "" {
+		alphabet := k.SecretAlphabet()
+		if b, err := alphabet.DecodeSecret(secret); err == nil {
+			q.Set("secret", alphabet.EncodeSecret(b))
+		}
+	}
+
+	label := strings.TrimPrefix(k.url.Path, "/")
+	if i := strings.Index(label, ":"); i != -1 {
+		label = url.PathEscape(label[:i]) + ":" + url.PathEscape(label[i+1:])
+	} else {
+		label = url.PathEscape(label)
+	}
+
+	return "otpauth://" + strings.ToLower(k.url.Host) + "/" + label + "?" + internal.EncodeQuery(q)
+}
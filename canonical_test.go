@@ -0,0 +1,34 @@
+package otp
+
+import "testing"
+
+func TestCanonicalURL(t *testing.T) {
+	urls := []string{
+		"otpauth://totp/Snake%20Oil:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Snake%20Oil&digits=8",
+		"otpauth://TOTP/Snake%20Oil:alice@example.com?digits=8&issuer=Snake+Oil&secret=jbswy3dpehpk3pxp",
+		"otpauth://totp/Snake%20Oil:alice%40example.com?issuer=Snake%20Oil&secret=%20JBSWY3dpehpk3pxp&digits=8",
+	}
+	expected := "otpauth://totp/Snake%20Oil:alice@example.com?digits=8&issuer=Snake%20Oil&secret=JBSWY3DPEHPK3PXP"
+
+	for _, u := range urls {
+		k, err := NewKeyFromURL(u)
+		if err != nil {
+			t.Fatalf("Error: %s", err.Error())
+		}
+		if expected != k.CanonicalURL() {
+			t.Fatalf("'%s' does not equal '%s' for '%s'", k.CanonicalURL(), expected, u)
+		}
+
+		// The canonical URL is a valid key with the same settings.
+		c, _ := NewKeyFromURL(k.CanonicalURL())
+		if c.CanonicalURL() != k.CanonicalURL() || "Snake Oil" != c.Issuer() {
+			t.Fatalf("Canonical URL is not stable for '%s'", u)
+		}
+	}
+
+	k, _ := NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&digits=6")
+	other, _ := NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&digits=8")
+	if k.CanonicalURL() == other.CanonicalURL() {
+		t.Fatalf("Different keys must have different canonical URLs")
+	}
+}
@@ -0,0 +1,225 @@
+// Command otp_native_host is a native messaging host for Chrome and Firefox that returns TOTP passcodes
+// from an encrypted key store (see store.FileStore) to a browser extension, so that autofill
+// extensions can be built on this library without the extension holding any secrets.
+//
+// Browsers start the host with arguments of their own, so register a wrapper script as the path in
+// the host manifest that passes the settings first:
+//
+//	#!/bin/sh
+//	exec /usr/local/bin/otp_native_host -store ~/.otp/keys -passphrase-file ~/.otp/passphrase -origins ~/.otp/origins.json "$@"
+//
+// The -origins file maps each web origin that may receive passcodes to the names of its keys in the
+// store:
+//
+//	{"https://github.com": ["github"]}
+//
+// The extension sends {"origin": "https://github.com/login"} and the host responds with
+// {"codes": [{"name": "github", "code": "123456", "expires": 1700000030}]}, where expires is the time
+// the passcode stops being current in seconds since the epoch. Origins that are not listed receive
+// {"error": "origin not allowed"}. Only TOTP keys are used, since generating a HOTP passcode would
+// have to advance its counter.
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/store"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+// maxMessageSize is the largest message accepted from the browser. Requests are tiny, so anything
+// larger is a protocol error.
+const maxMessageSize = 64 * 1024
+
+var errMessageTooLarge = errors.New("message is too large")
+
+type request struct {
+	Origin string `json:"origin"`
+}
+
+type code struct {
+	Name    string `json:"name"`
+	Code    string `json:"code"`
+	Expires int64  `json:"expires"`
+}
+
+type response struct {
+	Codes []code `json:"codes,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, time.Now))
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, now func() time.Time) int {
+	flags := flag.NewFlagSet("otp_native_host", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	storePath := flags.String("store", "", "Path to the key store")
+	passphrasePath := flags.String("passphrase-file", "", "Path to a file holding the passphrase of the key store")
+	originsPath := flags.String("origins", "", "Path to a JSON file mapping allowed origins to key names")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	if *storePath == "" || *passphrasePath == "" || *originsPath == "" {
+		fmt.Fprintf(stderr, "-store, -passphrase-file and -origins are required\n")
+		return 1
+	}
+
+	passphrase, err := os.ReadFile(*passphrasePath)
+	if err != nil {
+		fmt.Fprintf(stderr, "unable to read passphrase: %s\n", err.Error())
+		return 1
+	}
+	origins, err := readOrigins(*originsPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "unable to read origins: %s\n", err.Error())
+		return 1
+	}
+	h := &host{
+		keys:    store.NewFileStore(*storePath, []byte(strings.TrimRight(string(passphrase), "\r\n"))),
+		origins: origins,
+		stderr:  stderr,
+	}
+
+	for {
+		message, err := readMessage(stdin)
+		if err == io.EOF {
+			return 0
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "unable to read message: %s\n", err.Error())
+			return 1
+		}
+		if err := writeMessage(stdout, h.handle(message, now())); err != nil {
+			fmt.Fprintf(stderr, "unable to write message: %s\n", err.Error())
+			return 1
+		}
+	}
+}
+
+// readOrigins reads the file mapping allowed origins to key names, normalizing each origin.
+func readOrigins(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	listed := map[string][]string{}
+	if err := json.Unmarshal(data, &listed); err != nil {
+		return nil, err
+	}
+	origins := map[string][]string{}
+	for origin, names := range listed {
+		normalized := normalizeOrigin(origin)
+		if normalized == "" {
+			return nil, fmt.Errorf("invalid origin '%s'", origin)
+		}
+		origins[normalized] = append(origins[normalized], names...)
+	}
+	return origins, nil
+}
+
+// normalizeOrigin returns the scheme and host of s, which may be an origin or a full URL, or "" if it
+// has neither.
+func normalizeOrigin(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+type host struct {
+	keys    store.KeyStore
+	origins map[string][]string
+	stderr  io.Writer
+}
+
+// handle returns the response to message at t. Details of failures are only written to stderr, which
+// browsers keep in their own logs, so that the extension learns nothing about the store.
+func (h *host) handle(message []byte, t time.Time) response {
+	req := request{}
+	if err := json.Unmarshal(message, &req); err != nil {
+		return response{Error: "invalid request"}
+	}
+	names, ok := h.origins[normalizeOrigin(req.Origin)]
+	if !ok {
+		return response{Error: "origin not allowed"}
+	}
+
+	codes := []code{}
+	for _, name := range names {
+		k, err := h.keys.Get(name)
+		if err != nil {
+			fmt.Fprintf(h.stderr, "unable to read key '%s': %s\n", name, err.Error())
+			return response{Error: "unable to read keys"}
+		}
+		if k.Type() != "totp" {
+			continue
+		}
+		passcode, expires, err := generate(k, t)
+		if err != nil {
+			fmt.Fprintf(h.stderr, "unable to generate passcode for '%s': %s\n", name, err.Error())
+			return response{Error: "unable to generate passcodes"}
+		}
+		codes = append(codes, code{Name: name, Code: passcode, Expires: expires.Unix()})
+	}
+	return response{Codes: codes}
+}
+
+// generate returns the passcode of k at t, using every setting recorded in the key, and the time it
+// stops being current.
+func generate(k *otp.Key, t time.Time) (string, time.Time, error) {
+	opts := totp.ValidateOpts{
+		Period:    30,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	}
+	totp.FromKey(k)(nil, &opts)
+	passcode, err := totp.GenerateCodeCustom(k.Secret(), t, opts)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	period := int64(opts.Period)
+	return passcode, time.Unix((t.Unix()/period+1)*period, 0), nil
+}
+
+// readMessage reads one message of the native messaging protocol: its length as a 32-bit integer in
+// native byte order, followed by that many bytes of JSON.
+func readMessage(r io.Reader) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(r, binary.NativeEndian, &length); err != nil {
+		return nil, err
+	}
+	if length > maxMessageSize {
+		return nil, errMessageTooLarge
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(r, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// writeMessage writes resp as one message of the native messaging protocol.
+func writeMessage(w io.Writer, resp response) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.NativeEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
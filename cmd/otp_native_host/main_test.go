@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/store"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+func message(t *testing.T, v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.NativeEndian, uint32(len(data)))
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	storePath := filepath.Join(dir, "keys")
+	s := store.NewFileStore(storePath, []byte("hunter2"))
+	s.Iterations = 1000
+	github, _ := otp.NewKeyFromURL("otpauth://totp/GitHub:alice?secret=JBSWY3DPEHPK3PXP&issuer=GitHub")
+	counter, _ := otp.NewKeyFromURL("otpauth://hotp/GitHub:alice?secret=JBSWY3DPEHPK3PXP&issuer=GitHub&counter=1")
+	s.Put("github", github)
+	s.Put("github-hotp", counter)
+
+	os.WriteFile(filepath.Join(dir, "passphrase"), []byte("hunter2\n"), 0600)
+	os.WriteFile(filepath.Join(dir, "origins.json"), []byte(`{"https://GitHub.com": ["github", "github-hotp"]}`), 0600)
+	args := []string{"-store", storePath, "-passphrase-file", filepath.Join(dir, "passphrase"), "-origins", filepath.Join(dir, "origins.json"), "chrome-extension://abcdef/"}
+
+	now := time.Unix(1111111109, 0)
+	stdin := &bytes.Buffer{}
+	stdin.Write(message(t, request{Origin: "https://github.com/login"}))
+	stdin.Write(message(t, request{Origin: "https://github.com.example.com"}))
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	if status := run(args, stdin, stdout, stderr, func() time.Time { return now }); 0 != status {
+		t.Fatalf("Unexpected status %d: %s", status, stderr.String())
+	}
+
+	responses := []response{}
+	for {
+		data, err := readMessage(stdout)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error: %s", err.Error())
+		}
+		resp := response{}
+		json.Unmarshal(data, &resp)
+		responses = append(responses, resp)
+	}
+	if 2 != len(responses) {
+		t.Fatalf("Unexpected number of responses %d", len(responses))
+	}
+
+	expected, _ := totp.GenerateCode(github.Secret(), now)
+	codes := responses[0].Codes
+	if 1 != len(codes) || "github" != codes[0].Name || expected != codes[0].Code || 1111111110 != codes[0].Expires {
+		t.Fatalf("Unexpected codes %+v", codes)
+	}
+	if "origin not allowed" != responses[1].Error || 0 != len(responses[1].Codes) {
+		t.Fatalf("Unexpected response for an origin that is not allowed %+v", responses[1])
+	}
+}
+
+func TestReadMessageTooLarge(t *testing.T) {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.NativeEndian, uint32(maxMessageSize+1))
+	if _, err := readMessage(buf); errMessageTooLarge != err {
+		t.Fatalf("Expected message too large error")
+	}
+}
@@ -0,0 +1,253 @@
+//go:build unix
+
+// Command otp_ssh_gate requires a TOTP passcode before starting an SSH session, for bastion hosts
+// where PAM cannot be changed. Configure it as the ForceCommand in sshd_config:
+//
+//	ForceCommand /usr/local/bin/otp_ssh_gate -keys /etc/otp/%u -state /var/lib/otp_ssh_gate
+//
+// The user's key is read from the file named by the -keys template, with %u replaced by the username.
+// The file contains the user's otpauth URL. After a valid passcode the user's shell is executed,
+// running SSH_ORIGINAL_COMMAND if the client requested a command.
+//
+// To prevent a passcode from being replayed, the time step of the last accepted passcode for each user
+// is recorded in the -state directory, and only passcodes for later time steps are accepted. The gate
+// runs as the user who is logging in, so the user must not be able to reach the state directory, or
+// they could reset their own record. Install the gate setgid to a group of its own that can use the
+// directory, and that no user belongs to:
+//
+//	groupadd --system otp_ssh_gate
+//	install -o root -g otp_ssh_gate -m 2755 otp_ssh_gate /usr/local/bin/otp_ssh_gate
+//	install -d -o root -g otp_ssh_gate -m 0770 /var/lib/otp_ssh_gate
+//
+// The gate refuses to run if the user can write to or search the state directory, and gives up the
+// group before starting the shell. The key files can be made readable only by the same group, so that
+// users can't read their own secrets either. The shell is the one recorded for the user in
+// /etc/passwd.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+var (
+	errInvalid  = errors.New("passcode is not valid")
+	errReplayed = errors.New("passcode has already been used")
+	errNoShell  = errors.New("user is not listed in /etc/passwd")
+)
+
+func main() {
+	u, err := user.Current()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to determine user: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	flags := flag.NewFlagSet("otp_ssh_gate", flag.ExitOnError)
+	keys := flags.String("keys", "/etc/otp/%u", "Path to each user's key file, %u is replaced with the username")
+	state := flags.String("state", "/var/lib/otp_ssh_gate", "Directory used to record accepted passcodes")
+	skew := flags.Uint("skew", 1, "Number of periods before or after the current time to allow")
+	attempts := flags.Int("attempts", 3, "Number of passcode attempts allowed")
+	flags.Parse(os.Args[1:])
+
+	if err := checkStateDir(*state, u); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		os.Exit(1)
+	}
+	shell, err := readShell("/etc/passwd", u.Username)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to find shell: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	key, err := readKey(strings.ReplaceAll(*keys, "%u", u.Username))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		os.Exit(1)
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	for i := 0; i < *attempts; i++ {
+		fmt.Fprint(os.Stderr, "Verification code: ")
+		passcode, readErr := in.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			os.Exit(1)
+		}
+
+		if err := check(key, strings.TrimSpace(passcode), time.Now().UTC(), *skew, filepath.Join(*state, u.Username)); err == nil {
+			execShell(u, shell)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Invalid verification code\n")
+		if readErr == io.EOF {
+			break
+		}
+	}
+	os.Exit(1)
+}
+
+// checkStateDir returns an error if u could change the records in dir, because they own it or can
+// write to or search it through its other or group permissions. Root can change anything, so is not
+// checked.
+func checkStateDir(dir string, u *user.User) error {
+	if u.Uid == "0" {
+		return nil
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("unable to check state directory: %s", err.Error())
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("unable to check state directory: unknown owner")
+	}
+	if strconv.FormatUint(uint64(st.Uid), 10) == u.Uid {
+		return fmt.Errorf("state directory %s must not be owned by %s", dir, u.Username)
+	}
+	perm := info.Mode().Perm()
+	if perm&0003 != 0 {
+		return fmt.Errorf("state directory %s must not be writable or searchable by other users", dir)
+	}
+	if perm&0030 != 0 {
+		groups, err := u.GroupIds()
+		if err != nil {
+			return fmt.Errorf("unable to check state directory: %s", err.Error())
+		}
+		gid := strconv.FormatUint(uint64(st.Gid), 10)
+		for _, group := range append(groups, u.Gid) {
+			if group == gid {
+				return fmt.Errorf("state directory %s must not be writable or searchable by a group of %s", dir, u.Username)
+			}
+		}
+	}
+	return nil
+}
+
+// readShell returns the login shell of username from the passwd file at path, or /bin/sh if the
+// entry leaves it empty.
+func readShell(path string, username string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) != 7 || fields[0] != username {
+			continue
+		}
+		if fields[6] == "" {
+			return "/bin/sh", nil
+		}
+		return fields[6], nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errNoShell
+}
+
+func readKey(path string) (*otp.Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read key: %s", err.Error())
+	}
+	key, err := otp.NewKeyFromURL(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse key: %s", err.Error())
+	}
+	if key.Type() != "totp" {
+		return nil, fmt.Errorf("only totp keys are supported")
+	}
+	return key, nil
+}
+
+// check validates passcode using every setting recorded in key and records its time step in
+// statePath, rejecting passcodes for a time step at or before the last one recorded.
+func check(key *otp.Key, passcode string, t time.Time, skew uint, statePath string) error {
+	opts := totp.ValidateOpts{
+		Period:    30,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	}
+	totp.FromKey(key)(nil, &opts)
+	opts.Skew = skew
+
+	drift, valid, err := totp.ValidateDrift(passcode, key.Secret(), t, opts)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errInvalid
+	}
+
+	step := t.Unix()/int64(opts.Period) + drift
+	return recordStep(statePath, uint64(step))
+}
+
+// recordStep stores step as the last accepted time step in path, failing if a step at or after it has
+// already been recorded. The file is locked so that concurrent logins cannot accept the same passcode.
+func recordStep(path string, step uint64) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if last, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err == nil && step <= last {
+		return errReplayed
+	}
+
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt([]byte(strconv.FormatUint(step, 10)+"\n"), 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// execShell gives up the group the gate may be installed setgid to and replaces this process with the
+// user's shell, running SSH_ORIGINAL_COMMAND if set.
+func execShell(u *user.User, shell string) {
+	gid := syscall.Getgid()
+	if err := syscall.Setregid(gid, gid); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to drop group: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	args := []string{"-" + filepath.Base(shell)}
+	if command := os.Getenv("SSH_ORIGINAL_COMMAND"); command != "" {
+		args = []string{filepath.Base(shell), "-c", command}
+	}
+
+	os.Chdir(u.HomeDir)
+	os.Setenv("SHELL", shell)
+	err := syscall.Exec(shell, args, os.Environ())
+	fmt.Fprintf(os.Stderr, "unable to start shell: %s\n", err.Error())
+	os.Exit(1)
+}
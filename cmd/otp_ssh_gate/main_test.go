@@ -0,0 +1,131 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+func TestCheck(t *testing.T) {
+	key, _ := otp.NewKeyFromURL("otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&issuer=Example")
+	state := filepath.Join(t.TempDir(), "alice")
+	now := time.Unix(1111111109, 0).UTC()
+
+	code, err := totp.GenerateCode(key.Secret(), now)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	if err := check(key, code, now, 1, state); err != nil {
+		t.Fatalf("Expected no error, got %s", err.Error())
+	}
+	if err := check(key, code, now, 1, state); errReplayed != err {
+		t.Fatalf("Expected replayed error.")
+	}
+
+	// A passcode from an earlier time step is also a replay, even if it is within the skew.
+	earlier, _ := totp.GenerateCode(key.Secret(), now.Add(-30*time.Second))
+	if err := check(key, earlier, now, 1, state); errReplayed != err {
+		t.Fatalf("Expected replayed error for an earlier time step.")
+	}
+
+	later := now.Add(30 * time.Second)
+	code, _ = totp.GenerateCode(key.Secret(), later)
+	if err := check(key, code, later, 1, state); err != nil {
+		t.Fatalf("Expected no error, got %s", err.Error())
+	}
+
+	if err := check(key, "000000", later.Add(time.Hour), 0, state); errInvalid != err && errReplayed != err {
+		t.Fatalf("Expected an invalid passcode to be rejected.")
+	}
+}
+
+func TestCheckKeySettings(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "alice")
+
+	// Skew at the very start of time must not wrap around.
+	key, _ := otp.NewKeyFromURL("otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&issuer=Example")
+	code, _ := totp.GenerateCode(key.Secret(), time.Unix(0, 0))
+	if err := check(key, code, time.Unix(1, 0), 5, state); err != nil {
+		t.Fatalf("Expected no error, got %s", err.Error())
+	}
+
+	// Secrets in another alphabet are decoded as recorded in the key.
+	key, _ = totp.GenerateWith(totp.WithIssuer("Example"), totp.WithAccountName("alice"), totp.WithSecretAlphabet(otp.SecretAlphabetCrockford))
+	now := time.Unix(1111111109, 0).UTC()
+	code, _ = totp.GenerateCodeCustom(key.Secret(), now, totp.ValidateOpts{SecretAlphabet: otp.SecretAlphabetCrockford})
+	if err := check(key, code, now, 1, state); err != nil {
+		t.Fatalf("Expected no error, got %s", err.Error())
+	}
+
+	key, _ = totp.GenerateTestKey("alice", totp.GenerateOpts{Issuer: "Example", AccountName: "alice"})
+	code, _ = totp.GenerateCode(key.Secret(), now.Add(time.Minute))
+	if err := check(key, code, now.Add(time.Minute), 1, state); otp.ErrValidateTestKeyNotAllowed != err {
+		t.Fatalf("Expected test keys to be rejected.")
+	}
+}
+
+func TestCheckStateDir(t *testing.T) {
+	dir := t.TempDir()
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	owner := strconv.Itoa(os.Getuid())
+	group := strconv.Itoa(os.Getgid())
+	other := strconv.Itoa(os.Getuid() + 1)
+	if "0" == other {
+		t.Skip("Unable to pick another user")
+	}
+
+	// Root is never rejected, so ownership can only be checked when the tests don't run as root.
+	if err := checkStateDir(dir, &user.User{Uid: owner, Gid: group, Username: "alice"}); err == nil && "0" != owner {
+		t.Fatalf("Expected a directory owned by the user to be rejected")
+	}
+	if err := checkStateDir(dir, &user.User{Uid: "0", Username: "root"}); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	os.Chmod(dir, 0770)
+	defer os.Chmod(dir, info.Mode().Perm())
+	if err := checkStateDir(dir, &user.User{Uid: other, Gid: group, Username: "bob"}); err == nil {
+		t.Fatalf("Expected a directory the user's group can write to be rejected")
+	}
+	os.Chmod(dir, 0707)
+	if err := checkStateDir(dir, &user.User{Uid: other, Gid: other, Username: "bob"}); err == nil {
+		t.Fatalf("Expected a directory anyone can write to be rejected")
+	}
+	os.Chmod(dir, 0700)
+	if err := checkStateDir(dir, &user.User{Uid: other, Gid: other, Username: "bob"}); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := checkStateDir(filepath.Join(dir, "missing"), &user.User{Uid: other, Gid: other, Username: "bob"}); err == nil {
+		t.Fatalf("Expected a missing directory to be rejected")
+	}
+}
+
+func TestReadShell(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passwd")
+	passwd := "root:x:0:0:root:/root:/bin/bash\nalice:x:1000:1000:Alice:/home/alice:/usr/bin/zsh\nbob:x:1001:1001::/home/bob:\n"
+	if err := os.WriteFile(path, []byte(passwd), 0644); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	if shell, err := readShell(path, "alice"); err != nil || "/usr/bin/zsh" != shell {
+		t.Fatalf("Unexpected shell '%s': %v", shell, err)
+	}
+	if shell, err := readShell(path, "bob"); err != nil || "/bin/sh" != shell {
+		t.Fatalf("Unexpected shell '%s': %v", shell, err)
+	}
+	if _, err := readShell(path, "carol"); errNoShell != err {
+		t.Fatalf("Expected an unknown user to be rejected")
+	}
+}
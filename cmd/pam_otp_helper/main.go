@@ -0,0 +1,89 @@
+// Command pam_otp_helper validates a TOTP passcode for use with pam_exec, enabling OTP for SSH and sudo
+// without the pam_oath module.
+//
+// The user is taken from the PAM_USER environment variable set by pam_exec, and the passcode is read
+// from the first line of stdin, so pam_exec must be configured with expose_authtok:
+//
+//	auth required pam_exec.so expose_authtok quiet /usr/local/bin/pam_otp_helper -keys /etc/otp/%u
+//
+// Each user's key is read from the file named by the -keys template, with %u replaced by the
+// username. The file contains the user's otpauth URL, and every setting recorded in it is used for
+// validation (see totp.ValidateKey), so test keys are rejected.
+//
+// The exit code is 0 if the passcode is valid, 1 if it is not, and 2 if it could not be checked.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+const (
+	exitValid   = 0
+	exitInvalid = 1
+	exitError   = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Getenv, os.Stdin, os.Stderr))
+}
+
+func run(args []string, getenv func(string) string, stdin io.Reader, stderr io.Writer) int {
+	flags := flag.NewFlagSet("pam_otp_helper", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	keys := flags.String("keys", "/etc/otp/%u", "Path to each user's key file, %u is replaced with the username")
+	skew := flags.Uint("skew", 1, "Number of periods before or after the current time to allow")
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+
+	user := getenv("PAM_USER")
+	if user == "" || strings.ContainsAny(user, "/\x00") || strings.HasPrefix(user, ".") {
+		fmt.Fprintf(stderr, "invalid or missing PAM_USER\n")
+		return exitError
+	}
+
+	data, err := os.ReadFile(strings.ReplaceAll(*keys, "%u", user))
+	if err != nil {
+		fmt.Fprintf(stderr, "unable to read key: %s\n", err.Error())
+		return exitError
+	}
+	key, err := otp.NewKeyFromURL(string(data))
+	if err != nil {
+		fmt.Fprintf(stderr, "unable to parse key: %s\n", err.Error())
+		return exitError
+	}
+	if key.Type() != "totp" {
+		fmt.Fprintf(stderr, "only totp keys are supported\n")
+		return exitError
+	}
+
+	// pam_exec terminates the token with a NUL byte rather than a newline.
+	passcode, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		fmt.Fprintf(stderr, "unable to read passcode: %s\n", err.Error())
+		return exitError
+	}
+	passcode = strings.TrimRight(passcode, "\x00\r\n")
+
+	valid, err := totp.ValidateKey(passcode, key, time.Now().UTC(), totp.WithSkew(*skew))
+	if err == otp.ErrValidateInputInvalidLength {
+		return exitInvalid
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "unable to validate passcode: %s\n", err.Error())
+		return exitError
+	}
+	if !valid {
+		return exitInvalid
+	}
+	return exitValid
+}
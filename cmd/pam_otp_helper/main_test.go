@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp/totp"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	url := "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&issuer=Example\n"
+	if err := os.WriteFile(filepath.Join(dir, "alice"), []byte(url), 0600); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	args := []string{"-keys", filepath.Join(dir, "%u")}
+	env := func(user string) func(string) string {
+		return func(string) string { return user }
+	}
+
+	code, err := totp.GenerateCode("JBSWY3DPEHPK3PXP", time.Now().UTC())
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	if rv := run(args, env("alice"), strings.NewReader(code+"\x00"), io.Discard); exitValid != rv {
+		t.Fatalf("Expected valid exit code, got %d", rv)
+	}
+	if rv := run(args, env("alice"), strings.NewReader("000000\n"), io.Discard); exitInvalid != rv && code != "000000" {
+		t.Fatalf("Expected invalid exit code, got %d", rv)
+	}
+	if rv := run(args, env("alice"), strings.NewReader("0"), io.Discard); exitInvalid != rv {
+		t.Fatalf("Expected invalid exit code for short passcode, got %d", rv)
+	}
+	if rv := run(args, env("bob"), strings.NewReader(code), io.Discard); exitError != rv {
+		t.Fatalf("Expected error exit code for missing key, got %d", rv)
+	}
+	if rv := run(args, env("../alice"), strings.NewReader(code), io.Discard); exitError != rv {
+		t.Fatalf("Expected error exit code for unsafe username, got %d", rv)
+	}
+	if rv := run(args, env(""), strings.NewReader(code), io.Discard); exitError != rv {
+		t.Fatalf("Expected error exit code for missing username, got %d", rv)
+	}
+}
+
+func TestRunTestKey(t *testing.T) {
+	dir := t.TempDir()
+	k, err := totp.GenerateTestKey("alice", totp.GenerateOpts{Issuer: "Example", AccountName: "alice"})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := os.WriteFile(filepath.Join(dir, "alice"), []byte(k.String()), 0600); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	args := []string{"-keys", filepath.Join(dir, "%u")}
+
+	code, _ := totp.GenerateCode(k.Secret(), time.Now().UTC())
+	if rv := run(args, func(string) string { return "alice" }, strings.NewReader(code), io.Discard); exitValid == rv {
+		t.Fatalf("Test keys should be rejected")
+	}
+}
@@ -0,0 +1,56 @@
+package otp
+
+import (
+	"strings"
+)
+
+// SecretDecoder converts a user supplied secret into raw bytes. SecretAlphabet and DecoderChain are
+// secret decoders.
+type SecretDecoder interface {
+	DecodeSecret(secret string) ([]byte, error)
+}
+
+// SecretStep transforms a secret before it is decoded.
+type SecretStep func(secret string) string
+
+// DecoderChain is a SecretDecoder that applies each of Steps to the secret, in order, before decoding
+// it with Alphabet. Alphabet always trims whitespace, ignores the case of letters and adds missing
+// padding, so Steps only need to handle formats beyond that.
+type DecoderChain struct {
+	// Steps applied to the secret before it is decoded.
+	Steps []SecretStep
+	// Alphabet used to decode the secret. Defaults to RFC 4648.
+	Alphabet SecretAlphabet
+}
+
+// DecodeSecret applies the steps of the chain and decodes the result.
+func (c DecoderChain) DecodeSecret(secret string) ([]byte, error) {
+	for _, step := range c.Steps {
+		secret = step(secret)
+	}
+	return c.Alphabet.DecodeSecret(secret)
+}
+
+// StripSecretPrefix returns a step that removes prefix from the start of a secret, ignoring case,
+// for secrets that vendors export with a prefix such as "oath://totp/".
+func StripSecretPrefix(prefix string) SecretStep {
+	return func(secret string) string {
+		secret = strings.TrimSpace(secret)
+		if len(secret) >= len(prefix) && strings.EqualFold(secret[:len(prefix)], prefix) {
+			return secret[len(prefix):]
+		}
+		return secret
+	}
+}
+
+// StripSecretSeparators is a step that removes the spaces, dashes and underscores used to split
+// secrets into readable groups.
+func StripSecretSeparators(secret string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n', '-', '_':
+			return -1
+		}
+		return r
+	}, secret)
+}
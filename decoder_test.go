@@ -0,0 +1,31 @@
+package otp
+
+import (
+	"testing"
+)
+
+func TestDecoderChain(t *testing.T) {
+	chain := DecoderChain{
+		Steps: []SecretStep{StripSecretPrefix("oath://totp/"), StripSecretSeparators},
+	}
+
+	b, err := chain.DecodeSecret(" OATH://TOTP/nbsw-y3dp_o5xx e3de-ee ")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "helloworld!" != string(b) {
+		t.Fatalf("Unexpected secret '%s'", b)
+	}
+
+	if _, err := (DecoderChain{}).DecodeSecret("oath://totp/NBSWY3DP"); err == nil {
+		t.Fatalf("Expected error without steps")
+	}
+
+	chain.Alphabet = SecretAlphabetZBase32
+	b, err = chain.DecodeSecret("oath://totp/pb1s-a5dx-q7zz-r5dr-rr")
+	if err != nil || "helloworld!" != string(b) {
+		t.Fatalf("Expected chain to decode with its alphabet")
+	}
+
+	var _ SecretDecoder = SecretAlphabetRFC4648
+}
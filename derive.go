@@ -0,0 +1,40 @@
+package otp
+
+import (
+	"encoding/base32"
+
+	"github.com/ecnepsnai/otp/internal"
+)
+
+// MaxDeriveSize is the largest secret DeriveSecret can derive, in bytes, as HKDF-SHA256 produces at
+// most 255 blocks of 32 bytes.
+const MaxDeriveSize = 255 * 32
+
+// DeriveSecret derives a sub-secret from a single enrolled master secret for use with one relying
+// party, identified by context (for example, the service name). The master secret is expanded using
+// HKDF-SHA256 with the context as the info parameter, so each service receives an independent
+// secret and the raw master secret never needs to be shared with any of them.
+//
+// secret must be base32 encoded, and the derived secret is returned base32 encoded without padding,
+// ready to be used with hotp or totp. size is the length of the derived secret in bytes, and defaults
+// to 20 bytes. It must be at most MaxDeriveSize.
+func DeriveSecret(secret string, context string, size uint) (string, error) {
+	if context == "" {
+		return "", ErrDeriveMissingContext
+	}
+
+	if size == 0 {
+		size = 20
+	}
+	if size > MaxDeriveSize {
+		return "", ErrDeriveSizeTooLarge
+	}
+
+	master, err := internal.DecodeSecret(secret)
+	if err != nil {
+		return "", ErrValidateSecretInvalidBase32
+	}
+
+	derived := internal.HKDF(master, nil, []byte(context), int(size))
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(derived), nil
+}
@@ -0,0 +1,80 @@
+package otp
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ecnepsnai/otp/internal"
+)
+
+// Test case 1 from https://tools.ietf.org/html/rfc5869#appendix-A
+func TestHKDFRFCVector(t *testing.T) {
+	ikm, _ := hex.DecodeString("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b")
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+	expected := "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865"
+
+	okm := hex.EncodeToString(internal.HKDF(ikm, salt, info, 42))
+	if expected != okm {
+		t.Fatalf("'%s' does not equal '%s'", expected, okm)
+	}
+}
+
+// Test vectors from https://tools.ietf.org/html/rfc7914#section-11
+func TestPBKDF2RFCVector(t *testing.T) {
+	tests := []struct {
+		password   string
+		salt       string
+		iterations int
+		expected   string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	}
+	for _, test := range tests {
+		dk := hex.EncodeToString(internal.PBKDF2([]byte(test.password), []byte(test.salt), test.iterations, 64))
+		if test.expected != dk {
+			t.Fatalf("'%s' does not equal '%s'", test.expected, dk)
+		}
+	}
+}
+
+func TestDeriveSecret(t *testing.T) {
+	master := "JBSWY3DPEHPK3PXP"
+
+	vpn, err := DeriveSecret(master, "vpn.example.com", 0)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 32 != len(vpn) {
+		t.Fatalf("Derived secret is 32 bytes long as base32.")
+	}
+
+	again, _ := DeriveSecret("jbswy3dpehpk3pxp", "vpn.example.com", 0)
+	if vpn != again {
+		t.Fatalf("Derivation should be deterministic.")
+	}
+
+	wiki, _ := DeriveSecret(master, "wiki.example.com", 0)
+	if vpn == wiki {
+		t.Fatalf("Derived secrets should differ per context.")
+	}
+
+	long, _ := DeriveSecret(master, "vpn.example.com", 64)
+	if 103 != len(long) {
+		t.Fatalf("Derived secret is 103 bytes long as base32.")
+	}
+
+	if _, err := DeriveSecret(master, "vpn.example.com", MaxDeriveSize); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if _, err := DeriveSecret(master, "vpn.example.com", MaxDeriveSize+1); ErrDeriveSizeTooLarge != err {
+		t.Fatalf("Expected size too large error.")
+	}
+	if _, err := DeriveSecret(master, "", 0); ErrDeriveMissingContext != err {
+		t.Fatalf("Expected missing context error.")
+	}
+	if _, err := DeriveSecret("foo", "vpn.example.com", 0); ErrValidateSecretInvalidBase32 != err {
+		t.Fatalf("Expected invalid base32 error.")
+	}
+}
@@ -0,0 +1,138 @@
+package otp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Description is a structured summary of a Key for admin interfaces and logs. It never includes the
+// secret itself, only its size and fingerprint.
+type Description struct {
+	Type        string
+	Issuer      string
+	AccountName string
+	Algorithm   Algorithm
+	Digits      Digits
+	Encoder     Encoder
+	// Period in seconds, for TOTP keys.
+	Period uint64
+	// Counter, for HOTP keys.
+	Counter uint64
+	// SecretSize is the size of the decoded secret in bytes, or 0 if it can't be decoded.
+	SecretSize  int
+	Fingerprint string
+	ExpiresAt   time.Time
+	NotBefore   time.Time
+	Scopes      []string
+	Profile     string
+	// ProfileVersion is the version of Profile, if one is recorded.
+	ProfileVersion uint
+	TestKey        bool
+	RiskFlags      []string
+}
+
+// Describe returns a summary of the settings of this Key.
+func (k *Key) Describe() Description {
+	d := Description{
+		Type:           k.Type(),
+		Issuer:         k.Issuer(),
+		AccountName:    k.AccountName(),
+		Algorithm:      k.Algorithm(),
+		Digits:         k.Digits(),
+		Encoder:        k.Encoder(),
+		Fingerprint:    k.Fingerprint(),
+		ExpiresAt:      k.ExpiresAt(),
+		NotBefore:      k.NotBefore(),
+		Scopes:         k.Scopes(),
+		Profile:        k.Profile(),
+		ProfileVersion: k.ProfileVersion(),
+		TestKey:        k.IsTestKey(),
+		RiskFlags:      k.RiskFlags(),
+	}
+	if d.Type == "hotp" {
+		d.Counter = k.Counter()
+	} else {
+		d.Period = k.Period()
+	}
+	if b, err := k.SecretAlphabet().DecodeSecret(k.Secret()); err == nil {
+		d.SecretSize = len(b)
+	}
+	return d
+}
+
+// fields returns the name and printable value of every setting, in a fixed order.
+func (d Description) fields() [][2]string {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	profileVersion := ""
+	if d.Profile != "" {
+		profileVersion = strconv.FormatUint(uint64(d.ProfileVersion), 10)
+	}
+	encoder := string(d.Encoder)
+	if d.Encoder == EncoderDefault {
+		encoder = "decimal"
+	}
+
+	return [][2]string{
+		{"type", d.Type},
+		{"issuer", d.Issuer},
+		{"account", d.AccountName},
+		{"algorithm", d.Algorithm.String()},
+		{"digits", d.Digits.String()},
+		{"encoder", encoder},
+		{"period", strconv.FormatUint(d.Period, 10)},
+		{"counter", strconv.FormatUint(d.Counter, 10)},
+		{"secret_size", strconv.Itoa(d.SecretSize)},
+		{"fingerprint", d.Fingerprint},
+		{"expires", formatTime(d.ExpiresAt)},
+		{"not_before", formatTime(d.NotBefore)},
+		{"scopes", strings.Join(d.Scopes, ",")},
+		{"profile", d.Profile},
+		{"profile_version", profileVersion},
+		{"test", strconv.FormatBool(d.TestKey)},
+		{"risk", strings.Join(d.RiskFlags, ",")},
+	}
+}
+
+// String formats the description as space separated name=value pairs, leaving out empty values, for
+// use in logs.
+func (d Description) String() string {
+	parts := []string{}
+	for _, f := range d.fields() {
+		if f[1] != "" {
+			parts = append(parts, fmt.Sprintf("%s=%q", f[0], f[1]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Difference is a setting that differs between two keys.
+type Difference struct {
+	// Field is the name of the setting, as used by Description.String.
+	Field string
+	// A is the value in the first key.
+	A string
+	// B is the value in the second key.
+	B string
+}
+
+// DiffKeys returns every setting that differs between a and b, in a fixed order. Secrets are compared
+// by fingerprint, so a changed secret is reported without revealing either secret.
+func DiffKeys(a *Key, b *Key) []Difference {
+	fa := a.Describe().fields()
+	fb := b.Describe().fields()
+
+	differences := []Difference{}
+	for i := range fa {
+		if fa[i][1] != fb[i][1] {
+			differences = append(differences, Difference{Field: fa[i][0], A: fa[i][1], B: fb[i][1]})
+		}
+	}
+	return differences
+}
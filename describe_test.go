@@ -0,0 +1,60 @@
+package otp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyDescribe(t *testing.T) {
+	k, _ := NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil&digits=8&period=60&algorithm=SHA256&scope=login")
+	d := k.Describe()
+
+	if "totp" != d.Type || "SnakeOil" != d.Issuer || "alice" != d.AccountName {
+		t.Fatalf("Unexpected description %+v", d)
+	}
+	if AlgorithmSHA256 != d.Algorithm || DigitsEight != d.Digits || 60 != d.Period || 10 != d.SecretSize {
+		t.Fatalf("Unexpected description %+v", d)
+	}
+	if k.Fingerprint() != d.Fingerprint || 1 != len(d.Scopes) {
+		t.Fatalf("Unexpected description %+v", d)
+	}
+
+	s := d.String()
+	if strings.Contains(s, "JBSWY3DPEHPK3PXP") {
+		t.Fatalf("Description must not include the secret '%s'", s)
+	}
+	if !strings.Contains(s, `issuer="SnakeOil"`) || !strings.Contains(s, `period="60"`) || strings.Contains(s, "expires") {
+		t.Fatalf("Unexpected description '%s'", s)
+	}
+
+	h, _ := NewKeyFromURL("otpauth://hotp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&counter=5")
+	if 5 != h.Describe().Counter || 0 != h.Describe().Period {
+		t.Fatalf("Unexpected HOTP description %+v", h.Describe())
+	}
+}
+
+func TestDiffKeys(t *testing.T) {
+	a, _ := NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil&digits=6")
+	b, _ := NewKeyFromURL("otpauth://totp/SnakeOilCo:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOilCo&digits=8")
+
+	if 0 != len(DiffKeys(a, a)) {
+		t.Fatalf("Expected no differences between a key and itself")
+	}
+
+	differences := DiffKeys(a, b)
+	if 2 != len(differences) {
+		t.Fatalf("Unexpected differences %+v", differences)
+	}
+	if "issuer" != differences[0].Field || "SnakeOil" != differences[0].A || "SnakeOilCo" != differences[0].B {
+		t.Fatalf("Unexpected difference %+v", differences[0])
+	}
+	if "digits" != differences[1].Field {
+		t.Fatalf("Unexpected difference %+v", differences[1])
+	}
+
+	c, _ := NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=GEZDGNBVGY3TQOJQ&issuer=SnakeOil&digits=6")
+	differences = DiffKeys(a, c)
+	if 1 != len(differences) || "fingerprint" != differences[0].Field {
+		t.Fatalf("Expected only the fingerprint to differ, got %+v", differences)
+	}
+}
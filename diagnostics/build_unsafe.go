@@ -0,0 +1,6 @@
+//go:build otp_unsafe
+
+package diagnostics
+
+// unsafeBuild is true when the otp_unsafe build tag is set.
+const unsafeBuild = true
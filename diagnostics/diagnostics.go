@@ -0,0 +1,159 @@
+// Package diagnostics helps support engineers understand why a user's passcode was rejected.
+//
+// A report reveals at which other time steps or counters a passcode would have been accepted, which
+// is information an attacker could use. These functions must never be reachable from a login path.
+// They refuse to run unless the program is built with the otp_unsafe build tag, so that production
+// builds can't reach them at all, and unless Opts.Unsafe is explicitly set:
+//
+//	go build -tags otp_unsafe ./cmd/support-tool
+package diagnostics
+
+import (
+	"math"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/hotp"
+)
+
+// ErrUnsafeNotEnabled is returned when analysis is requested without setting Opts.Unsafe.
+var ErrUnsafeNotEnabled = otp.NewCodedError("OTP_DIAGNOSTICS_NOT_ENABLED", "Diagnostics must be explicitly enabled with Unsafe")
+
+// ErrUnsafeNotBuilt is returned when analysis is requested by a program built without the otp_unsafe
+// build tag.
+var ErrUnsafeNotBuilt = otp.NewCodedError("OTP_DIAGNOSTICS_NOT_BUILT", "Diagnostics require the otp_unsafe build tag")
+
+// Opts provides options for AnalyzeTOTP() and AnalyzeHOTP().
+type Opts struct {
+	// Must be set to true to acknowledge that reports reveal information about valid passcodes.
+	Unsafe bool
+	// Number of seconds a TOTP hash is valid for. Defaults to 30 seconds. Ignored for HOTP.
+	Period uint
+	// Digits the key is configured for. Defaults to 6.
+	Digits otp.Digits
+	// Algorithm the key is configured for. Defaults to SHA1.
+	Algorithm otp.Algorithm
+	// Number of time steps (or counters) either side of the expected one to search. Defaults to 120.
+	Range uint
+}
+
+// Report describes the nearest time step or counter at which a passcode would have been valid.
+type Report struct {
+	// Found is true if the passcode was valid at any searched step or counter.
+	Found bool
+	// Counter is the HOTP counter, or the TOTP time step, at which the passcode was valid.
+	Counter uint64
+	// StepOffset is the number of steps between the expected counter and Counter.
+	StepOffset int64
+	// ClockOffset is the implied offset of the user's clock from the server's clock. Only set for TOTP.
+	ClockOffset time.Duration
+	// Digits that produced the passcode.
+	Digits otp.Digits
+	// Algorithm that produced the passcode.
+	Algorithm otp.Algorithm
+	// DigitsMismatch is true if the passcode length does not match the configured digits.
+	DigitsMismatch bool
+	// AlgorithmMismatch is true if the passcode was only valid with a different algorithm.
+	AlgorithmMismatch bool
+}
+
+// algorithms are the alternatives tried when the configured algorithm does not explain a passcode.
+var algorithms = [...]otp.Algorithm{
+	otp.AlgorithmSHA1,
+	otp.AlgorithmSHA256,
+	otp.AlgorithmSHA512,
+}
+
+// AnalyzeTOTP reports the nearest time step to t at which passcode would have been valid for secret,
+// along with the implied clock offset and whether a digits or algorithm mismatch explains the failure.
+func AnalyzeTOTP(passcode string, secret string, t time.Time, opts Opts) (*Report, error) {
+	if opts.Period == 0 {
+		opts.Period = 30
+	}
+
+	counter := uint64(math.Floor(float64(t.Unix()) / float64(opts.Period)))
+	report, err := analyze(passcode, secret, counter, opts)
+	if err != nil {
+		return nil, err
+	}
+	if report.Found {
+		report.ClockOffset = time.Duration(report.StepOffset) * time.Duration(opts.Period) * time.Second
+	}
+	return report, nil
+}
+
+// AnalyzeHOTP reports the nearest counter to counter at which passcode would have been valid for
+// secret, and whether a digits or algorithm mismatch explains the failure.
+func AnalyzeHOTP(passcode string, secret string, counter uint64, opts Opts) (*Report, error) {
+	return analyze(passcode, secret, counter, opts)
+}
+
+func analyze(passcode string, secret string, counter uint64, opts Opts) (*Report, error) {
+	if !unsafeBuild {
+		return nil, ErrUnsafeNotBuilt
+	}
+	if !opts.Unsafe {
+		return nil, ErrUnsafeNotEnabled
+	}
+	if opts.Digits == 0 {
+		opts.Digits = otp.DigitsSix
+	}
+	if opts.Range == 0 {
+		opts.Range = 120
+	}
+
+	// A passcode can only ever have been produced with as many digits as it has.
+	digits := otp.Digits(len(passcode))
+	if digits == 0 {
+		return nil, otp.ErrValidateInputInvalidLength
+	}
+
+	// Prefer an explanation that uses the configured algorithm over one that doesn't.
+	candidates := []otp.Algorithm{opts.Algorithm}
+	for _, a := range algorithms {
+		if a != opts.Algorithm {
+			candidates = append(candidates, a)
+		}
+	}
+
+	for _, algorithm := range candidates {
+		vOpts := hotp.ValidateOpts{
+			Digits:    digits,
+			Algorithm: algorithm,
+		}
+
+		for offset := int64(0); offset <= int64(opts.Range); offset++ {
+			for _, o := range []int64{offset, -offset} {
+				c := counter + uint64(o)
+				if (o > 0 && c < counter) || (o < 0 && c > counter) {
+					// Skip counters that would wrap around.
+					continue
+				}
+
+				valid, err := hotp.ValidateCustom(passcode, c, secret, vOpts)
+				if err != nil {
+					return nil, err
+				}
+				if valid {
+					return &Report{
+						Found:             true,
+						Counter:           c,
+						StepOffset:        o,
+						Digits:            digits,
+						Algorithm:         algorithm,
+						DigitsMismatch:    digits != opts.Digits,
+						AlgorithmMismatch: algorithm != opts.Algorithm,
+					}, nil
+				}
+
+				if offset == 0 {
+					break
+				}
+			}
+		}
+	}
+
+	return &Report{
+		DigitsMismatch: digits != opts.Digits,
+	}, nil
+}
@@ -0,0 +1,19 @@
+//go:build !otp_unsafe
+
+package diagnostics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnalyzeRequiresBuildTag(t *testing.T) {
+	// Setting Unsafe is not enough without the build tag.
+	opts := Opts{Unsafe: true}
+	if _, err := AnalyzeTOTP("94287082", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", time.Unix(59, 0), opts); ErrUnsafeNotBuilt != err {
+		t.Fatalf("Expected unsafe not built error.")
+	}
+	if _, err := AnalyzeHOTP("755224", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", 0, opts); ErrUnsafeNotBuilt != err {
+		t.Fatalf("Expected unsafe not built error.")
+	}
+}
@@ -0,0 +1,102 @@
+//go:build otp_unsafe
+
+package diagnostics
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+var secSha1 = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestAnalyzeRequiresUnsafe(t *testing.T) {
+	report, err := AnalyzeTOTP("94287082", secSha1, time.Unix(59, 0), Opts{})
+	if ErrUnsafeNotEnabled != err {
+		t.Fatalf("Expected unsafe not enabled error.")
+	}
+	if report != nil {
+		t.Fatalf("Report should be nil on error.")
+	}
+
+	if _, err := AnalyzeHOTP("755224", secSha1, 0, Opts{}); ErrUnsafeNotEnabled != err {
+		t.Fatalf("Expected unsafe not enabled error.")
+	}
+}
+
+func TestAnalyzeTOTPClockOffset(t *testing.T) {
+	server := time.Unix(1111111109, 0).UTC()
+	user := server.Add(-5 * time.Minute)
+
+	code, err := totp.GenerateCodeCustom(secSha1, user, totp.ValidateOpts{Digits: otp.DigitsSix})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	report, err := AnalyzeTOTP(code, secSha1, server, Opts{Unsafe: true})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !report.Found {
+		t.Fatalf("Expected the code to be found.")
+	}
+	if -10 != report.StepOffset {
+		t.Fatalf("Unexpected step offset %d", report.StepOffset)
+	}
+	if -5*time.Minute != report.ClockOffset {
+		t.Fatalf("Unexpected clock offset %s", report.ClockOffset)
+	}
+	if report.DigitsMismatch || report.AlgorithmMismatch {
+		t.Fatalf("Expected no mismatch.")
+	}
+}
+
+func TestAnalyzeMismatch(t *testing.T) {
+	secSha256 := base32.StdEncoding.EncodeToString([]byte("12345678901234567890123456789012"))
+
+	report, err := AnalyzeTOTP("46119246", secSha256, time.Unix(59, 0).UTC(), Opts{Unsafe: true})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !report.Found {
+		t.Fatalf("Expected the code to be found.")
+	}
+	if !report.DigitsMismatch || otp.DigitsEight != report.Digits {
+		t.Fatalf("Expected a digits mismatch.")
+	}
+	if !report.AlgorithmMismatch || otp.AlgorithmSHA256 != report.Algorithm {
+		t.Fatalf("Expected an algorithm mismatch.")
+	}
+	if 0 != report.StepOffset {
+		t.Fatalf("Unexpected step offset %d", report.StepOffset)
+	}
+}
+
+func TestAnalyzeHOTP(t *testing.T) {
+	report, err := AnalyzeHOTP("520489", secSha1, 3, Opts{Unsafe: true})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !report.Found || 9 != report.Counter || 6 != report.StepOffset {
+		t.Fatalf("Expected the code to be found at counter 9.")
+	}
+
+	report, err = AnalyzeHOTP("755224", secSha1, 0, Opts{Unsafe: true, Range: 5})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !report.Found || 0 != report.Counter {
+		t.Fatalf("Expected the code to be found at counter 0.")
+	}
+
+	report, err = AnalyzeHOTP("000000", secSha1, 0, Opts{Unsafe: true, Range: 5})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if report.Found {
+		t.Fatalf("Expected the code not to be found.")
+	}
+}
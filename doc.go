@@ -0,0 +1,8 @@
+// Package otp implements HOTP (RFC 4226) and TOTP (RFC 6238) keys, with passcode generation and
+// validation in the hotp and totp packages.
+//
+// None of the packages in this module have init functions or global state that is modified after
+// startup, and importing them does no work beyond declaring constants and error values. Lookup
+// tables are built lazily on first use, so this module is suitable for environments where cold
+// start time matters, and none of it can panic while a program is being initialized.
+package otp
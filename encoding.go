@@ -0,0 +1,74 @@
+package otp
+
+import (
+	"encoding/base32"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ecnepsnai/otp/internal"
+)
+
+// SecretEncodingProfile controls how secrets and provisioning URLs are serialized when generating a
+// Key, so that the output is guaranteed to be accepted by the chosen ecosystem.
+type SecretEncodingProfile int
+
+const (
+	// SecretEncodingGoogleAuthenticatorCompatible encodes secrets as upper case base32 without padding,
+	// and spaces in the URL as %20. This is the default and is accepted by Google Authenticator and
+	// most other clients.
+	SecretEncodingGoogleAuthenticatorCompatible SecretEncodingProfile = iota
+	// SecretEncodingRFCStrict encodes secrets as upper case base32 with padding, exactly as described
+	// by RFC 4648.
+	SecretEncodingRFCStrict
+)
+
+func (p SecretEncodingProfile) String() string {
+	switch p {
+	case SecretEncodingGoogleAuthenticatorCompatible:
+		return "GoogleAuthenticatorCompatible"
+	case SecretEncodingRFCStrict:
+		return "RFCStrict"
+	}
+	panic("unreached")
+}
+
+// EncodeSecret encodes raw secret bytes for use in a provisioning URL.
+func (p SecretEncodingProfile) EncodeSecret(secret []byte) string {
+	switch p {
+	case SecretEncodingRFCStrict:
+		return base32.StdEncoding.EncodeToString(secret)
+	default:
+		return internal.EncodeSecret(secret)
+	}
+}
+
+// CheckLabel returns ErrGenerateInvalidLabel if issuer or accountName cannot be represented in a
+// provisioning URL label. The label is "issuer:accountName", so the issuer must not contain a colon,
+// and neither may the account name when there is no issuer.
+func CheckLabel(issuer string, accountName string) error {
+	if strings.Contains(issuer, ":") {
+		return ErrGenerateInvalidLabel
+	}
+	if issuer == "" && strings.Contains(accountName, ":") {
+		return ErrGenerateInvalidLabel
+	}
+	return nil
+}
+
+// TruncateLabel shortens s to at most length characters, replacing the end of the string with an
+// ellipsis when it is too long. Characters are counted as runes, so multi-byte characters are never
+// split. A length of 0 means no limit.
+func TruncateLabel(s string, length uint) string {
+	if length == 0 || uint(utf8.RuneCountInString(s)) <= length {
+		return s
+	}
+
+	n := uint(0)
+	for i := range s {
+		if n == length-1 {
+			return s[:i] + "…"
+		}
+		n++
+	}
+	return s
+}
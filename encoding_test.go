@@ -0,0 +1,52 @@
+package otp
+
+import (
+	"testing"
+)
+
+func TestSecretEncodingProfile(t *testing.T) {
+	secret := []byte("helloworld!")
+
+	if "NBSWY3DPO5XXE3DEEE" != SecretEncodingGoogleAuthenticatorCompatible.EncodeSecret(secret) {
+		t.Fatalf("Unexpected secret '%s'", SecretEncodingGoogleAuthenticatorCompatible.EncodeSecret(secret))
+	}
+	if "NBSWY3DPO5XXE3DEEE======" != SecretEncodingRFCStrict.EncodeSecret(secret) {
+		t.Fatalf("Unexpected secret '%s'", SecretEncodingRFCStrict.EncodeSecret(secret))
+	}
+
+	if "RFCStrict" != SecretEncodingRFCStrict.String() {
+		t.Fatalf("Unexpected name '%s'", SecretEncodingRFCStrict.String())
+	}
+}
+
+func TestCheckLabel(t *testing.T) {
+	if err := CheckLabel("Snake Oil", "alice:example"); err != nil {
+		t.Fatalf("Account name may contain a colon when there is an issuer")
+	}
+	if err := CheckLabel("Snake:Oil", "alice"); ErrGenerateInvalidLabel != err {
+		t.Fatalf("Expected invalid label error.")
+	}
+	if err := CheckLabel("", "alice:example"); ErrGenerateInvalidLabel != err {
+		t.Fatalf("Expected invalid label error.")
+	}
+}
+
+func TestTruncateLabel(t *testing.T) {
+	tests := []struct {
+		input    string
+		length   uint
+		expected string
+	}{
+		{"Snake Oil", 0, "Snake Oil"},
+		{"Snake Oil", 9, "Snake Oil"},
+		{"Snake Oil", 6, "Snake…"},
+		{"Schlangenöl GmbH", 12, "Schlangenöl…"},
+		{"蛇油株式会社", 4, "蛇油株…"},
+		{"Snake Oil", 1, "…"},
+	}
+	for _, test := range tests {
+		if test.expected != TruncateLabel(test.input, test.length) {
+			t.Fatalf("Unexpected truncation of '%s' to %d: '%s'", test.input, test.length, TruncateLabel(test.input, test.length))
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package escrow protects OTP secrets for recovery under dual control. A secret is split with
+// Shamir's secret sharing so that any Threshold of the recovery officers' shares are needed to
+// recover it, and each share is encrypted to one officer's RSA public key.
+package escrow
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"io"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// ErrInvalidThreshold is returned when the threshold is not between 1 and the number of officers, or
+// there are more than 255 officers.
+var ErrInvalidThreshold = otp.NewCodedError("OTP_ESCROW_INVALID_THRESHOLD", "Threshold must be between 1 and the number of officers")
+
+// ErrNotEnoughShares is returned when fewer shares than the threshold are given to Combine.
+var ErrNotEnoughShares = otp.NewCodedError("OTP_ESCROW_NOT_ENOUGH_SHARES", "Not enough shares to recover secret")
+
+// ErrInvalidShares is returned when the shares given to Combine are inconsistent.
+var ErrInvalidShares = otp.NewCodedError("OTP_ESCROW_INVALID_SHARES", "Shares are duplicated or do not match")
+
+// oaepLabel binds ciphertexts to this package so they cannot be confused with other RSA-OAEP uses.
+const oaepLabel = "github.com/ecnepsnai/otp/escrow"
+
+// Officer is a recovery officer who holds one share of escrowed secrets.
+type Officer struct {
+	// Name identifies the officer.
+	Name string
+	// PublicKey the officer's share is encrypted to.
+	PublicKey *rsa.PublicKey
+}
+
+// Envelope is one officer's encrypted share of an escrowed secret.
+type Envelope struct {
+	// Officer is the name of the officer the share is encrypted to.
+	Officer string
+	// Index is the x coordinate of the share, from 1 to 255.
+	Index byte
+	// Ciphertext is the share value encrypted with RSA-OAEP and SHA-256.
+	Ciphertext []byte
+}
+
+// Escrow is an escrowed secret.
+type Escrow struct {
+	// Threshold is the number of shares required to recover the secret.
+	Threshold int
+	// Envelopes holds one encrypted share for each officer.
+	Envelopes []Envelope
+}
+
+// Share is a decrypted share of an escrowed secret.
+type Share struct {
+	Index byte
+	Value []byte
+}
+
+// Seal splits secret into one share per officer, any threshold of which can recover the secret, and
+// encrypts each share to its officer. If r is nil, crypto/rand is used.
+func Seal(secret []byte, officers []Officer, threshold int, r io.Reader) (*Escrow, error) {
+	if threshold < 1 || threshold > len(officers) || len(officers) > 255 {
+		return nil, ErrInvalidThreshold
+	}
+	if r == nil {
+		r = rand.Reader
+	}
+
+	shares, err := split(secret, len(officers), threshold, r)
+	if err != nil {
+		return nil, err
+	}
+
+	escrow := &Escrow{Threshold: threshold}
+	for i, officer := range officers {
+		ciphertext, err := rsa.EncryptOAEP(sha256.New(), r, officer.PublicKey, shares[i].Value, []byte(oaepLabel))
+		if err != nil {
+			return nil, err
+		}
+		escrow.Envelopes = append(escrow.Envelopes, Envelope{
+			Officer:    officer.Name,
+			Index:      shares[i].Index,
+			Ciphertext: ciphertext,
+		})
+	}
+
+	return escrow, nil
+}
+
+// Open decrypts an officer's envelope with their private key.
+func Open(envelope Envelope, key *rsa.PrivateKey) (Share, error) {
+	value, err := rsa.DecryptOAEP(sha256.New(), nil, key, envelope.Ciphertext, []byte(oaepLabel))
+	if err != nil {
+		return Share{}, err
+	}
+	return Share{Index: envelope.Index, Value: value}, nil
+}
+
+// Combine recovers the secret from at least threshold decrypted shares.
+func Combine(shares []Share, threshold int) ([]byte, error) {
+	if threshold < 1 || len(shares) < threshold {
+		return nil, ErrNotEnoughShares
+	}
+	shares = shares[:threshold]
+
+	seen := map[byte]bool{}
+	for _, s := range shares {
+		if s.Index == 0 || seen[s.Index] || len(s.Value) != len(shares[0].Value) {
+			return nil, ErrInvalidShares
+		}
+		seen[s.Index] = true
+	}
+
+	// Lagrange interpolation at x = 0 for each byte of the secret.
+	secret := make([]byte, len(shares[0].Value))
+	for i, si := range shares {
+		basis := byte(1)
+		for j, sj := range shares {
+			if i != j {
+				basis = mul(basis, div(sj.Index, sj.Index^si.Index))
+			}
+		}
+		for b := range secret {
+			secret[b] ^= mul(si.Value[b], basis)
+		}
+	}
+
+	return secret, nil
+}
+
+// split creates n shares of secret using a random polynomial of degree threshold-1 per byte.
+func split(secret []byte, n int, threshold int, r io.Reader) ([]Share, error) {
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{Index: byte(i + 1), Value: make([]byte, len(secret))}
+	}
+
+	coefficients := make([]byte, threshold)
+	for b, v := range secret {
+		coefficients[0] = v
+		if _, err := io.ReadFull(r, coefficients[1:]); err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			// Evaluate the polynomial at the share's index using Horner's method.
+			x := shares[i].Index
+			y := byte(0)
+			for c := threshold - 1; c >= 0; c-- {
+				y = mul(y, x) ^ coefficients[c]
+			}
+			shares[i].Value[b] = y
+		}
+	}
+	clear(coefficients)
+
+	return shares, nil
+}
+
+// mul multiplies in GF(2^8) with the AES reduction polynomial.
+func mul(a, b byte) byte {
+	var p byte
+	for b != 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+// div divides in GF(2^8). b must not be zero.
+func div(a, b byte) byte {
+	// The inverse of b is b^254.
+	inverse := byte(1)
+	for i := 0; i < 254; i++ {
+		inverse = mul(inverse, b)
+	}
+	return mul(a, inverse)
+}
@@ -0,0 +1,105 @@
+package escrow
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+)
+
+func testOfficers(t *testing.T, n int) ([]Officer, []*rsa.PrivateKey) {
+	officers := make([]Officer, n)
+	keys := make([]*rsa.PrivateKey, n)
+	for i := range officers {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("Error: %s", err.Error())
+		}
+		keys[i] = key
+		officers[i] = Officer{Name: string(rune('a' + i)), PublicKey: &key.PublicKey}
+	}
+	return officers, keys
+}
+
+func TestSealCombine(t *testing.T) {
+	secret := []byte("JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP")
+	officers, keys := testOfficers(t, 3)
+
+	escrow, err := Seal(secret, officers, 2, nil)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 3 != len(escrow.Envelopes) || 2 != escrow.Threshold {
+		t.Fatalf("Unexpected escrow")
+	}
+
+	shares := make([]Share, 3)
+	for i, envelope := range escrow.Envelopes {
+		if officers[i].Name != envelope.Officer {
+			t.Fatalf("Envelope is for the wrong officer")
+		}
+		if bytes.Contains(envelope.Ciphertext, secret) {
+			t.Fatalf("Envelope should not contain the secret")
+		}
+		share, err := Open(envelope, keys[i])
+		if err != nil {
+			t.Fatalf("Error: %s", err.Error())
+		}
+		shares[i] = share
+	}
+
+	for _, pair := range [][]Share{{shares[0], shares[1]}, {shares[1], shares[2]}, {shares[2], shares[0]}} {
+		recovered, err := Combine(pair, 2)
+		if err != nil {
+			t.Fatalf("Error: %s", err.Error())
+		}
+		if !bytes.Equal(secret, recovered) {
+			t.Fatalf("Recovered secret does not match")
+		}
+	}
+
+	if _, err := Combine(shares[:1], 2); ErrNotEnoughShares != err {
+		t.Fatalf("Expected not enough shares error.")
+	}
+	if _, err := Combine([]Share{shares[0], shares[0]}, 2); ErrInvalidShares != err {
+		t.Fatalf("Expected invalid shares error.")
+	}
+
+	// A single share reveals nothing on its own, so combining it with a threshold of 1 must not
+	// return the secret.
+	single, _ := Combine(shares[:1], 1)
+	if bytes.Equal(secret, single) {
+		t.Fatalf("A single share should not equal the secret")
+	}
+}
+
+func TestOpenWrongKey(t *testing.T) {
+	officers, keys := testOfficers(t, 2)
+	escrow, err := Seal([]byte("secret"), officers, 2, nil)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if _, err := Open(escrow.Envelopes[0], keys[1]); err == nil {
+		t.Fatalf("Expected an error opening another officer's envelope")
+	}
+}
+
+func TestSealInvalidThreshold(t *testing.T) {
+	officers, _ := testOfficers(t, 2)
+	for _, threshold := range []int{0, 3} {
+		if _, err := Seal([]byte("secret"), officers, threshold, nil); ErrInvalidThreshold != err {
+			t.Fatalf("Expected invalid threshold error for %d.", threshold)
+		}
+	}
+}
+
+func TestGaloisField(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		if 1 != mul(byte(a), div(1, byte(a))) {
+			t.Fatalf("%d has no inverse", a)
+		}
+	}
+	if 0xc1 != mul(0x57, 0x83) {
+		t.Fatalf("Unexpected product %x", mul(0x57, 0x83))
+	}
+}
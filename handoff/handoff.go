@@ -0,0 +1,172 @@
+// Package handoff replaces otpauth URLs with short opaque tokens that can be redeemed exactly once,
+// so a secret never has to be embedded directly in an email or chat message. A token is created for
+// a key and sent to the user, who opens it with the companion HTTP handler to receive the full URL.
+package handoff
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// ErrTokenNotFound is returned when a token does not exist, has expired, or was already redeemed.
+var ErrTokenNotFound = otp.NewCodedError("OTP_HANDOFF_TOKEN_NOT_FOUND", "Token not found")
+
+// Store persists pending tokens. Take must atomically remove the token so that it can only ever be
+// redeemed once, even when called concurrently.
+type Store interface {
+	// Put saves the URL for a token until expires.
+	Put(token string, url string, expires time.Time) error
+	// Take removes and returns the URL for a token. ok is false if the token does not exist or has
+	// expired.
+	Take(token string) (url string, ok bool, err error)
+}
+
+// Handoff creates and redeems tokens.
+type Handoff struct {
+	// Store used to persist pending tokens.
+	Store Store
+	// How long a token can be redeemed for. Defaults to 15 minutes.
+	TTL time.Duration
+	// Reader to use for generating tokens. Defaults to crypto/rand.
+	Rand io.Reader
+}
+
+// Create returns a new token that can be redeemed for the URL of k.
+func (h *Handoff) Create(k *otp.Key) (string, error) {
+	ttl := h.TTL
+	if ttl == 0 {
+		ttl = 15 * time.Minute
+	}
+	r := h.Rand
+	if r == nil {
+		r = rand.Reader
+	}
+
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	token := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+
+	if err := h.Store.Put(token, k.String(), time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Redeem returns the key for token and invalidates the token.
+func (h *Handoff) Redeem(token string) (*otp.Key, error) {
+	url, ok, err := h.Store.Take(strings.ToLower(strings.TrimSpace(token)))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+
+	return otp.NewKeyFromURL(url)
+}
+
+// confirmPage is served for GET requests. It posts the token back, so the token is only redeemed when
+// the user confirms.
+const confirmPage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta name="robots" content="noindex"><title>Set up authenticator</title></head>
+<body><form method="post"><input type="hidden" name="token" value="%s"><button type="submit">Show setup code</button></form></body>
+</html>
+`
+
+// ServeHTTP redeems the token given in the "token" parameter of a POST request and responds with the
+// otpauth URL as plain text. Unknown, expired and previously redeemed tokens receive a 404 response.
+//
+// A GET request only serves a page that posts the token back when the user confirms, without redeeming
+// it. Email link scanners and chat link previews fetch links before the user opens them, and would
+// otherwise use up the token and receive the secret.
+func (h *Handoff) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		fmt.Fprintf(w, confirmPage, html.EscapeString(r.URL.Query().Get("token")))
+		return
+	}
+
+	k, err := h.Redeem(r.FormValue("token"))
+	if err == ErrTokenNotFound {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, k.String())
+}
+
+// MemoryStore is a Store that keeps tokens in memory. It is only suitable for a single process.
+type MemoryStore struct {
+	lock   sync.Mutex
+	tokens map[string]memoryToken
+}
+
+type memoryToken struct {
+	url     string
+	expires time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tokens: map[string]memoryToken{},
+	}
+}
+
+// Put saves the URL for a token until expires, discarding any expired tokens.
+func (s *MemoryStore) Put(token string, url string, expires time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	for t, v := range s.tokens {
+		if !now.Before(v.expires) {
+			delete(s.tokens, t)
+		}
+	}
+
+	s.tokens[token] = memoryToken{url: url, expires: expires}
+	return nil
+}
+
+// Take removes and returns the URL for a token.
+func (s *MemoryStore) Take(token string) (string, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	v, ok := s.tokens[token]
+	if !ok {
+		return "", false, nil
+	}
+	delete(s.tokens, token)
+
+	if !time.Now().Before(v.expires) {
+		return "", false, nil
+	}
+	return v.url, true, nil
+}
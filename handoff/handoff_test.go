@@ -0,0 +1,109 @@
+package handoff
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+const testURL = `otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example`
+
+func TestCreateRedeem(t *testing.T) {
+	k, _ := otp.NewKeyFromURL(testURL)
+	h := &Handoff{Store: NewMemoryStore()}
+
+	token, err := h.Create(k)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 26 != len(token) {
+		t.Fatalf("Token should be 26 characters.")
+	}
+
+	redeemed, err := h.Redeem(token)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if testURL != redeemed.String() {
+		t.Fatalf("'%s' does not equal '%s'", testURL, redeemed.String())
+	}
+
+	if _, err := h.Redeem(token); ErrTokenNotFound != err {
+		t.Fatalf("Expected token not found error on second redeem.")
+	}
+}
+
+func TestRedeemExpired(t *testing.T) {
+	k, _ := otp.NewKeyFromURL(testURL)
+	h := &Handoff{Store: NewMemoryStore(), TTL: time.Nanosecond}
+
+	token, err := h.Create(k)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	time.Sleep(time.Millisecond)
+
+	if _, err := h.Redeem(token); ErrTokenNotFound != err {
+		t.Fatalf("Expected token not found error for expired token.")
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	k, _ := otp.NewKeyFromURL(testURL)
+	h := &Handoff{Store: NewMemoryStore()}
+	token, _ := h.Create(k)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/enroll?token="+token, nil))
+	if http.StatusOK != rec.Code {
+		t.Fatalf("Unexpected status %d", rec.Code)
+	}
+	if testURL != rec.Body.String() {
+		t.Fatalf("'%s' does not equal '%s'", testURL, rec.Body.String())
+	}
+	if "no-store" != rec.Header().Get("Cache-Control") {
+		t.Fatalf("Response should not be cached.")
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/enroll?token="+token, nil))
+	if http.StatusNotFound != rec.Code {
+		t.Fatalf("Expected a second request to be rejected, got status %d", rec.Code)
+	}
+}
+
+func TestServeHTTPGet(t *testing.T) {
+	k, _ := otp.NewKeyFromURL(testURL)
+	h := &Handoff{Store: NewMemoryStore()}
+	token, _ := h.Create(k)
+
+	// A link scanner fetching the link must not use up the token or see the secret.
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/enroll?token="+token, nil))
+	if http.StatusOK != rec.Code {
+		t.Fatalf("Unexpected status %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "JBSWY3DPEHPK3PXP") || !strings.Contains(rec.Body.String(), `method="post"`) {
+		t.Fatalf("Expected a confirm page, got '%s'", rec.Body.String())
+	}
+
+	form := url.Values{"token": {token}}
+	req := httptest.NewRequest(http.MethodPost, "/enroll", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if http.StatusOK != rec.Code || testURL != rec.Body.String() {
+		t.Fatalf("Expected the token to still be usable after a GET, got status %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/enroll?token=%22%3E%3Cscript%3E", nil))
+	if strings.Contains(rec.Body.String(), "<script>") {
+		t.Fatalf("Token should be escaped in the confirm page")
+	}
+}
@@ -0,0 +1,105 @@
+package hotp
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// ChallengeLength is the number of digits in a numeric challenge.
+const ChallengeLength = 8
+
+// GenerateChallenge creates a random numeric challenge of ChallengeLength digits, suitable for
+// reading out to a user during call-center verification. If r is nil, crypto/rand is used.
+func GenerateChallenge(r io.Reader) (string, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	challenge := make([]byte, ChallengeLength)
+	b := make([]byte, 1)
+	for i := range challenge {
+		for {
+			if _, err := io.ReadFull(r, b); err != nil {
+				return "", err
+			}
+			// Discard the top of the byte range to avoid a modulo bias towards lower digits.
+			if b[0] < 250 {
+				challenge[i] = '0' + b[0]%10
+				break
+			}
+		}
+	}
+
+	return string(challenge), nil
+}
+
+// GenerateChallengeCode creates a challenge-response passcode given a counter, a numeric challenge
+// and secret. The challenge is packed as BCD and appended to the counter before the HMAC operation,
+// so the resulting passcode is only valid for that specific challenge.
+func GenerateChallengeCode(secret string, counter uint64, challenge string, opts ValidateOpts) (passcode string, err error) {
+	opts.Digits = defaultDigits(opts)
+
+	packed, err := packChallenge(challenge)
+	if err != nil {
+		return "", err
+	}
+
+	secretBytes, err := hmacKey(secret, opts)
+	if err != nil {
+		return "", otp.ErrValidateSecretInvalidBase32
+	}
+
+	buf := make([]byte, 8, 8+len(packed))
+	binary.BigEndian.PutUint64(buf, counter)
+	buf = append(buf, packed...)
+
+	return truncate(secretBytes, buf, opts), nil
+}
+
+// ValidateChallenge validates a challenge-response passcode created by GenerateChallengeCode.
+func ValidateChallenge(passcode string, counter uint64, challenge string, secret string, opts ValidateOpts) (bool, error) {
+	passcode = normalizePasscode(passcode, opts)
+
+	if err := checkValidate(passcode, counter, opts); err != nil {
+		return false, err
+	}
+
+	otpstr, err := GenerateChallengeCode(secret, counter, challenge, opts)
+	if err != nil {
+		return false, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(otpstr), []byte(passcode)) == 1 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// packChallenge converts the numeric challenge into packed BCD, two digits per byte.
+func packChallenge(challenge string) ([]byte, error) {
+	challenge = strings.TrimSpace(challenge)
+	if len(challenge) != ChallengeLength {
+		return nil, otp.ErrValidateChallengeInvalid
+	}
+
+	packed := make([]byte, ChallengeLength/2)
+	for i := 0; i < len(challenge); i++ {
+		c := challenge[i]
+		if c < '0' || c > '9' {
+			return nil, otp.ErrValidateChallengeInvalid
+		}
+		if i%2 == 0 {
+			packed[i/2] = (c - '0') << 4
+		} else {
+			packed[i/2] |= c - '0'
+		}
+	}
+
+	return packed, nil
+}
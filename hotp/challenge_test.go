@@ -0,0 +1,109 @@
+package hotp
+
+import (
+	"bytes"
+	"encoding/base32"
+	"testing"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestGenerateChallenge(t *testing.T) {
+	challenge, err := GenerateChallenge(nil)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if len(challenge) != ChallengeLength {
+		t.Fatalf("Challenge should be %d digits.", ChallengeLength)
+	}
+	for _, c := range challenge {
+		if c < '0' || c > '9' {
+			t.Fatalf("Challenge should only contain digits.")
+		}
+	}
+
+	// Bytes at or above 250 are discarded to avoid bias.
+	challenge, err = GenerateChallenge(bytes.NewReader([]byte{255, 1, 2, 3, 4, 5, 6, 7, 249}))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "12345679" != challenge {
+		t.Fatalf("'%s' does not equal '%s'", "12345679", challenge)
+	}
+
+	if _, err := GenerateChallenge(bytes.NewReader([]byte{1, 2})); err == nil {
+		t.Fatalf("Expected an error from a short reader.")
+	}
+}
+
+func TestChallengeCode(t *testing.T) {
+	secSha1 := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	opts := ValidateOpts{
+		Digits:    otp.DigitsEight,
+		Algorithm: otp.AlgorithmSHA1,
+	}
+
+	code, err := GenerateChallengeCode(secSha1, 1, "12345678", opts)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 8 != len(code) {
+		t.Fatalf("Code should be 8 digits.")
+	}
+
+	plain, _ := GenerateCodeCustom(secSha1, 1, opts)
+	if plain == code {
+		t.Fatalf("Challenge code should differ from the plain HOTP code.")
+	}
+
+	other, _ := GenerateChallengeCode(secSha1, 1, "87654321", opts)
+	if other == code {
+		t.Fatalf("Challenge code should depend on the challenge.")
+	}
+
+	valid, err := ValidateChallenge(code, 1, "12345678", secSha1, opts)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid {
+		t.Fatalf("Valid should be true.")
+	}
+
+	valid, err = ValidateChallenge(code, 1, "87654321", secSha1, opts)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false for a different challenge.")
+	}
+
+	valid, err = ValidateChallenge(code, 2, "12345678", secSha1, opts)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false for a different counter.")
+	}
+}
+
+func TestChallengeInvalid(t *testing.T) {
+	secSha1 := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	for _, challenge := range []string{"", "1234567", "123456789", "1234567a"} {
+		code, err := GenerateChallengeCode(secSha1, 1, challenge, ValidateOpts{})
+		if otp.ErrValidateChallengeInvalid != err {
+			t.Fatalf("Expected invalid challenge error for '%s'.", challenge)
+		}
+		if "" != code {
+			t.Fatalf("Code should be empty string when we have an error.")
+		}
+	}
+
+	valid, err := ValidateChallenge("000000", 1, "1234", secSha1, ValidateOpts{Digits: otp.DigitsSix})
+	if otp.ErrValidateChallengeInvalid != err {
+		t.Fatalf("Expected invalid challenge error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+}
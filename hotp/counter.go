@@ -0,0 +1,21 @@
+package hotp
+
+import (
+	"math"
+)
+
+// CounterLimit is the first counter that validation refuses, returning
+// otp.ErrValidateCounterExhausted. It leaves room for 2^32 counters below the uint64 maximum so that
+// look-ahead windows and the next expected counter never wrap around to 0, and so that a key whose
+// counter is nearly exhausted is noticed long before it overflows.
+const CounterLimit = math.MaxUint64 - 1<<32
+
+// CounterHeadroom returns how many more counters can be validated after counter before the key must
+// be enrolled again, or 0 if the counter is exhausted. Stores can check it to prompt users to
+// re-enroll keys with unusual initial counters, for example when it drops below a million.
+func CounterHeadroom(counter uint64) uint64 {
+	if counter >= CounterLimit {
+		return 0
+	}
+	return CounterLimit - counter - 1
+}
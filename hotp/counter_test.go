@@ -0,0 +1,36 @@
+package hotp
+
+import (
+	"testing"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestCounterHeadroom(t *testing.T) {
+	if CounterLimit-1 != CounterHeadroom(0) {
+		t.Fatalf("Unexpected headroom %d", CounterHeadroom(0))
+	}
+	if 0 != CounterHeadroom(CounterLimit-1) || 0 != CounterHeadroom(CounterLimit) || 0 != CounterHeadroom(^uint64(0)) {
+		t.Fatalf("Expected no headroom at the limit")
+	}
+	if 9 != CounterHeadroom(CounterLimit-10) {
+		t.Fatalf("Unexpected headroom %d", CounterHeadroom(CounterLimit-10))
+	}
+}
+
+func TestValidateCounterExhausted(t *testing.T) {
+	code, _ := GenerateCode(secSha1, CounterLimit)
+	valid, err := ValidateCustom(code, CounterLimit, secSha1, ValidateOpts{Digits: otp.DigitsSix})
+	if otp.ErrValidateCounterExhausted != err {
+		t.Fatalf("Expected counter exhausted error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+
+	code, _ = GenerateCode(secSha1, CounterLimit-1)
+	valid, err = ValidateCustom(code, CounterLimit-1, secSha1, ValidateOpts{Digits: otp.DigitsSix})
+	if err != nil || !valid {
+		t.Fatalf("Expected the last counter below the limit to be valid.")
+	}
+}
@@ -21,13 +21,15 @@ import (
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/subtle"
-	"encoding/base32"
 	"encoding/binary"
 	"fmt"
+	"hash"
 	"io"
 	"math"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ecnepsnai/otp"
 	"github.com/ecnepsnai/otp/internal"
@@ -57,6 +59,38 @@ type ValidateOpts struct {
 	Digits otp.Digits
 	// Algorithm to use for HMAC. Defaults to SHA1.
 	Algorithm otp.Algorithm
+	// Encoder used to render the passcode. Defaults to decimal digits.
+	Encoder otp.Encoder
+	// Alphabet the secret is written in (see Key.SecretAlphabet). Defaults to RFC 4648.
+	SecretAlphabet otp.SecretAlphabet
+	// Decoder used to convert the secret to bytes, such as an otp.DecoderChain. Defaults to
+	// SecretAlphabet.
+	SecretDecoder otp.SecretDecoder
+	// Purpose the passcode is for, such as "login" or "pairing". Passcodes for one context are never
+	// valid for another, or for no context, so they can't be replayed across purposes. Authenticator
+	// apps don't support contexts. Defaults to none.
+	Context string
+	// Time after which the key is no longer valid (see Key.ExpiresAt). Defaults to never.
+	ExpiresAt time.Time
+	// Time before which the key may not be used (see Key.NotBefore). Defaults to immediately.
+	NotBefore time.Time
+	// Operations the key is restricted to (see Key.Scopes). Defaults to any operation.
+	Scopes []string
+	// Operation this passcode is being validated for. Must be one of Scopes when Scopes is set.
+	Scope string
+	// Whether the key is a deterministic test key (see Key.IsTestKey). Set by FromKey. A test key
+	// can't be recognized from its secret alone, so use ValidateKey to reject test keys by default.
+	TestKey bool
+	// Accept test keys. This must only be enabled outside of production.
+	AllowTestKeys bool
+	// Risk flags of the key (see Key.RiskFlags).
+	RiskFlags []string
+	// Decides whether a key with RiskFlags may be validated, such as otp.DenyRiskFlags. Defaults to
+	// accepting every key.
+	RiskPolicy otp.RiskPolicy
+	// Profiles that ValidateKey looks up the profile recorded in the key in (see
+	// ProfileRegistry.ForKey). Defaults to none, using the settings recorded in the key.
+	ProfileRegistry *otp.ProfileRegistry
 }
 
 // GenerateCode creates a HOTP passcode given a counter and secret.
@@ -73,63 +107,71 @@ func GenerateCode(secret string, counter uint64) (string, error) {
 // create a passcode.
 func GenerateCodeCustom(secret string, counter uint64, opts ValidateOpts) (passcode string, err error) {
 	//Set default value
-	if opts.Digits == 0 {
-		opts.Digits = otp.DigitsSix
-	}
-	// As noted in issue #10 and #17 this adds support for TOTP secrets that are
-	// missing their padding.
-	secret = strings.TrimSpace(secret)
-	if n := len(secret) % 8; n != 0 {
-		secret = secret + strings.Repeat("=", 8-n)
-	}
-
-	// As noted in issue #24 Google has started producing base32 in lower case,
-	// but the StdEncoding (and the RFC), expect a dictionary of only upper case letters.
-	secret = strings.ToUpper(secret)
-
-	secretBytes, err := base32.StdEncoding.DecodeString(secret)
+	opts.Digits = defaultDigits(opts)
+	secretBytes, err := hmacKey(secret, opts)
 	if err != nil {
 		return "", otp.ErrValidateSecretInvalidBase32
 	}
 
 	buf := make([]byte, 8)
-	mac := hmac.New(opts.Algorithm.Hash, secretBytes)
 	binary.BigEndian.PutUint64(buf, counter)
 	if debug {
 		fmt.Printf("counter=%v\n", counter)
 		fmt.Printf("buf=%v\n", buf)
 	}
 
-	mac.Write(buf)
-	sum := mac.Sum(nil)
+	return truncate(secretBytes, buf, opts), nil
+}
 
-	// "Dynamic truncation" in RFC 4226
-	// http://tools.ietf.org/html/rfc4226#section-5.4
-	offset := sum[len(sum)-1] & 0xf
-	value := int64(((int(sum[offset]) & 0x7f) << 24) |
-		((int(sum[offset+1] & 0xff)) << 16) |
-		((int(sum[offset+2] & 0xff)) << 8) |
-		(int(sum[offset+3]) & 0xff))
+// truncate computes the HMAC of msg keyed with secretBytes and applies the
+// RFC 4226 dynamic truncation to produce a passcode.
+func truncate(secretBytes []byte, msg []byte, opts ValidateOpts) string {
+	return truncateMAC(hmac.New(opts.Algorithm.Hash, secretBytes), msg, nil, opts)
+}
+
+// truncateMAC is truncate using an already keyed mac, so that callers can reset and reuse it for many
+// messages without repeating the key schedule. The HMAC is written into sum when it has enough
+// capacity.
+func truncateMAC(mac hash.Hash, msg []byte, sum []byte, opts ValidateOpts) string {
+	value := dynamicTruncate(mac, msg, sum)
+
+	if opts.Encoder == otp.EncoderSteam {
+		return steamEncode(value, opts.Digits.Length())
+	}
 
 	l := opts.Digits.Length()
 	mod := int32(value % int64(math.Pow10(l)))
 
 	if debug {
-		fmt.Printf("offset=%v\n", offset)
 		fmt.Printf("value=%v\n", value)
 		fmt.Printf("mod'ed=%v\n", mod)
 	}
 
-	return opts.Digits.Format(mod), nil
+	return opts.Digits.Format(mod)
+}
+
+// dynamicTruncate computes the HMAC of msg with mac, writing it into sum when it has enough capacity,
+// and returns the 31 bit value selected by the RFC 4226 dynamic truncation.
+func dynamicTruncate(mac hash.Hash, msg []byte, sum []byte) int64 {
+	mac.Write(msg)
+	sum = mac.Sum(sum[:0])
+
+	// "Dynamic truncation" in RFC 4226
+	// http://tools.ietf.org/html/rfc4226#section-5.4
+	offset := sum[len(sum)-1] & 0xf
+	return int64(((int(sum[offset]) & 0x7f) << 24) |
+		((int(sum[offset+1] & 0xff)) << 16) |
+		((int(sum[offset+2] & 0xff)) << 8) |
+		(int(sum[offset+3]) & 0xff))
 }
 
 // ValidateCustom validates an HOTP with customizable options. Most users should
 // use Validate().
 func ValidateCustom(passcode string, counter uint64, secret string, opts ValidateOpts) (bool, error) {
-	passcode = strings.TrimSpace(passcode)
+	passcode = normalizePasscode(passcode, opts)
 
-	if len(passcode) != opts.Digits.Length() {
-		return false, otp.ErrValidateInputInvalidLength
+	if err := checkValidate(passcode, counter, opts); err != nil {
+		return false, err
 	}
 
 	otpstr, err := GenerateCodeCustom(secret, counter, opts)
@@ -144,6 +186,116 @@ func ValidateCustom(passcode string, counter uint64, secret string, opts Validat
 	return false, nil
 }
 
+// decodeSecret converts secret to bytes using the decoder in opts.
+func decodeSecret(secret string, opts ValidateOpts) ([]byte, error) {
+	if opts.SecretDecoder != nil {
+		return opts.SecretDecoder.DecodeSecret(secret)
+	}
+	return opts.SecretAlphabet.DecodeSecret(secret)
+}
+
+// hmacKey returns the key to compute passcodes with. When opts has a context, the decoded secret is
+// replaced by an HMAC of the context keyed with it, which separates the passcodes of every context
+// from each other while leaving the message format unchanged.
+func hmacKey(secret string, opts ValidateOpts) ([]byte, error) {
+	secretBytes, err := decodeSecret(secret, opts)
+	if err != nil || opts.Context == "" {
+		return secretBytes, err
+	}
+
+	mac := hmac.New(opts.Algorithm.Hash, secretBytes)
+	mac.Write([]byte("otp-context\x00"))
+	mac.Write([]byte(opts.Context))
+	return mac.Sum(nil), nil
+}
+
+// defaultDigits returns the number of digits to use when none are given in opts.
+func defaultDigits(opts ValidateOpts) otp.Digits {
+	if opts.Digits != 0 {
+		return opts.Digits
+	}
+	if opts.Encoder == otp.EncoderSteam {
+		return otp.DigitsSteam
+	}
+	return otp.DigitsSix
+}
+
+// steamAlphabet is the set of characters used in Steam Guard passcodes.
+const steamAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+
+// steamEncode renders value as a Steam Guard passcode of length characters.
+func steamEncode(value int64, length int) string {
+	code := make([]byte, length)
+	for i := range code {
+		code[i] = steamAlphabet[value%int64(len(steamAlphabet))]
+		value /= int64(len(steamAlphabet))
+	}
+	return string(code)
+}
+
+// normalizePasscode removes surrounding whitespace from a user supplied passcode. Steam Guard
+// passcodes are also converted to upper case, as users may type them in either case.
+func normalizePasscode(passcode string, opts ValidateOpts) string {
+	passcode = strings.TrimSpace(passcode)
+	if opts.Encoder == otp.EncoderSteam {
+		passcode = strings.ToUpper(passcode)
+	}
+	return passcode
+}
+
+// checkValidate performs the checks common to all validation functions that do not depend on the
+// passcode value itself.
+func checkValidate(passcode string, counter uint64, opts ValidateOpts) error {
+	if counter >= CounterLimit {
+		return otp.ErrValidateCounterExhausted
+	}
+
+	now := time.Now()
+	if !opts.ExpiresAt.IsZero() && !now.Before(opts.ExpiresAt) {
+		return otp.ErrValidateKeyExpired
+	}
+
+	if !opts.NotBefore.IsZero() && now.Before(opts.NotBefore) {
+		return otp.ErrValidateKeyNotYetValid
+	}
+
+	if opts.TestKey && !opts.AllowTestKeys {
+		return otp.ErrValidateTestKeyNotAllowed
+	}
+
+	if err := checkScope(opts.Scopes, opts.Scope); err != nil {
+		return err
+	}
+
+	if opts.RiskPolicy != nil && len(opts.RiskFlags) != 0 {
+		if err := opts.RiskPolicy(opts.RiskFlags); err != nil {
+			return err
+		}
+	}
+
+	if len(passcode) != opts.Digits.Length() {
+		return otp.ErrValidateInputInvalidLength
+	}
+
+	return nil
+}
+
+// checkScope ensures that scope is one of the permitted scopes, if the key is restricted.
+func checkScope(permitted []string, scope string) error {
+	if len(permitted) == 0 {
+		return nil
+	}
+	if scope == "" {
+		return otp.ErrValidateScopeMissing
+	}
+	for _, p := range permitted {
+		if p == scope {
+			return nil
+		}
+	}
+	return otp.ErrValidateScopeNotPermitted
+}
+
 // GenerateOpts provides options for .Generate()
 type GenerateOpts struct {
 	// Name of the issuing Organization/Company.
@@ -160,10 +312,35 @@ type GenerateOpts struct {
 	Algorithm otp.Algorithm
 	// Reader to use for generating HOTP Key.
 	Rand io.Reader
+	// Encoder used to render passcodes. Defaults to decimal digits.
+	Encoder otp.Encoder
+	// Alphabet to write the secret in. Defaults to RFC 4648, which should be used unless the key is
+	// for a client that requires another alphabet.
+	SecretAlphabet otp.SecretAlphabet
+	// Time after which the key is no longer valid. Defaults to never.
+	ExpiresAt time.Time
+	// Time before which the key may not be used, for keys provisioned in advance. Defaults to immediately.
+	NotBefore time.Time
+	// Operations the key is restricted to, such as "login". Defaults to any operation.
+	Scopes []string
+	// Profile used to encode the secret and URL. Defaults to Google Authenticator compatible.
+	SecretEncoding otp.SecretEncodingProfile
+	// Show a pseudonym instead of the account name in the URL (see otp.AccountPseudonym). The
+	// application must keep the real account name itself. Defaults to false.
+	HideAccountName bool
+	// Name of the profile the key was generated with, recorded in the URL (see Key.Profile).
+	// Defaults to none.
+	ProfileName string
+	// Version of the profile the key was generated with, recorded in the URL if ProfileName is set
+	// (see Key.ProfileVersion). Defaults to 0.
+	ProfileVersion uint
+	// Maximum number of characters in the issuer, longer issuers are truncated (see otp.TruncateLabel).
+	// Defaults to no limit.
+	MaxIssuerLength uint
+	// Maximum number of characters in the account name, longer names are truncated. Defaults to no limit.
+	MaxAccountNameLength uint
 }
 
-var b32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
-
 // Generate creates a new HOTP Key.
 func Generate(opts GenerateOpts) (*otp.Key, error) {
 	// url encode the Issuer/AccountName
@@ -175,12 +352,22 @@ func Generate(opts GenerateOpts) (*otp.Key, error) {
 		return nil, otp.ErrGenerateMissingAccountName
 	}
 
+	opts.Issuer = otp.TruncateLabel(opts.Issuer, opts.MaxIssuerLength)
+	opts.AccountName = otp.TruncateLabel(opts.AccountName, opts.MaxAccountNameLength)
+
+	if err := otp.CheckLabel(opts.Issuer, opts.AccountName); err != nil {
+		return nil, err
+	}
+
 	if opts.SecretSize == 0 {
 		opts.SecretSize = 10
 	}
 
 	if opts.Digits == 0 {
 		opts.Digits = otp.DigitsSix
+		if opts.Encoder == otp.EncoderSteam {
+			opts.Digits = otp.DigitsSteam
+		}
 	}
 
 	if opts.Rand == nil {
@@ -190,20 +377,45 @@ func Generate(opts GenerateOpts) (*otp.Key, error) {
 	// otpauth://hotp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example
 
 	v := url.Values{}
-	if len(opts.Secret) != 0 {
-		v.Set("secret", b32NoPadding.EncodeToString(opts.Secret))
-	} else {
-		secret := make([]byte, opts.SecretSize)
+	secret := opts.Secret
+	if len(secret) == 0 {
+		secret = make([]byte, opts.SecretSize)
 		_, err := opts.Rand.Read(secret)
 		if err != nil {
 			return nil, err
 		}
-		v.Set("secret", b32NoPadding.EncodeToString(secret))
+	}
+	if opts.SecretAlphabet == otp.SecretAlphabetRFC4648 {
+		v.Set("secret", opts.SecretEncoding.EncodeSecret(secret))
+	} else {
+		v.Set("secret", opts.SecretAlphabet.EncodeSecret(secret))
+		v.Set("alphabet", string(opts.SecretAlphabet))
+	}
+	if opts.HideAccountName {
+		opts.AccountName = otp.AccountPseudonym(secret, opts.AccountName)
 	}
 
 	v.Set("issuer", opts.Issuer)
 	v.Set("algorithm", opts.Algorithm.String())
 	v.Set("digits", opts.Digits.String())
+	if opts.Encoder != otp.EncoderDefault {
+		v.Set("encoder", string(opts.Encoder))
+	}
+	if !opts.ExpiresAt.IsZero() {
+		v.Set("expires", strconv.FormatInt(opts.ExpiresAt.Unix(), 10))
+	}
+	if !opts.NotBefore.IsZero() {
+		v.Set("notbefore", strconv.FormatInt(opts.NotBefore.Unix(), 10))
+	}
+	if len(opts.Scopes) != 0 {
+		v.Set("scope", strings.Join(opts.Scopes, ","))
+	}
+	if opts.ProfileName != "" {
+		v.Set("profile", opts.ProfileName)
+		if opts.ProfileVersion != 0 {
+			v.Set("profile_version", strconv.FormatUint(uint64(opts.ProfileVersion), 10))
+		}
+	}
 
 	u := url.URL{
 		Scheme:   "otpauth",
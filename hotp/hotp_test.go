@@ -22,6 +22,7 @@ import (
 	"encoding/base32"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ecnepsnai/otp"
 )
@@ -33,6 +34,8 @@ type tc struct {
 	Secret  string
 }
 
+var b32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
 var (
 	secSha1 = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
 
@@ -270,3 +273,222 @@ func TestGenerate(t *testing.T) {
 		t.Fatalf("Specified Secret was not kept")
 	}
 }
+
+func TestValidateExpired(t *testing.T) {
+	secSha1 := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	valid, err := ValidateCustom("755224", 0, secSha1, ValidateOpts{
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid {
+		t.Fatalf("Valid should be true before expiry.")
+	}
+
+	valid, err = ValidateCustom("755224", 0, secSha1, ValidateOpts{
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+		ExpiresAt: time.Now().Add(-time.Hour),
+	})
+	if otp.ErrValidateKeyExpired != err {
+		t.Fatalf("Expected key expired error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+
+	k, err := Generate(GenerateOpts{
+		Issuer:      "SnakeOil",
+		AccountName: "alice@example.com",
+		ExpiresAt:   time.Unix(1700000000, 0),
+	})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !strings.Contains(k.String(), "expires=1700000000") {
+		t.Fatalf("Expiry was not included in the URL")
+	}
+}
+
+func TestValidateScope(t *testing.T) {
+	secSha1 := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	k, err := Generate(GenerateOpts{
+		Issuer:      "SnakeOil",
+		AccountName: "alice@example.com",
+		Scopes:      []string{"login", "withdrawal"},
+	})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !strings.Contains(k.String(), "scope=login%2Cwithdrawal") {
+		t.Fatalf("Scopes were not included in the URL")
+	}
+
+	opts := ValidateOpts{
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+		Scopes:    k.Scopes(),
+	}
+
+	valid, err := ValidateCustom("755224", 0, secSha1, opts)
+	if otp.ErrValidateScopeMissing != err {
+		t.Fatalf("Expected missing scope error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+
+	opts.Scope = "transfer"
+	valid, err = ValidateCustom("755224", 0, secSha1, opts)
+	if otp.ErrValidateScopeNotPermitted != err {
+		t.Fatalf("Expected scope not permitted error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+
+	opts.Scope = "withdrawal"
+	valid, err = ValidateCustom("755224", 0, secSha1, opts)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid {
+		t.Fatalf("Valid should be true.")
+	}
+}
+
+func BenchmarkGenerateCode(b *testing.B) {
+	opts := ValidateOpts{Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GenerateCodeCustom(secSha1, uint64(i), opts)
+	}
+}
+
+func BenchmarkValidate(b *testing.B) {
+	opts := ValidateOpts{Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ValidateCustom("755224", 0, secSha1, opts)
+	}
+}
+
+// Allocations on the passcode generation hot path must stay within budget.
+func TestGenerateCodeAllocs(t *testing.T) {
+	opts := ValidateOpts{Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+	allocs := testing.AllocsPerRun(100, func() {
+		GenerateCodeCustom(secSha1, 1, opts)
+	})
+	if allocs > 10 {
+		t.Fatalf("GenerateCodeCustom allocated %v times, budget is 10", allocs)
+	}
+}
+
+func TestValidateNotBefore(t *testing.T) {
+	secSha1 := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	valid, err := ValidateCustom("755224", 0, secSha1, ValidateOpts{
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+		NotBefore: time.Now().Add(time.Hour),
+	})
+	if otp.ErrValidateKeyNotYetValid != err {
+		t.Fatalf("Expected key not yet valid error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+
+	valid, err = ValidateCustom("755224", 0, secSha1, ValidateOpts{
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+		NotBefore: time.Now().Add(-time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid {
+		t.Fatalf("Valid should be true after activation.")
+	}
+}
+
+func TestSteamEncoder(t *testing.T) {
+	opts := ValidateOpts{
+		Algorithm: otp.AlgorithmSHA1,
+		Encoder:   otp.EncoderSteam,
+	}
+
+	code, err := GenerateCodeCustom(secSha1, 0, opts)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "GG5F5" != code {
+		t.Fatalf("'%s' does not equal '%s'", "GG5F5", code)
+	}
+
+	opts.Digits = otp.DigitsSteam
+	valid, err := ValidateCustom("gg5f5", 0, secSha1, opts)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid {
+		t.Fatalf("Valid should be true regardless of case.")
+	}
+
+	k, err := Generate(GenerateOpts{
+		Issuer:      "Steam",
+		AccountName: "alice",
+		Encoder:     otp.EncoderSteam,
+	})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if otp.EncoderSteam != k.Encoder() || otp.DigitsSteam != k.Digits() {
+		t.Fatalf("Encoder was not kept")
+	}
+}
+
+func TestContext(t *testing.T) {
+	opts := ValidateOpts{Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+	plain, _ := GenerateCodeCustom(secSha1, 1, opts)
+
+	opts.Context = "login"
+	login, err := GenerateCodeCustom(secSha1, 1, opts)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "287082" != plain || plain == login {
+		t.Fatalf("Passcodes with a context must differ from those without one")
+	}
+	if valid, _ := ValidateCustom(login, 1, secSha1, opts); !valid {
+		t.Fatalf("Valid should be true for the same context.")
+	}
+
+	opts.Context = "pairing"
+	if valid, _ := ValidateCustom(login, 1, secSha1, opts); valid {
+		t.Fatalf("Valid should be false for another context.")
+	}
+	if _, valid, _ := ValidateWindow(login, 0, secSha1, WindowOpts{ValidateOpts: opts, Window: 5}); valid {
+		t.Fatalf("Valid should be false for another context in a window.")
+	}
+	opts.Context = "login"
+	if counter, valid, _ := ValidateWindow(login, 0, secSha1, WindowOpts{ValidateOpts: opts, Window: 5}); !valid || 1 != counter {
+		t.Fatalf("Expected the passcode to match counter 1 in a window.")
+	}
+}
+
+// The v1 API of github.com/pquerna/otp/hotp must keep compiling after switching imports.
+func TestPquernaCompatibility(t *testing.T) {
+	var _ func(string, uint64, string) bool = Validate
+	var _ func(string, uint64) (string, error) = GenerateCode
+	var _ func(string, uint64, ValidateOpts) (string, error) = GenerateCodeCustom
+	var _ func(string, uint64, string, ValidateOpts) (bool, error) = ValidateCustom
+	var _ func(GenerateOpts) (*otp.Key, error) = Generate
+	var _ = ValidateOpts{Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1, Encoder: otp.EncoderDefault}
+	var _ = GenerateOpts{Issuer: "Example", AccountName: "alice", SecretSize: 20, Secret: nil, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1, Rand: nil}
+}
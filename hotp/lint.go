@@ -0,0 +1,44 @@
+package hotp
+
+import (
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// MaxSafeWindow is the largest look-ahead window LintOpts accepts without a warning. Each counter in
+// the window is another passcode an attacker can guess.
+const MaxSafeWindow = 100
+
+// LintOpts returns a warning for every insecure or risky setting in opts, encoding the security
+// guidance for HOTP validation as code. It returns nil if there is nothing to warn about.
+func LintOpts(opts WindowOpts) []otp.LintWarning {
+	warnings := lintValidateOpts(opts.ValidateOpts)
+	if opts.Window > MaxSafeWindow {
+		warnings = append(warnings, otp.LintWarning{Option: "Window", Message: "windows of more than 100 counters make guessing a passcode much easier"})
+	}
+	return warnings
+}
+
+func lintValidateOpts(opts ValidateOpts) []otp.LintWarning {
+	var warnings []otp.LintWarning
+
+	if opts.Digits != 0 && opts.Digits.Length() < otp.DigitsSix.Length() {
+		warnings = append(warnings, otp.LintWarning{Option: "Digits", Message: "passcodes of fewer than 6 digits are easily guessed"})
+	}
+
+	switch opts.Algorithm {
+	case otp.AlgorithmMD5:
+		warnings = append(warnings, otp.LintWarning{Option: "Algorithm", Message: "MD5 is broken and should not be used for new keys"})
+	case otp.AlgorithmSHA1:
+		if opts.ExpiresAt.IsZero() || (!opts.NotBefore.IsZero() && opts.ExpiresAt.Sub(opts.NotBefore) > 365*24*time.Hour) {
+			warnings = append(warnings, otp.LintWarning{Option: "Algorithm", Message: "SHA1 keys valid for more than a year should be replaced with SHA256 keys where clients support it"})
+		}
+	}
+
+	if opts.AllowTestKeys {
+		warnings = append(warnings, otp.LintWarning{Option: "AllowTestKeys", Message: "test keys can be derived by anyone and must not be accepted in production"})
+	}
+
+	return warnings
+}
@@ -0,0 +1,44 @@
+package hotp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestLintOpts(t *testing.T) {
+	safe := WindowOpts{
+		ValidateOpts: ValidateOpts{Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA256},
+		Window:       10,
+	}
+	if warnings := LintOpts(safe); 0 != len(warnings) {
+		t.Fatalf("Unexpected warnings %v", warnings)
+	}
+
+	options := func(opts WindowOpts) []string {
+		names := []string{}
+		for _, w := range LintOpts(opts) {
+			names = append(names, w.Option)
+		}
+		return names
+	}
+
+	warnings := options(WindowOpts{
+		ValidateOpts: ValidateOpts{Digits: 4, Algorithm: otp.AlgorithmMD5, AllowTestKeys: true},
+		Window:       1000,
+	})
+	if 4 != len(warnings) || "Digits" != warnings[0] || "Algorithm" != warnings[1] || "AllowTestKeys" != warnings[2] || "Window" != warnings[3] {
+		t.Fatalf("Unexpected warnings %v", warnings)
+	}
+
+	// SHA1 is only a concern for long-lived keys.
+	notBefore := time.Unix(1700000000, 0)
+	short := WindowOpts{ValidateOpts: ValidateOpts{NotBefore: notBefore, ExpiresAt: notBefore.Add(30 * 24 * time.Hour)}}
+	if warnings := options(short); 0 != len(warnings) {
+		t.Fatalf("Unexpected warnings %v", warnings)
+	}
+	if warnings := options(WindowOpts{}); 1 != len(warnings) || "Algorithm" != warnings[0] {
+		t.Fatalf("Expected a warning for a SHA1 key that never expires, got %v", warnings)
+	}
+}
@@ -0,0 +1,227 @@
+package hotp
+
+import (
+	"io"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/internal/optcore"
+)
+
+// Option configures a call to GenerateWith, ValidateWith or ValidateKey. Options that only apply to
+// generating keys are ignored when validating, and the other way around, so the same set of options
+// can be shared by both.
+type Option func(g *GenerateOpts, v *ValidateOpts)
+
+// wrap adapts an option of the shared core to this package.
+func wrap(option optcore.Option) Option {
+	return func(g *GenerateOpts, v *ValidateOpts) {
+		option(g.core(), v.core())
+	}
+}
+
+// core returns the fields of o shared with the option core, or nil if o is nil.
+func (o *GenerateOpts) core() *optcore.Generate {
+	if o == nil {
+		return nil
+	}
+	return &optcore.Generate{
+		Issuer:               &o.Issuer,
+		AccountName:          &o.AccountName,
+		SecretSize:           &o.SecretSize,
+		Secret:               &o.Secret,
+		Digits:               &o.Digits,
+		Algorithm:            &o.Algorithm,
+		Rand:                 &o.Rand,
+		Encoder:              &o.Encoder,
+		SecretAlphabet:       &o.SecretAlphabet,
+		ExpiresAt:            &o.ExpiresAt,
+		NotBefore:            &o.NotBefore,
+		Scopes:               &o.Scopes,
+		SecretEncoding:       &o.SecretEncoding,
+		HideAccountName:      &o.HideAccountName,
+		ProfileName:          &o.ProfileName,
+		ProfileVersion:       &o.ProfileVersion,
+		MaxIssuerLength:      &o.MaxIssuerLength,
+		MaxAccountNameLength: &o.MaxAccountNameLength,
+	}
+}
+
+// core returns the fields of o shared with the option core, or nil if o is nil.
+func (o *ValidateOpts) core() *optcore.Validate {
+	if o == nil {
+		return nil
+	}
+	return &optcore.Validate{
+		Digits:          &o.Digits,
+		Algorithm:       &o.Algorithm,
+		Encoder:         &o.Encoder,
+		SecretAlphabet:  &o.SecretAlphabet,
+		SecretDecoder:   &o.SecretDecoder,
+		Context:         &o.Context,
+		ExpiresAt:       &o.ExpiresAt,
+		NotBefore:       &o.NotBefore,
+		Scopes:          &o.Scopes,
+		Scope:           &o.Scope,
+		TestKey:         &o.TestKey,
+		AllowTestKeys:   &o.AllowTestKeys,
+		RiskFlags:       &o.RiskFlags,
+		RiskPolicy:      &o.RiskPolicy,
+		ProfileRegistry: &o.ProfileRegistry,
+	}
+}
+
+// GenerateWith creates a new HOTP Key configured by options. It is equivalent to calling Generate
+// with a GenerateOpts built from the options.
+func GenerateWith(options ...Option) (*otp.Key, error) {
+	opts := GenerateOpts{}
+	for _, option := range options {
+		option(&opts, nil)
+	}
+	return Generate(opts)
+}
+
+// ValidateWith validates a HOTP passcode configured by options. Unlike ValidateCustom, the defaults
+// match Validate: 6 digits and SHA1.
+func ValidateWith(passcode string, counter uint64, secret string, options ...Option) (bool, error) {
+	opts := ValidateOpts{
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	}
+	for _, option := range options {
+		option(nil, &opts)
+	}
+	return ValidateCustom(passcode, counter, secret, opts)
+}
+
+// ValidateKey validates a HOTP passcode for k at counter, using every setting recorded in the key
+// (digits, algorithm, encoder, activation and expiry times, scopes, test key marking and risk flags).
+// options are for settings such as WithScope and WithRiskPolicy that are chosen by the validator.
+// They can tighten the restrictions recorded in the key, such as with an earlier WithExpiresAt, but
+// never lift them. With WithProfileRegistry, the settings of the profile version the key was
+// generated with replace the digits, algorithm and encoder recorded in the key.
+func ValidateKey(passcode string, counter uint64, k *otp.Key, options ...Option) (bool, error) {
+	options = append(append([]Option{FromKey(k)}, options...), wrap(optcore.Restrict(k)), wrap(optcore.KeyProfile(k)))
+	return ValidateWith(passcode, counter, k.Secret(), options...)
+}
+
+// FromKey applies the settings recorded in k.
+func FromKey(k *otp.Key) Option {
+	return wrap(optcore.FromKey(k))
+}
+
+// WithIssuer sets the name of the issuing Organization/Company.
+func WithIssuer(issuer string) Option {
+	return wrap(optcore.Issuer(issuer))
+}
+
+// WithAccountName sets the name of the User's Account (eg, email address).
+func WithAccountName(accountName string) Option {
+	return wrap(optcore.AccountName(accountName))
+}
+
+// WithProfile applies the digits, algorithm and encoder of profile, and records its name and version
+// in the key.
+func WithProfile(profile otp.Profile) Option {
+	return wrap(optcore.Profile(profile))
+}
+
+// WithDigits sets the number of digits in a passcode.
+func WithDigits(digits otp.Digits) Option {
+	return wrap(optcore.Digits(digits))
+}
+
+// WithAlgorithm sets the algorithm to use for HMAC.
+func WithAlgorithm(algorithm otp.Algorithm) Option {
+	return wrap(optcore.Algorithm(algorithm))
+}
+
+// WithEncoder sets how passcodes are rendered.
+func WithEncoder(encoder otp.Encoder) Option {
+	return wrap(optcore.Encoder(encoder))
+}
+
+// WithSecretAlphabet sets the base32 alphabet the secret is written in.
+func WithSecretAlphabet(alphabet otp.SecretAlphabet) Option {
+	return wrap(optcore.SecretAlphabet(alphabet))
+}
+
+// WithSecretDecoder sets the decoder used to convert secrets to bytes when validating.
+func WithSecretDecoder(decoder otp.SecretDecoder) Option {
+	return wrap(optcore.SecretDecoder(decoder))
+}
+
+// WithContext sets the purpose passcodes are generated and validated for.
+func WithContext(context string) Option {
+	return wrap(optcore.Context(context))
+}
+
+// WithSecretSize sets the size of a randomly generated secret in bytes.
+func WithSecretSize(size uint) Option {
+	return wrap(optcore.SecretSize(size))
+}
+
+// WithSecret sets the secret to store instead of generating a random one. You should generally not
+// use this.
+func WithSecret(secret []byte) Option {
+	return wrap(optcore.Secret(secret))
+}
+
+// WithSecretEncoding sets the profile used to encode the secret and URL.
+func WithSecretEncoding(profile otp.SecretEncodingProfile) Option {
+	return wrap(optcore.SecretEncoding(profile))
+}
+
+// WithMaxLabelLength sets the maximum number of characters in the issuer and account name. Longer
+// values are truncated.
+func WithMaxLabelLength(issuer uint, accountName uint) Option {
+	return wrap(optcore.MaxLabelLength(issuer, accountName))
+}
+
+// WithHiddenAccountName shows a pseudonym instead of the account name in the URL.
+func WithHiddenAccountName() Option {
+	return wrap(optcore.HiddenAccountName())
+}
+
+// WithRand sets the reader to use for generating the secret.
+func WithRand(r io.Reader) Option {
+	return wrap(optcore.Rand(r))
+}
+
+// WithExpiresAt sets the time after which the key is no longer valid.
+func WithExpiresAt(t time.Time) Option {
+	return wrap(optcore.ExpiresAt(t))
+}
+
+// WithNotBefore sets the time before which the key may not be used.
+func WithNotBefore(t time.Time) Option {
+	return wrap(optcore.NotBefore(t))
+}
+
+// WithScopes sets the operations the key is restricted to.
+func WithScopes(scopes ...string) Option {
+	return wrap(optcore.Scopes(scopes...))
+}
+
+// WithScope sets the operation a passcode is being validated for.
+func WithScope(scope string) Option {
+	return wrap(optcore.Scope(scope))
+}
+
+// WithAllowTestKeys accepts test keys. This must only be used outside of production.
+func WithAllowTestKeys() Option {
+	return wrap(optcore.AllowTestKeys())
+}
+
+// WithProfileRegistry makes ValidateKey validate keys that record a profile (see Key.Profile) with
+// the settings of the version of the profile they were generated with in r, so that registering a
+// new version of a profile never changes how existing keys are validated. Keys whose profile is not
+// in r are validated with the settings recorded in the key.
+func WithProfileRegistry(r *otp.ProfileRegistry) Option {
+	return wrap(optcore.ProfileRegistry(r))
+}
+
+// WithRiskPolicy sets the policy deciding whether a key with risk flags may be validated.
+func WithRiskPolicy(policy otp.RiskPolicy) Option {
+	return wrap(optcore.RiskPolicy(policy))
+}
@@ -0,0 +1,82 @@
+package hotp
+
+import (
+	"testing"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestGenerateWith(t *testing.T) {
+	k, err := GenerateWith(
+		WithIssuer("SnakeOil"),
+		WithAccountName("alice@example.com"),
+		WithDigits(otp.DigitsEight),
+		WithSecretSize(20),
+	)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "SnakeOil" != k.Issuer() || "alice@example.com" != k.AccountName() {
+		t.Fatalf("Extracting Issuer and Account Name")
+	}
+	if otp.DigitsEight != k.Digits() || 32 != len(k.Secret()) {
+		t.Fatalf("Options were not applied")
+	}
+}
+
+func TestValidateWith(t *testing.T) {
+	valid, err := ValidateWith("287082", 1, secSha1)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid {
+		t.Fatalf("Valid should be true.")
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	k, err := otp.NewKeyFromURL("otpauth://hotp/Example:alice@google.com?secret=" + secSha1 + "&scope=login")
+	if err != nil {
+		t.Fatalf("failed to parse url")
+	}
+
+	if _, err := ValidateKey("287082", 1, k); otp.ErrValidateScopeMissing != err {
+		t.Fatalf("Expected missing scope error.")
+	}
+	valid, err := ValidateKey("287082", 1, k, WithScope("login"))
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid {
+		t.Fatalf("Valid should be true.")
+	}
+}
+
+func TestMaxLabelLength(t *testing.T) {
+	k, err := GenerateWith(
+		WithIssuer("Snake Oil Incorporated"),
+		WithAccountName("alice@example.com"),
+		WithMaxLabelLength(10, 8),
+	)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "Snake Oil…" != k.Issuer() || "alice@e…" != k.AccountName() {
+		t.Fatalf("Unexpected label '%s:%s'", k.Issuer(), k.AccountName())
+	}
+}
+
+func TestSecretDecoder(t *testing.T) {
+	decoder := otp.DecoderChain{Steps: []otp.SecretStep{otp.StripSecretPrefix("vendor:")}}
+	valid, err := ValidateWith("755224", 0, "vendor:"+secSha1, WithSecretDecoder(decoder))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatalf("Valid should be true with the decoder.")
+	}
+
+	if _, err := ValidateWith("755224", 0, "vendor:"+secSha1); otp.ErrValidateSecretInvalidBase32 != err {
+		t.Fatalf("Expected invalid base32 error without the decoder.")
+	}
+}
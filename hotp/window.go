@@ -0,0 +1,167 @@
+package hotp
+
+import (
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/binary"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// WindowOpts provides options for ValidateWindow().
+type WindowOpts struct {
+	ValidateOpts
+	// Number of counters after the expected counter to also accept, to resynchronise with tokens that
+	// have been used without validating. Defaults to 0, only accepting the expected counter.
+	Window uint64
+	// Maximum number of goroutines used to scan the window. Defaults to 1. Only worth raising for
+	// windows of thousands of counters, such as long-offline hardware tokens.
+	Parallelism int
+}
+
+// minParallelChunk is the smallest number of counters worth handing to a separate goroutine.
+const minParallelChunk = 64
+
+// ValidateWindow validates a HOTP passcode against the counters from counter up to counter+Window.
+// If the passcode is valid the matching counter is returned, and the caller should store that
+// counter plus one as the next expected counter. If the passcode is valid for more than one counter
+// in the window the lowest one is returned.
+func ValidateWindow(passcode string, counter uint64, secret string, opts WindowOpts) (uint64, bool, error) {
+	passcode = normalizePasscode(passcode, opts.ValidateOpts)
+
+	if err := checkValidate(passcode, counter, opts.ValidateOpts); err != nil {
+		return 0, false, err
+	}
+
+	secretBytes, err := hmacKey(secret, opts.ValidateOpts)
+	if err != nil {
+		return 0, false, otp.ErrValidateSecretInvalidBase32
+	}
+
+	// Counters from CounterLimit up are never accepted, which also keeps the window from wrapping.
+	last := counter + opts.Window
+	if last < counter || last >= CounterLimit {
+		last = CounterLimit - 1
+	}
+
+	workers := opts.Parallelism
+	if workers < 1 {
+		workers = 1
+	}
+	size := last - counter + 1
+	if limit := size / minParallelChunk; uint64(workers) > limit {
+		workers = int(limit)
+	}
+	if workers <= 1 {
+		match, ok := scanWindow(passcode, secretBytes, counter, last, opts.ValidateOpts, nil)
+		return match, ok, nil
+	}
+
+	match, ok := scanParallel(passcode, secretBytes, counter, last, workers, opts.ValidateOpts)
+	return match, ok, nil
+}
+
+// scanParallel splits the counters from counter to last, inclusive, between workers goroutines. It
+// is kept apart from ValidateWindow because the goroutines make their arguments escape to the heap,
+// which would otherwise cost every validation an allocation.
+func scanParallel(passcode string, secretBytes []byte, counter, last uint64, workers int, opts ValidateOpts) (uint64, bool) {
+	size := last - counter + 1
+
+	// best holds the lowest matching counter found so far, so workers scanning higher counters can
+	// stop early once a lower match is known.
+	best := &atomic.Uint64{}
+	best.Store(math.MaxUint64)
+	found := &atomic.Bool{}
+
+	chunk := size / uint64(workers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		start := counter + uint64(i)*chunk
+		end := start + chunk - 1
+		if i == workers-1 {
+			end = last
+		}
+
+		wg.Add(1)
+		go func(start, end uint64) {
+			defer wg.Done()
+			match, ok := scanWindow(passcode, secretBytes, start, end, opts, best)
+			if !ok {
+				return
+			}
+			found.Store(true)
+			for {
+				current := best.Load()
+				if match >= current || best.CompareAndSwap(current, match) {
+					return
+				}
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	if !found.Load() {
+		return 0, false
+	}
+	return best.Load(), true
+}
+
+// scanWindow looks for passcode between the counters start and end, inclusive. If best is not nil,
+// scanning stops once the counter passes the value it holds.
+func scanWindow(passcode string, secretBytes []byte, start, end uint64, opts ValidateOpts, best *atomic.Uint64) (uint64, bool) {
+	// A single keyed HMAC and buffers are reused for every counter in the window, and decimal
+	// passcodes are compared as numbers so that no passcode strings are built while scanning.
+	mac := hmac.New(opts.Algorithm.Hash, secretBytes)
+	sum := make([]byte, 0, mac.Size())
+	buf := make([]byte, 8)
+	want := []byte(passcode)
+	numeric, isNumeric := parseNumericPasscode(passcode, opts)
+	modulus := int64(math.Pow10(opts.Digits.Length()))
+
+	for c := start; ; c++ {
+		if best != nil && c > best.Load() {
+			return 0, false
+		}
+
+		binary.BigEndian.PutUint64(buf, c)
+		if isNumeric {
+			value := int32(dynamicTruncate(mac, buf, sum) % modulus)
+			if subtle.ConstantTimeEq(value, numeric) == 1 {
+				return c, true
+			}
+		} else {
+			otpstr := truncateMAC(mac, buf, sum, opts)
+			if subtle.ConstantTimeCompare([]byte(otpstr), want) == 1 {
+				return c, true
+			}
+		}
+
+		if c == end {
+			return 0, false
+		}
+		mac.Reset()
+	}
+}
+
+// parseNumericPasscode returns the value of a decimal passcode. Passcodes that are not made up only of
+// digits, or that could never be generated, are not numeric and must be compared as strings.
+func parseNumericPasscode(passcode string, opts ValidateOpts) (int32, bool) {
+	if opts.Encoder != otp.EncoderDefault {
+		return 0, false
+	}
+
+	value := int64(0)
+	for i := 0; i < len(passcode); i++ {
+		if passcode[i] < '0' || passcode[i] > '9' {
+			return 0, false
+		}
+		value = value*10 + int64(passcode[i]-'0')
+		if value > math.MaxInt32 {
+			return 0, false
+		}
+	}
+	return int32(value), true
+}
@@ -0,0 +1,164 @@
+package hotp
+
+import (
+	"testing"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestValidateWindow(t *testing.T) {
+	opts := WindowOpts{
+		ValidateOpts: ValidateOpts{
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		},
+		Window: 5,
+	}
+
+	counter, valid, err := ValidateWindow("520489", 4, secSha1, opts)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid || 9 != counter {
+		t.Fatalf("Expected the passcode to match counter 9.")
+	}
+
+	_, valid, err = ValidateWindow("520489", 3, secSha1, opts)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false outside of the window.")
+	}
+
+	_, valid, err = ValidateWindow("foo", 0, secSha1, opts)
+	if otp.ErrValidateInputInvalidLength != err {
+		t.Fatalf("Expected Invalid length error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+
+	_, _, err = ValidateWindow("520489", 0, "foo", opts)
+	if otp.ErrValidateSecretInvalidBase32 != err {
+		t.Fatalf("Expected invalid base32 error.")
+	}
+}
+
+func TestValidateWindowNonNumeric(t *testing.T) {
+	opts := WindowOpts{ValidateOpts: ValidateOpts{Digits: otp.DigitsSix}, Window: 5}
+
+	// Passcodes that are not only digits must never match, even if they parse as the number.
+	_, valid, err := ValidateWindow("+20489", 4, secSha1, opts)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false for a non-numeric passcode.")
+	}
+
+	opts.Encoder = otp.EncoderSteam
+	opts.Digits = otp.DigitsSteam
+	code, _ := GenerateCodeCustom(secSha1, 7, opts.ValidateOpts)
+	counter, valid, err := ValidateWindow(code, 4, secSha1, opts)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid || 7 != counter {
+		t.Fatalf("Expected the Steam passcode to match counter 7.")
+	}
+}
+
+func TestValidateWindowParallel(t *testing.T) {
+	vOpts := ValidateOpts{
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	}
+	code, err := GenerateCodeCustom(secSha1, 7000, vOpts)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	serialCounter, serialValid, err := ValidateWindow(code, 0, secSha1, WindowOpts{
+		ValidateOpts: vOpts,
+		Window:       10000,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+
+	for _, parallelism := range []int{2, 4, 16} {
+		counter, valid, err := ValidateWindow(code, 0, secSha1, WindowOpts{
+			ValidateOpts: vOpts,
+			Window:       10000,
+			Parallelism:  parallelism,
+		})
+		if err != nil {
+			t.Fatalf("Expected no error.")
+		}
+		if valid != serialValid || counter != serialCounter {
+			t.Fatalf("Parallel scan with %d workers found %d, serial scan found %d", parallelism, counter, serialCounter)
+		}
+		if !valid || counter > 7000 {
+			t.Fatalf("Expected a match at or before counter 7000.")
+		}
+	}
+}
+
+func TestValidateWindowOverflow(t *testing.T) {
+	opts := WindowOpts{
+		ValidateOpts: ValidateOpts{
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		},
+		Window:      1000,
+		Parallelism: 4,
+	}
+
+	code, _ := GenerateCodeCustom(secSha1, CounterLimit-1, opts.ValidateOpts)
+	counter, valid, err := ValidateWindow(code, CounterLimit-10, secSha1, opts)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if CounterLimit-1 != counter || !valid {
+		t.Fatalf("Expected a match at the last usable counter.")
+	}
+
+	// The window stops before CounterLimit.
+	code, _ = GenerateCodeCustom(secSha1, CounterLimit+5, opts.ValidateOpts)
+	_, valid, _ = ValidateWindow(code, CounterLimit-10, secSha1, opts)
+	if valid {
+		t.Fatalf("Expected no match at or beyond CounterLimit.")
+	}
+
+	_, valid, err = ValidateWindow(code, ^uint64(0)-10, secSha1, opts)
+	if otp.ErrValidateCounterExhausted != err {
+		t.Fatalf("Expected counter exhausted error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+}
+
+func BenchmarkValidateWindow(b *testing.B) {
+	opts := WindowOpts{
+		ValidateOpts: ValidateOpts{Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1},
+		Window:       5000,
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ValidateWindow("000000", 1<<32, secSha1, opts)
+	}
+}
+
+func BenchmarkValidateWindowParallel(b *testing.B) {
+	opts := WindowOpts{
+		ValidateOpts: ValidateOpts{Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1},
+		Window:       5000,
+		Parallelism:  8,
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ValidateWindow("000000", 1<<32, secSha1, opts)
+	}
+}
@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// HKDF implements the HMAC-based key derivation function from RFC 5869 using SHA-256, returning
+// size bytes of key material. size must not exceed 255*32 bytes.
+func HKDF(secret, salt, info []byte, size int) []byte {
+	if len(salt) == 0 {
+		salt = make([]byte, sha256.Size)
+	}
+
+	// Extract
+	extractor := hmac.New(sha256.New, salt)
+	extractor.Write(secret)
+	prk := extractor.Sum(nil)
+
+	// Expand
+	out := make([]byte, 0, size+sha256.Size)
+	expander := hmac.New(sha256.New, prk)
+	var t []byte
+	for i := byte(1); len(out) < size; i++ {
+		expander.Reset()
+		expander.Write(t)
+		expander.Write(info)
+		expander.Write([]byte{i})
+		t = expander.Sum(nil)
+		out = append(out, t...)
+	}
+
+	return out[:size]
+}
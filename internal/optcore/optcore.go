@@ -0,0 +1,414 @@
+// Package optcore implements the functional options shared by the hotp and totp packages. Each
+// package exposes its options structs through Generate and Validate, whose fields point into them, so
+// every option is written once.
+package optcore
+
+import (
+	"io"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// Generate points to the fields of a GenerateOpts. Fields the package does not have are nil.
+type Generate struct {
+	Issuer               *string
+	AccountName          *string
+	Period               *uint
+	SecretSize           *uint
+	Secret               *[]byte
+	Digits               *otp.Digits
+	Algorithm            *otp.Algorithm
+	Rand                 *io.Reader
+	Encoder              *otp.Encoder
+	SecretAlphabet       *otp.SecretAlphabet
+	ExpiresAt            *time.Time
+	NotBefore            *time.Time
+	Scopes               *[]string
+	SecretEncoding       *otp.SecretEncodingProfile
+	HideAccountName      *bool
+	ProfileName          *string
+	ProfileVersion       *uint
+	MaxIssuerLength      *uint
+	MaxAccountNameLength *uint
+}
+
+// Validate points to the fields of a ValidateOpts. Fields the package does not have are nil.
+type Validate struct {
+	Period           *uint
+	Skew             *uint
+	ClockUncertainty *time.Duration
+	Digits           *otp.Digits
+	Algorithm        *otp.Algorithm
+	Encoder          *otp.Encoder
+	SecretAlphabet   *otp.SecretAlphabet
+	SecretDecoder    *otp.SecretDecoder
+	Context          *string
+	ExpiresAt        *time.Time
+	NotBefore        *time.Time
+	Scopes           *[]string
+	Scope            *string
+	TestKey          *bool
+	AllowTestKeys    *bool
+	RiskFlags        *[]string
+	RiskPolicy       *otp.RiskPolicy
+	ProfileRegistry  **otp.ProfileRegistry
+}
+
+// Option sets fields of g or v, either of which may be nil.
+type Option func(g *Generate, v *Validate)
+
+// set stores value in field, if the package has the field.
+func set[T any](field *T, value T) {
+	if field != nil {
+		*field = value
+	}
+}
+
+// FromKey applies the settings recorded in k.
+func FromKey(k *otp.Key) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.Issuer, k.Issuer())
+			set(g.AccountName, k.AccountName())
+			set(g.Period, uint(k.Period()))
+			set(g.Digits, k.Digits())
+			set(g.Algorithm, k.Algorithm())
+			set(g.Encoder, k.Encoder())
+			set(g.SecretAlphabet, k.SecretAlphabet())
+			set(g.ExpiresAt, k.ExpiresAt())
+			set(g.NotBefore, k.NotBefore())
+			set(g.Scopes, k.Scopes())
+		}
+		if v != nil {
+			set(v.Period, uint(k.Period()))
+			set(v.Digits, k.Digits())
+			set(v.Algorithm, k.Algorithm())
+			set(v.Encoder, k.Encoder())
+			set(v.SecretAlphabet, k.SecretAlphabet())
+			set(v.ExpiresAt, k.ExpiresAt())
+			set(v.NotBefore, k.NotBefore())
+			set(v.Scopes, k.Scopes())
+			set(v.TestKey, k.IsTestKey())
+			set(v.RiskFlags, k.RiskFlags())
+		}
+	}
+}
+
+// Restrict applies the settings recorded in k after the options of the caller, so that options can
+// tighten the restrictions of the key but never lift them: the earliest expiry and latest activation
+// time win, the scopes of the key replace any others, and test key marking and risk flags are kept.
+func Restrict(k *otp.Key) Option {
+	return func(g *Generate, v *Validate) {
+		if v == nil {
+			return
+		}
+		set(v.Period, uint(k.Period()))
+		set(v.Digits, k.Digits())
+		set(v.Algorithm, k.Algorithm())
+		set(v.Encoder, k.Encoder())
+		set(v.SecretAlphabet, k.SecretAlphabet())
+		if t := k.ExpiresAt(); !t.IsZero() && (v.ExpiresAt.IsZero() || t.Before(*v.ExpiresAt)) {
+			*v.ExpiresAt = t
+		}
+		if t := k.NotBefore(); !t.IsZero() && t.After(*v.NotBefore) {
+			*v.NotBefore = t
+		}
+		if scopes := k.Scopes(); len(scopes) != 0 {
+			*v.Scopes = scopes
+		}
+		if k.IsTestKey() {
+			*v.TestKey = true
+		}
+		*v.RiskFlags = union(*v.RiskFlags, k.RiskFlags())
+	}
+}
+
+func union(a []string, b []string) []string {
+	out := append([]string{}, a...)
+	for _, s := range b {
+		found := false
+		for _, o := range out {
+			found = found || o == s
+		}
+		if !found {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Issuer sets the name of the issuing Organization/Company.
+func Issuer(issuer string) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.Issuer, issuer)
+		}
+	}
+}
+
+// AccountName sets the name of the User's Account.
+func AccountName(accountName string) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.AccountName, accountName)
+		}
+	}
+}
+
+// Period sets the number of seconds a TOTP hash is valid for.
+func Period(period uint) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.Period, period)
+		}
+		if v != nil {
+			set(v.Period, period)
+		}
+	}
+}
+
+// ClockUncertainty sets the maximum error of the clock.
+func ClockUncertainty(d time.Duration) Option {
+	return func(g *Generate, v *Validate) {
+		if v != nil {
+			set(v.ClockUncertainty, d)
+		}
+	}
+}
+
+// Skew sets the number of periods before or after the current time to allow.
+func Skew(skew uint) Option {
+	return func(g *Generate, v *Validate) {
+		if v != nil {
+			set(v.Skew, skew)
+		}
+	}
+}
+
+// Profile applies the settings of profile, and records its name and version in the key.
+func Profile(profile otp.Profile) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.ProfileName, profile.Name)
+			set(g.ProfileVersion, profile.Version)
+			set(g.Period, profile.Period)
+			set(g.Digits, profile.Digits)
+			set(g.Algorithm, profile.Algorithm)
+			set(g.Encoder, profile.Encoder)
+		}
+		if v != nil {
+			set(v.Period, profile.Period)
+			set(v.Digits, profile.Digits)
+			set(v.Algorithm, profile.Algorithm)
+			set(v.Encoder, profile.Encoder)
+		}
+	}
+}
+
+// Digits sets the number of digits in a passcode.
+func Digits(digits otp.Digits) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.Digits, digits)
+		}
+		if v != nil {
+			set(v.Digits, digits)
+		}
+	}
+}
+
+// Algorithm sets the algorithm to use for HMAC.
+func Algorithm(algorithm otp.Algorithm) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.Algorithm, algorithm)
+		}
+		if v != nil {
+			set(v.Algorithm, algorithm)
+		}
+	}
+}
+
+// Encoder sets how passcodes are rendered.
+func Encoder(encoder otp.Encoder) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.Encoder, encoder)
+		}
+		if v != nil {
+			set(v.Encoder, encoder)
+		}
+	}
+}
+
+// SecretAlphabet sets the base32 alphabet the secret is written in.
+func SecretAlphabet(alphabet otp.SecretAlphabet) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.SecretAlphabet, alphabet)
+		}
+		if v != nil {
+			set(v.SecretAlphabet, alphabet)
+		}
+	}
+}
+
+// SecretDecoder sets the decoder used to convert secrets to bytes when validating.
+func SecretDecoder(decoder otp.SecretDecoder) Option {
+	return func(g *Generate, v *Validate) {
+		if v != nil {
+			set(v.SecretDecoder, decoder)
+		}
+	}
+}
+
+// Context sets the purpose passcodes are generated and validated for.
+func Context(context string) Option {
+	return func(g *Generate, v *Validate) {
+		if v != nil {
+			set(v.Context, context)
+		}
+	}
+}
+
+// SecretSize sets the size of a randomly generated secret in bytes.
+func SecretSize(size uint) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.SecretSize, size)
+		}
+	}
+}
+
+// Secret sets the secret to store instead of generating a random one.
+func Secret(secret []byte) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.Secret, secret)
+		}
+	}
+}
+
+// SecretEncoding sets the profile used to encode the secret and URL.
+func SecretEncoding(profile otp.SecretEncodingProfile) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.SecretEncoding, profile)
+		}
+	}
+}
+
+// MaxLabelLength sets the maximum number of characters in the issuer and account name.
+func MaxLabelLength(issuer uint, accountName uint) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.MaxIssuerLength, issuer)
+			set(g.MaxAccountNameLength, accountName)
+		}
+	}
+}
+
+// HiddenAccountName shows a pseudonym instead of the account name in the URL.
+func HiddenAccountName() Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.HideAccountName, true)
+		}
+	}
+}
+
+// Rand sets the reader to use for generating the secret.
+func Rand(r io.Reader) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.Rand, r)
+		}
+	}
+}
+
+// ExpiresAt sets the time after which the key is no longer valid.
+func ExpiresAt(t time.Time) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.ExpiresAt, t)
+		}
+		if v != nil {
+			set(v.ExpiresAt, t)
+		}
+	}
+}
+
+// NotBefore sets the time before which the key may not be used.
+func NotBefore(t time.Time) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.NotBefore, t)
+		}
+		if v != nil {
+			set(v.NotBefore, t)
+		}
+	}
+}
+
+// Scopes sets the operations the key is restricted to.
+func Scopes(scopes ...string) Option {
+	return func(g *Generate, v *Validate) {
+		if g != nil {
+			set(g.Scopes, scopes)
+		}
+		if v != nil {
+			set(v.Scopes, scopes)
+		}
+	}
+}
+
+// Scope sets the operation a passcode is being validated for.
+func Scope(scope string) Option {
+	return func(g *Generate, v *Validate) {
+		if v != nil {
+			set(v.Scope, scope)
+		}
+	}
+}
+
+// AllowTestKeys accepts test keys.
+func AllowTestKeys() Option {
+	return func(g *Generate, v *Validate) {
+		if v != nil {
+			set(v.AllowTestKeys, true)
+		}
+	}
+}
+
+// ProfileRegistry sets the registry that KeyProfile looks up the profile of a key in.
+func ProfileRegistry(r *otp.ProfileRegistry) Option {
+	return func(g *Generate, v *Validate) {
+		if v != nil {
+			set(v.ProfileRegistry, r)
+		}
+	}
+}
+
+// KeyProfile applies the settings of the version of the profile that k was generated with, if the
+// validator set a ProfileRegistry that holds it. It comes after Restrict, so that the registry
+// decides how passcodes are computed, while the restrictions recorded in the key still apply.
+func KeyProfile(k *otp.Key) Option {
+	return func(g *Generate, v *Validate) {
+		if v == nil || v.ProfileRegistry == nil || *v.ProfileRegistry == nil {
+			return
+		}
+		if p, ok := (*v.ProfileRegistry).ForKey(k); ok {
+			Profile(p)(nil, v)
+		}
+	}
+}
+
+// RiskPolicy sets the policy deciding whether a key with risk flags may be validated.
+func RiskPolicy(policy otp.RiskPolicy) Option {
+	return func(g *Generate, v *Validate) {
+		if v != nil {
+			set(v.RiskPolicy, policy)
+		}
+	}
+}
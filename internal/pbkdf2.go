@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// PBKDF2 implements the password-based key derivation function from RFC 8018 using HMAC-SHA256,
+// returning size bytes of key material.
+func PBKDF2(password, salt []byte, iterations int, size int) []byte {
+	prf := hmac.New(sha256.New, password)
+	out := make([]byte, 0, size+sha256.Size)
+	block := make([]byte, 4)
+	u := make([]byte, 0, sha256.Size)
+
+	for i := uint32(1); len(out) < size; i++ {
+		binary.BigEndian.PutUint32(block, i)
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(block)
+		u = prf.Sum(u[:0])
+		t := append([]byte{}, u...)
+
+		for n := 1; n < iterations; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		out = append(out, t...)
+	}
+
+	return out[:size]
+}
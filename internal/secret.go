@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"encoding/base32"
+	"strings"
+)
+
+// DecodeSecret converts a user supplied base32 secret into raw bytes. It tolerates surrounding
+// whitespace, missing padding and lower case letters.
+func DecodeSecret(secret string) ([]byte, error) {
+	// As noted in issue #10 and #17 this adds support for TOTP secrets that are
+	// missing their padding.
+	secret = strings.TrimSpace(secret)
+	if n := len(secret) % 8; n != 0 {
+		secret = secret + strings.Repeat("=", 8-n)
+	}
+
+	// As noted in issue #24 Google has started producing base32 in lower case,
+	// but the StdEncoding (and the RFC), expect a dictionary of only upper case letters.
+	secret = strings.ToUpper(secret)
+
+	return base32.StdEncoding.DecodeString(secret)
+}
+
+// EncodeSecret converts raw secret bytes into base32 without padding, the form used in otpauth URLs.
+func EncodeSecret(secret []byte) string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret)
+}
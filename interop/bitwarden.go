@@ -0,0 +1,50 @@
+package interop
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// steamPrefix marks a Steam Guard secret in Bitwarden's TOTP field.
+const steamPrefix = "steam://"
+
+// ParseBitwarden parses the value of a Bitwarden login's TOTP field. Bitwarden accepts an otpauth URL,
+// a "steam://" prefixed Steam Guard secret, or a bare base32 secret using the default settings of
+// SHA1, 6 digits and 30 seconds. Only otpauth URLs carry an issuer and account name, so the item's
+// name and username should be given for the other forms.
+func ParseBitwarden(value string, issuer string, accountName string) (*otp.Key, error) {
+	value = strings.TrimSpace(value)
+	lower := strings.ToLower(value)
+
+	if strings.HasPrefix(lower, "otpauth://") {
+		return importURL(value)
+	}
+
+	params := url.Values{
+		"algorithm": {otp.AlgorithmSHA1.String()},
+		"period":    {"30"},
+		"digits":    {otp.DigitsSix.String()},
+	}
+
+	if strings.HasPrefix(lower, steamPrefix) {
+		value = value[len(steamPrefix):]
+		params["digits"] = []string{otp.DigitsSteam.String()}
+		params["encoder"] = []string{string(otp.EncoderSteam)}
+	}
+
+	return newKey("totp", issuer, accountName, compactSecret(value), params)
+}
+
+// FormatBitwarden returns k in the form Bitwarden stores in a login's TOTP field. Steam Guard keys use
+// the "steam://" prefix, as Bitwarden does not recognise the encoder parameter, and all other keys
+// are returned as their otpauth URL. The value holds the secret, so it also returns a copy of k marked
+// with otp.RiskExported to store in place of k.
+func FormatBitwarden(k *otp.Key) (string, *otp.Key, error) {
+	if k.Encoder() == otp.EncoderSteam {
+		return exported(k, steamPrefix+k.Secret())
+	}
+
+	return exported(k, k.String())
+}
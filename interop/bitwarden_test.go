@@ -0,0 +1,73 @@
+package interop
+
+import (
+	"testing"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestParseBitwarden(t *testing.T) {
+	k, err := ParseBitwarden("jbsw y3dp ehpk 3pxp", "Example", "alice@example.com")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "totp" != k.Type() || "JBSWY3DPEHPK3PXP" != k.Secret() {
+		t.Fatalf("Bare secret was not parsed")
+	}
+	if "Example" != k.Issuer() || "alice@example.com" != k.AccountName() {
+		t.Fatalf("Extracting Issuer and Account Name")
+	}
+	if 30 != k.Period() || otp.DigitsSix != k.Digits() || otp.AlgorithmSHA1 != k.Algorithm() {
+		t.Fatalf("Defaults were not applied")
+	}
+
+	k, err = ParseBitwarden("steam://JBSWY3DPEHPK3PXP", "Steam", "alice")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if otp.EncoderSteam != k.Encoder() || otp.DigitsSteam != k.Digits() {
+		t.Fatalf("Steam settings were not applied")
+	}
+	if "JBSWY3DPEHPK3PXP" != k.Secret() {
+		t.Fatalf("Steam prefix was not removed")
+	}
+	if !k.HasRiskFlag(otp.RiskImported) {
+		t.Fatalf("Parsed key should be marked as imported")
+	}
+
+	k, err = ParseBitwarden("otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&digits=8", "Ignored", "ignored")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "Example" != k.Issuer() || otp.DigitsEight != k.Digits() {
+		t.Fatalf("URL values should be kept")
+	}
+
+	if _, err := ParseBitwarden("not a secret!", "Example", "alice"); otp.ErrValidateSecretInvalidBase32 != err {
+		t.Fatalf("Expected invalid base32 error.")
+	}
+}
+
+func TestFormatBitwarden(t *testing.T) {
+	k, _ := ParseBitwarden("steam://JBSWY3DPEHPK3PXP", "Steam", "alice")
+	value, _, err := FormatBitwarden(k)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "steam://JBSWY3DPEHPK3PXP" != value {
+		t.Fatalf("Unexpected value '%s'", value)
+	}
+
+	url := "otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example"
+	k, _ = otp.NewKeyFromURL(url)
+	value, flagged, err := FormatBitwarden(k)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if url != value {
+		t.Fatalf("Unexpected value '%s'", value)
+	}
+	if !flagged.HasRiskFlag(otp.RiskExported) || k.HasRiskFlag(otp.RiskExported) {
+		t.Fatalf("Expected a copy of the key marked as exported")
+	}
+}
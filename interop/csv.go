@@ -0,0 +1,257 @@
+package interop
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// CSVColumns returns the columns of the bulk provisioning CSV schema, in the order WriteCSV writes
+// them.
+// The first row must be a header naming the columns; they may be in any order and only "account" and
+// "secret" are required. The columns are:
+//
+//	account   Name of the user's account, eg email address. Required.
+//	issuer    Name of the issuing organization.
+//	secret    Base32 encoded secret. Whitespace and dashes are ignored. Required.
+//	type      "totp" or "hotp". Defaults to "hotp" if a counter is set, otherwise "totp".
+//	algorithm SHA1, SHA256, SHA512 or MD5. Defaults to SHA1.
+//	digits    Number of digits: 6, 7 or 8. Defaults to 6. Ignored for Steam Guard keys.
+//	period    TOTP period in seconds. Defaults to 30.
+//	counter   Initial HOTP counter. Defaults to 0.
+//	alphabet  Base32 alphabet of the secret: "zbase32", "crockford" or blank for RFC 4648.
+//	encoder   How passcodes are rendered: "steam" or blank for decimal digits.
+func CSVColumns() []string {
+	return []string{"account", "issuer", "secret", "type", "algorithm", "digits", "period", "counter", "alphabet", "encoder"}
+}
+
+// ErrCSVMissingColumn is returned when the CSV header does not include a required column.
+var ErrCSVMissingColumn = otp.NewCodedError("OTP_INTEROP_CSV_MISSING_COLUMN", "CSV is missing a required column")
+
+// CSVRowError describes a CSV row that could not be imported.
+type CSVRowError struct {
+	// Row is the 1-based line number the record starts on, counting the header as line 1. Records
+	// with quoted line breaks span several lines.
+	Row int
+	// Err is the reason the row was rejected.
+	Err error
+}
+
+func (e *CSVRowError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Row, e.Err.Error())
+}
+
+func (e *CSVRowError) Unwrap() error {
+	return e.Err
+}
+
+// ReadCSV reads keys from r using the CSVColumns schema. Rows that cannot be imported are reported
+// individually in rowErrors and do not stop the remaining rows from being read. err is only set if
+// the CSV itself cannot be read. Use StreamCSV for files too large to hold in memory.
+func ReadCSV(r io.Reader) (keys []*otp.Key, rowErrors []*CSVRowError, err error) {
+	err = StreamCSV(r, func(k *otp.Key, rowErr *CSVRowError) error {
+		if rowErr != nil {
+			rowErrors = append(rowErrors, rowErr)
+		} else {
+			keys = append(keys, k)
+		}
+		return nil
+	})
+	if err == ErrCSVMissingColumn {
+		return nil, nil, err
+	}
+	return keys, rowErrors, err
+}
+
+// StreamCSV reads keys from r using the CSVColumns schema, calling fn with each key or row error as it
+// is read, so that files of any size are imported in constant memory. If fn returns an error reading
+// stops and the error is returned. Otherwise, err is only set if the CSV itself cannot be read.
+func StreamCSV(r io.Reader, fn func(k *otp.Key, rowErr *CSVRowError) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"account", "secret"} {
+		if _, ok := columns[required]; !ok {
+			return ErrCSVMissingColumn
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if parseErr, ok := err.(*csv.ParseError); ok {
+				if err := fn(nil, &CSVRowError{Row: parseErr.StartLine, Err: err}); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+
+		row, _ := reader.FieldPos(0)
+		k, err := parseCSVRecord(record, columns)
+		if err != nil {
+			err = fn(nil, &CSVRowError{Row: row, Err: err})
+		} else {
+			err = fn(k, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseCSVRecord(record []string, columns map[string]int) (*otp.Key, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	account := field("account")
+	if account == "" {
+		return nil, otp.ErrGenerateMissingAccountName
+	}
+
+	typ := strings.ToLower(field("type"))
+	if typ == "" {
+		typ = "totp"
+		if field("counter") != "" {
+			typ = "hotp"
+		}
+	}
+	if typ != "totp" && typ != "hotp" {
+		return nil, fmt.Errorf("unknown type %q", typ)
+	}
+
+	params := url.Values{}
+
+	algorithm := strings.ToUpper(field("algorithm"))
+	switch algorithm {
+	case "":
+		algorithm = otp.AlgorithmSHA1.String()
+	case "SHA1", "SHA256", "SHA512", "MD5":
+	default:
+		return nil, fmt.Errorf("unknown algorithm %q", algorithm)
+	}
+	params.Set("algorithm", algorithm)
+
+	alphabet := otp.SecretAlphabet(strings.ToLower(field("alphabet")))
+	switch alphabet {
+	case otp.SecretAlphabetRFC4648:
+	case otp.SecretAlphabetZBase32, otp.SecretAlphabetCrockford:
+		params.Set("alphabet", string(alphabet))
+	default:
+		return nil, fmt.Errorf("unknown alphabet %q", alphabet)
+	}
+
+	encoder := otp.Encoder(strings.ToLower(field("encoder")))
+	switch encoder {
+	case otp.EncoderDefault:
+		digits := field("digits")
+		switch digits {
+		case "":
+			digits = otp.DigitsSix.String()
+		case "6", "7", "8":
+		default:
+			return nil, fmt.Errorf("invalid digits %q", digits)
+		}
+		params.Set("digits", digits)
+	case otp.EncoderSteam:
+		params.Set("encoder", string(encoder))
+	default:
+		return nil, fmt.Errorf("unknown encoder %q", encoder)
+	}
+
+	if typ == "totp" {
+		period := field("period")
+		if period == "" {
+			period = "30"
+		}
+		if p, err := strconv.ParseUint(period, 10, 64); err != nil || p == 0 {
+			return nil, fmt.Errorf("invalid period %q", period)
+		}
+		params.Set("period", period)
+	} else {
+		counter := field("counter")
+		if counter == "" {
+			counter = "0"
+		}
+		if _, err := strconv.ParseUint(counter, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid counter %q", counter)
+		}
+		params.Set("counter", counter)
+	}
+
+	return newKey(typ, field("issuer"), account, compactSecret(field("secret")), params)
+}
+
+// WriteCSV writes keys to w using the CSVColumns schema, including the header row. The export holds
+// every secret, so it returns copies of keys marked with otp.RiskExported, in the same order, to store
+// in place of the originals.
+func WriteCSV(w io.Writer, keys []*otp.Key) ([]*otp.Key, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(CSVColumns()); err != nil {
+		return nil, err
+	}
+
+	flagged := make([]*otp.Key, 0, len(keys))
+	for _, k := range keys {
+		period := ""
+		counter := ""
+		if k.Type() == "hotp" {
+			counter = strconv.FormatUint(k.Counter(), 10)
+		} else {
+			period = strconv.FormatUint(k.Period(), 10)
+		}
+
+		record := []string{
+			k.AccountName(),
+			k.Issuer(),
+			k.Secret(),
+			k.Type(),
+			k.Algorithm().String(),
+			k.Digits().String(),
+			period,
+			counter,
+			string(k.SecretAlphabet()),
+			string(k.Encoder()),
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+		_, f, err := exported(k, "")
+		if err != nil {
+			return nil, err
+		}
+		flagged = append(flagged, f)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return flagged, nil
+}
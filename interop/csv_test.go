@@ -0,0 +1,146 @@
+package interop
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestReadCSV(t *testing.T) {
+	data := `account,issuer,secret,algorithm,digits,period,counter
+alice@example.com,Example,JBSW Y3DP EHPK 3PXP,SHA256,8,60,
+bob@example.com,Example,JBSWY3DPEHPK3PXP,,,,12
+,Example,JBSWY3DPEHPK3PXP,,,,
+carol@example.com,Example,not base32!,,,,
+dave@example.com,Example,JBSWY3DPEHPK3PXP,SHA3,,,
+"erin
+@example.com",Example,JBSWY3DPEHPK3PXP,,,,
+frank@example.com,Example,JBSWY3DPEHPK3PXP,,255,,
+`
+	keys, rowErrors, err := ReadCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 3 != len(keys) {
+		t.Fatalf("Expected 3 keys, got %d", len(keys))
+	}
+	if "totp" != keys[0].Type() || 60 != keys[0].Period() || otp.DigitsEight != keys[0].Digits() || otp.AlgorithmSHA256 != keys[0].Algorithm() {
+		t.Fatalf("First row settings were not kept")
+	}
+	if "hotp" != keys[1].Type() || 12 != keys[1].Counter() {
+		t.Fatalf("Second row should be HOTP with a counter of 12")
+	}
+
+	if 4 != len(rowErrors) {
+		t.Fatalf("Expected 4 row errors, got %d", len(rowErrors))
+	}
+	if 4 != rowErrors[0].Row || !errors.Is(rowErrors[0], otp.ErrGenerateMissingAccountName) {
+		t.Fatalf("Unexpected row error: %s", rowErrors[0].Error())
+	}
+	if 5 != rowErrors[1].Row || !errors.Is(rowErrors[1], otp.ErrValidateSecretInvalidBase32) {
+		t.Fatalf("Unexpected row error: %s", rowErrors[1].Error())
+	}
+	if 6 != rowErrors[2].Row {
+		t.Fatalf("Unexpected row error: %s", rowErrors[2].Error())
+	}
+	// Rows are numbered by the line they start on, so the record spanning lines 7 and 8 moves the
+	// next one to line 9.
+	if 9 != rowErrors[3].Row || !strings.Contains(rowErrors[3].Error(), "invalid digits") {
+		t.Fatalf("Unexpected row error: %s", rowErrors[3].Error())
+	}
+}
+
+func TestReadCSVMissingColumn(t *testing.T) {
+	if _, _, err := ReadCSV(strings.NewReader("issuer,secret\nExample,JBSWY3DPEHPK3PXP\n")); ErrCSVMissingColumn != err {
+		t.Fatalf("Expected missing column error.")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	a, _ := otp.NewKeyFromURL(`otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example&digits=8`)
+	b, _ := otp.NewKeyFromURL(`otpauth://hotp/Example:bob@google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example&counter=3`)
+	c, _ := otp.NewKeyFromURL(`otpauth://totp/Example:carol@google.com?secret=jbswy3dpehpk3pxp&issuer=Example&alphabet=zbase32`)
+	d, _ := otp.NewKeyFromURL(`otpauth://totp/Steam:dave?secret=JBSWY3DPEHPK3PXP&issuer=Steam&encoder=steam`)
+
+	buf := &bytes.Buffer{}
+	flagged, err := WriteCSV(buf, []*otp.Key{a, b, c, d})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 4 != len(flagged) || !flagged[0].HasRiskFlag(otp.RiskExported) || a.HasRiskFlag(otp.RiskExported) || b.AccountName() != flagged[1].AccountName() {
+		t.Fatalf("Expected copies of the keys marked as exported")
+	}
+	expected := `account,issuer,secret,type,algorithm,digits,period,counter,alphabet,encoder
+alice@google.com,Example,JBSWY3DPEHPK3PXP,totp,SHA1,8,30,,,
+bob@google.com,Example,JBSWY3DPEHPK3PXP,hotp,SHA1,6,,3,,
+carol@google.com,Example,jbswy3dpehpk3pxp,totp,SHA1,6,30,,zbase32,
+dave,Steam,JBSWY3DPEHPK3PXP,totp,SHA1,5,30,,,steam
+`
+	if expected != buf.String() {
+		t.Fatalf("Unexpected CSV:\n%s", buf.String())
+	}
+
+	keys, rowErrors, err := ReadCSV(buf)
+	if err != nil || len(rowErrors) != 0 || len(keys) != 4 {
+		t.Fatalf("Written CSV could not be read back")
+	}
+	if keys[0].String() == "" || keys[1].Counter() != 3 {
+		t.Fatalf("Round trip lost settings")
+	}
+	if !keys[0].HasRiskFlag(otp.RiskImported) {
+		t.Fatalf("Read key should be marked as imported")
+	}
+	if otp.SecretAlphabetZBase32 != keys[2].SecretAlphabet() || c.Secret() != keys[2].Secret() {
+		t.Fatalf("Round trip lost the alphabet: %s", keys[2].URL())
+	}
+	if otp.EncoderSteam != keys[3].Encoder() || otp.DigitsSteam != keys[3].Digits() {
+		t.Fatalf("Round trip lost the encoder: %s", keys[3].URL())
+	}
+}
+
+func TestStreamCSV(t *testing.T) {
+	r, w := io.Pipe()
+	go func() {
+		io.WriteString(w, "account,secret\n")
+		for i := 0; i < 50000; i++ {
+			fmt.Fprintf(w, "user%d@example.com,JBSWY3DPEHPK3PXP\n", i)
+		}
+		io.WriteString(w, "bad@example.com,not base32!\n")
+		w.Close()
+	}()
+
+	imported := 0
+	failed := 0
+	err := StreamCSV(r, func(k *otp.Key, rowErr *CSVRowError) error {
+		if rowErr != nil {
+			failed++
+			if 50002 != rowErr.Row {
+				t.Fatalf("Unexpected row %d", rowErr.Row)
+			}
+			return nil
+		}
+		imported++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 50000 != imported || 1 != failed {
+		t.Fatalf("Unexpected %d keys and %d errors", imported, failed)
+	}
+
+	stop := errors.New("stop")
+	seen := 0
+	err = StreamCSV(strings.NewReader("account,secret\na,JBSWY3DPEHPK3PXP\nb,JBSWY3DPEHPK3PXP\n"), func(k *otp.Key, rowErr *CSVRowError) error {
+		seen++
+		return stop
+	})
+	if stop != err || 1 != seen {
+		t.Fatalf("Expected reading to stop at the callback error")
+	}
+}
@@ -0,0 +1,137 @@
+package interop
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// DuplicateReason describes why keys were grouped as duplicates.
+type DuplicateReason int
+
+const (
+	// DuplicateCopies are keys with the same secret and the same issuer and account name.
+	DuplicateCopies DuplicateReason = iota
+	// DuplicateSecret are keys with the same secret but different names, the same token saved more
+	// than once under different names.
+	DuplicateSecret
+	// DuplicateLabel are keys with different secrets for the same issuer and account name, usually
+	// because the account was enrolled again and only the newest key still works.
+	DuplicateLabel
+)
+
+func (r DuplicateReason) String() string {
+	switch r {
+	case DuplicateCopies:
+		return "copies"
+	case DuplicateSecret:
+		return "same secret"
+	case DuplicateLabel:
+		return "same account"
+	}
+	panic("unreached")
+}
+
+// Duplicate is a group of keys in an import batch that refer to the same token or account.
+type Duplicate struct {
+	// Reason the keys were grouped.
+	Reason DuplicateReason
+	// Indexes of the keys in the batch, in ascending order.
+	Indexes []int
+	// Keep is the index of the key suggested to be kept. For DuplicateLabel this is the last key in
+	// the batch, assuming the batch is in the order the keys were enrolled.
+	Keep int
+	// Suggestion describes how to merge the keys, in English.
+	Suggestion string
+}
+
+// FindDuplicates groups the keys of an import batch that share a secret (compared by fingerprint) or
+// an issuer and account name (compared without regard to case). Every group has at least two keys,
+// and keys that are exact copies are reported once as DuplicateCopies rather than under both other
+// reasons.
+func FindDuplicates(keys []*otp.Key) []Duplicate {
+	bySecret := map[string][]int{}
+	byLabel := map[string][]int{}
+	byBoth := map[string][]int{}
+	for i, k := range keys {
+		fingerprint := k.Fingerprint()
+		label := duplicateLabel(k)
+		bySecret[fingerprint] = append(bySecret[fingerprint], i)
+		byLabel[label] = append(byLabel[label], i)
+		byBoth[fingerprint+"/"+label] = append(byBoth[fingerprint+"/"+label], i)
+	}
+
+	duplicates := []Duplicate{}
+	for _, indexes := range byBoth {
+		if len(indexes) > 1 {
+			duplicates = append(duplicates, Duplicate{
+				Reason:     DuplicateCopies,
+				Indexes:    indexes,
+				Keep:       indexes[0],
+				Suggestion: "These keys are identical, keep one and remove the others",
+			})
+		}
+	}
+	for _, indexes := range bySecret {
+		if distinct := distinctLabels(keys, indexes); len(distinct) > 1 {
+			duplicates = append(duplicates, Duplicate{
+				Reason:     DuplicateSecret,
+				Indexes:    distinct,
+				Keep:       distinct[0],
+				Suggestion: "These keys are the same token saved under different names, keep one and give it the right name",
+			})
+		}
+	}
+	for _, indexes := range byLabel {
+		if distinct := distinctSecrets(keys, indexes); len(distinct) > 1 {
+			duplicates = append(duplicates, Duplicate{
+				Reason:     DuplicateLabel,
+				Indexes:    distinct,
+				Keep:       distinct[len(distinct)-1],
+				Suggestion: "These keys are for the same account but have different secrets, only the newest is likely to work",
+			})
+		}
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Indexes[0] != duplicates[j].Indexes[0] {
+			return duplicates[i].Indexes[0] < duplicates[j].Indexes[0]
+		}
+		return duplicates[i].Reason < duplicates[j].Reason
+	})
+	return duplicates
+}
+
+// duplicateLabel returns the issuer and account name of k, compared without regard to case.
+func duplicateLabel(k *otp.Key) string {
+	return strings.ToLower(strings.TrimSpace(k.Issuer())) + ":" + strings.ToLower(strings.TrimSpace(k.AccountName()))
+}
+
+// distinctLabels returns the first of indexes for each distinct issuer and account name.
+func distinctLabels(keys []*otp.Key, indexes []int) []int {
+	seen := map[string]bool{}
+	distinct := []int{}
+	for _, i := range indexes {
+		label := duplicateLabel(keys[i])
+		if !seen[label] {
+			seen[label] = true
+			distinct = append(distinct, i)
+		}
+	}
+	return distinct
+}
+
+// distinctSecrets returns the last of indexes for each distinct secret, in ascending order.
+func distinctSecrets(keys []*otp.Key, indexes []int) []int {
+	last := map[string]int{}
+	for _, i := range indexes {
+		last[keys[i].Fingerprint()] = i
+	}
+	distinct := []int{}
+	for _, i := range last {
+		distinct = append(distinct, i)
+	}
+	sort.Ints(distinct)
+	return distinct
+}
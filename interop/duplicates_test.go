@@ -0,0 +1,45 @@
+package interop
+
+import (
+	"testing"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	urls := []string{
+		"otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil",
+		"otpauth://totp/snakeoil:Alice?secret=jbswy3dpehpk3pxp&issuer=snakeoil",
+		"otpauth://totp/Work:alice?secret=JBSWY3DPEHPK3PXP&issuer=Work",
+		"otpauth://totp/Other:bob?secret=GEZDGNBVGY3TQOJQ&issuer=Other",
+		"otpauth://totp/Other:bob?secret=NBSWY3DPO5XXE3DE&issuer=Other",
+		"otpauth://totp/Unique:carol?secret=GEZDGNBVGY3TQOJQGEZDGNBV&issuer=Unique",
+	}
+	keys := []*otp.Key{}
+	for _, u := range urls {
+		k, _ := otp.NewKeyFromURL(u)
+		keys = append(keys, k)
+	}
+
+	duplicates := FindDuplicates(keys)
+	if 3 != len(duplicates) {
+		t.Fatalf("Expected 3 duplicate groups, got %+v", duplicates)
+	}
+
+	if DuplicateCopies != duplicates[0].Reason || 2 != len(duplicates[0].Indexes) || 1 != duplicates[0].Indexes[1] {
+		t.Fatalf("Unexpected copies %+v", duplicates[0])
+	}
+	if DuplicateSecret != duplicates[1].Reason || 2 != len(duplicates[1].Indexes) || 2 != duplicates[1].Indexes[1] {
+		t.Fatalf("Unexpected same secret %+v", duplicates[1])
+	}
+	if DuplicateLabel != duplicates[2].Reason || 4 != duplicates[2].Keep {
+		t.Fatalf("Unexpected same account %+v", duplicates[2])
+	}
+	if "" == duplicates[2].Suggestion {
+		t.Fatalf("Expected a suggestion")
+	}
+
+	if 0 != len(FindDuplicates(keys[3:4])) {
+		t.Fatalf("Expected no duplicates for a single key")
+	}
+}
@@ -0,0 +1,53 @@
+package interop
+
+import (
+	"io"
+	"strings"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// ErrImageNoCodes describes an image that does not contain any QR codes.
+var ErrImageNoCodes = otp.NewCodedError("OTP_INTEROP_IMAGE_NO_CODES", "Image does not contain a QR code")
+
+// ImageDecoder finds the QR codes in an image. This package does not read images itself so that it
+// stays free of dependencies; implement ImageDecoder by wrapping a QR library such as gozxing, or
+// build with the otp_zbar build tag to use ZBarDecoder, which runs the zbarimg command.
+type ImageDecoder interface {
+	// DecodeImage returns the text of every QR code in the image read from r.
+	DecodeImage(r io.Reader) ([]string, error)
+}
+
+// ReadImage imports keys from the QR codes in an image, such as a screenshot of an enrollment page.
+// The text of each code is imported like a line of ReadText, and the Line of a diagnostic is the
+// 1-based position of the code in the list returned by dec. err is only set if dec fails or finds no
+// codes.
+func ReadImage(r io.Reader, dec ImageDecoder) (keys []*otp.Key, diagnostics []TextDiagnostic, err error) {
+	codes, err := dec.DecodeImage(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(codes) == 0 {
+		return nil, nil, ErrImageNoCodes
+	}
+
+	for i, text := range codes {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			diagnostics = append(diagnostics, TextDiagnostic{Line: i + 1, Message: "QR code is empty"})
+			continue
+		}
+
+		k, warning, err := parseTextLine(text, i+1)
+		if err != nil {
+			diagnostics = append(diagnostics, TextDiagnostic{Line: i + 1, Message: err.Error()})
+			continue
+		}
+		keys = append(keys, k)
+		if warning != "" {
+			diagnostics = append(diagnostics, TextDiagnostic{Line: i + 1, Imported: true, Message: warning})
+		}
+	}
+
+	return keys, diagnostics, nil
+}
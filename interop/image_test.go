@@ -0,0 +1,44 @@
+package interop
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type fakeDecoder struct {
+	codes []string
+	err   error
+}
+
+func (d fakeDecoder) DecodeImage(r io.Reader) ([]string, error) {
+	return d.codes, d.err
+}
+
+func TestReadImage(t *testing.T) {
+	dec := fakeDecoder{codes: []string{
+		"otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example",
+		"",
+		"https://example.com/help",
+	}}
+
+	keys, diagnostics, err := ReadImage(strings.NewReader(""), dec)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 1 != len(keys) || "alice@example.com" != keys[0].AccountName() {
+		t.Fatalf("Unexpected keys %v", keys)
+	}
+	if 2 != len(diagnostics) || 2 != diagnostics[0].Line || 3 != diagnostics[1].Line || diagnostics[1].Imported {
+		t.Fatalf("Unexpected diagnostics %v", diagnostics)
+	}
+
+	if _, _, err := ReadImage(strings.NewReader(""), fakeDecoder{}); ErrImageNoCodes != err {
+		t.Fatalf("Expected no codes error")
+	}
+	failed := errors.New("unsupported image")
+	if _, _, err := ReadImage(strings.NewReader(""), fakeDecoder{err: failed}); failed != err {
+		t.Fatalf("Expected the decoder error")
+	}
+}
@@ -0,0 +1,77 @@
+// Package interop imports and exports OTP keys in the formats used by other applications, such as
+// password managers and spreadsheets of hardware token seeds. Imported keys are marked with
+// otp.RiskImported, and the export functions return copies of the keys marked with otp.RiskExported
+// for the caller to store, so that a risk policy can treat secrets that exist elsewhere differently.
+package interop
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/internal"
+)
+
+// newKey builds a key of the given type ("totp" or "hotp") from its parts. The secret is normalised
+// to base32 without padding or whitespace in the alphabet named by the "alphabet" parameter, and
+// params are added to the URL as-is. Like every imported key, it is marked with otp.RiskImported,
+// since the secret also exists in the application it came from.
+func newKey(typ string, issuer string, accountName string, secret string, params url.Values) (*otp.Key, error) {
+	alphabet := otp.SecretAlphabet(params.Get("alphabet"))
+	b, err := alphabet.DecodeSecret(secret)
+	if err != nil {
+		return nil, otp.ErrValidateSecretInvalidBase32
+	}
+
+	v := url.Values{}
+	for k, vs := range params {
+		v[k] = vs
+	}
+	v.Set("secret", alphabet.EncodeSecret(b))
+	if issuer != "" {
+		v.Set("issuer", issuer)
+	}
+
+	label := accountName
+	if issuer != "" {
+		label = issuer + ":" + accountName
+	}
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     typ,
+		Path:     "/" + label,
+		RawQuery: internal.EncodeQuery(v),
+	}
+
+	return importURL(u.String())
+}
+
+// importURL parses an otpauth URL from another application, marking the key with otp.RiskImported.
+func importURL(s string) (*otp.Key, error) {
+	k, err := otp.NewKeyFromURL(s)
+	if err != nil {
+		return nil, err
+	}
+	return otp.AddRiskFlags(k, otp.RiskImported)
+}
+
+// exported returns a copy of k marked with otp.RiskExported.
+func exported(k *otp.Key, formatted string) (string, *otp.Key, error) {
+	flagged, err := otp.AddRiskFlags(k, otp.RiskExported)
+	if err != nil {
+		return "", nil, err
+	}
+	return formatted, flagged, nil
+}
+
+// compactSecret removes the whitespace and dashes that applications add to make secrets readable.
+func compactSecret(secret string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n', '-':
+			return -1
+		}
+		return r
+	}, secret)
+}
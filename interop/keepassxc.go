@@ -0,0 +1,91 @@
+package interop
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// ErrKeePassXCMissingKey is returned when a KeePassXC otp string does not contain a key.
+var ErrKeePassXCMissingKey = otp.NewCodedError("OTP_INTEROP_KEEPASSXC_MISSING_KEY", "KeePassXC otp string is missing key")
+
+// ParseKeePassXC parses the value of the "otp" attribute KeePassXC stores on an entry. The value is
+// either an otpauth URL, or the KeeOtp style "key=...&step=...&size=..." string, which does not carry
+// an issuer or account name, so the entry's title and username should be given instead.
+func ParseKeePassXC(value string, issuer string, accountName string) (*otp.Key, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(strings.ToLower(value), "otpauth://") {
+		return importURL(value)
+	}
+
+	q, err := url.ParseQuery(value)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := compactSecret(q.Get("key"))
+	if secret == "" {
+		return nil, ErrKeePassXCMissingKey
+	}
+
+	v := url.Values{}
+	typ := "totp"
+	if strings.EqualFold(q.Get("type"), "hotp") {
+		typ = "hotp"
+		counter := q.Get("counter")
+		if counter == "" {
+			counter = "0"
+		}
+		v.Set("counter", counter)
+	} else {
+		period := q.Get("step")
+		if period == "" {
+			period = "30"
+		}
+		v.Set("period", period)
+	}
+
+	digits := q.Get("size")
+	if digits == "" {
+		digits = "6"
+	}
+	v.Set("digits", digits)
+
+	switch strings.ToLower(q.Get("otpHashMode")) {
+	case "sha256":
+		v.Set("algorithm", otp.AlgorithmSHA256.String())
+	case "sha512":
+		v.Set("algorithm", otp.AlgorithmSHA512.String())
+	default:
+		v.Set("algorithm", otp.AlgorithmSHA1.String())
+	}
+
+	return newKey(typ, issuer, accountName, secret, v)
+}
+
+// FormatKeePassXC returns k in the KeeOtp style "key=...&step=...&size=..." string understood by
+// KeePassXC. KeePassXC also accepts the otpauth URL returned by k.String() directly. The value holds
+// the secret, so it also returns a copy of k marked with otp.RiskExported to store in place of k.
+func FormatKeePassXC(k *otp.Key) (string, *otp.Key, error) {
+	v := url.Values{}
+	v.Set("key", k.Secret())
+	v.Set("size", k.Digits().String())
+
+	if k.Type() == "hotp" {
+		v.Set("type", "Hotp")
+		v.Set("counter", strconv.FormatUint(k.Counter(), 10))
+	} else {
+		v.Set("step", strconv.FormatUint(k.Period(), 10))
+	}
+
+	switch k.Algorithm() {
+	case otp.AlgorithmSHA256:
+		v.Set("otpHashMode", "Sha256")
+	case otp.AlgorithmSHA512:
+		v.Set("otpHashMode", "Sha512")
+	}
+
+	return exported(k, v.Encode())
+}
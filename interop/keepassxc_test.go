@@ -0,0 +1,94 @@
+package interop
+
+import (
+	"testing"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestParseKeePassXCKeeOtp(t *testing.T) {
+	k, err := ParseKeePassXC("key=jbsw y3dp ehpk 3pxp&step=60&size=8&otpHashMode=Sha256", "Example", "alice@example.com")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "totp" != k.Type() {
+		t.Fatalf("Extracting Type")
+	}
+	if "Example" != k.Issuer() || "alice@example.com" != k.AccountName() {
+		t.Fatalf("Extracting Issuer and Account Name")
+	}
+	if "JBSWY3DPEHPK3PXP" != k.Secret() {
+		t.Fatalf("Secret was not normalised, got '%s'", k.Secret())
+	}
+	if 60 != k.Period() || otp.DigitsEight != k.Digits() || otp.AlgorithmSHA256 != k.Algorithm() {
+		t.Fatalf("Settings were not kept")
+	}
+
+	k, err = ParseKeePassXC("key=JBSWY3DPEHPK3PXP", "", "alice@example.com")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 30 != k.Period() || otp.DigitsSix != k.Digits() || otp.AlgorithmSHA1 != k.Algorithm() {
+		t.Fatalf("Defaults were not applied")
+	}
+	if "" != k.Issuer() || "alice@example.com" != k.AccountName() {
+		t.Fatalf("Extracting Issuer and Account Name")
+	}
+
+	k, err = ParseKeePassXC("key=JBSWY3DPEHPK3PXP&type=Hotp&counter=5", "Example", "alice")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "hotp" != k.Type() || 5 != k.Counter() {
+		t.Fatalf("HOTP settings were not kept")
+	}
+}
+
+func TestParseKeePassXCURL(t *testing.T) {
+	k, err := ParseKeePassXC("otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example\n", "Ignored", "ignored")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "Example" != k.Issuer() || "alice@google.com" != k.AccountName() {
+		t.Fatalf("URL values should take precedence over the entry")
+	}
+}
+
+func TestParseKeePassXCInvalid(t *testing.T) {
+	if _, err := ParseKeePassXC("step=30&size=6", "Example", "alice"); ErrKeePassXCMissingKey != err {
+		t.Fatalf("Expected missing key error.")
+	}
+	if _, err := ParseKeePassXC("key=1", "Example", "alice"); otp.ErrValidateSecretInvalidBase32 != err {
+		t.Fatalf("Expected invalid base32 error.")
+	}
+}
+
+func TestFormatKeePassXC(t *testing.T) {
+	k, _ := otp.NewKeyFromURL(`otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&algorithm=SHA512&digits=8&period=60`)
+	value, flagged, err := FormatKeePassXC(k)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "key=JBSWY3DPEHPK3PXP&otpHashMode=Sha512&size=8&step=60" != value {
+		t.Fatalf("Unexpected value '%s'", value)
+	}
+	if !flagged.HasRiskFlag(otp.RiskExported) || k.HasRiskFlag(otp.RiskExported) {
+		t.Fatalf("Expected a copy of the key marked as exported")
+	}
+
+	parsed, err := ParseKeePassXC(value, k.Issuer(), k.AccountName())
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if parsed.Secret() != k.Secret() || parsed.Period() != k.Period() || parsed.Digits() != k.Digits() || parsed.Algorithm() != k.Algorithm() {
+		t.Fatalf("Round trip lost settings")
+	}
+	if !parsed.HasRiskFlag(otp.RiskImported) {
+		t.Fatalf("Parsed key should be marked as imported")
+	}
+
+	k, _ = otp.NewKeyFromURL(`otpauth://hotp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&counter=7`)
+	if value, _, _ := FormatKeePassXC(k); "counter=7&key=JBSWY3DPEHPK3PXP&size=6&type=Hotp" != value {
+		t.Fatalf("Unexpected value '%s'", value)
+	}
+}
@@ -0,0 +1,42 @@
+package interop
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// ParseOnePassword parses a one-time password field in any of the loose forms 1Password accepts: an
+// otpauth URL, a bare base32 secret, or a bare secret followed by URL parameters such as
+// "JBSW Y3DP?digits=8&period=60". Like 1Password, whitespace and dashes are removed from the secret
+// and it is treated case-insensitively. Only otpauth URLs carry an issuer and account name, so the
+// item's title and username should be given for the other forms.
+func ParseOnePassword(value string, issuer string, accountName string) (*otp.Key, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(strings.ToLower(value), "otpauth://") {
+		return importURL(value)
+	}
+
+	secret, rawQuery, _ := strings.Cut(value, "?")
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{
+		"algorithm": {otp.AlgorithmSHA1.String()},
+		"period":    {"30"},
+		"digits":    {otp.DigitsSix.String()},
+	}
+	for _, name := range []string{"algorithm", "period", "digits"} {
+		if v := q.Get(name); v != "" {
+			params.Set(name, v)
+		}
+	}
+	if v := q.Get("issuer"); v != "" && issuer == "" {
+		issuer = v
+	}
+
+	return newKey("totp", issuer, accountName, compactSecret(secret), params)
+}
@@ -0,0 +1,51 @@
+package interop
+
+import (
+	"testing"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestParseOnePassword(t *testing.T) {
+	k, err := ParseOnePassword(" jbsw-y3dp ehpk-3pxp ", "Example", "alice@example.com")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "JBSWY3DPEHPK3PXP" != k.Secret() {
+		t.Fatalf("Secret was not normalised, got '%s'", k.Secret())
+	}
+	if "Example" != k.Issuer() || "alice@example.com" != k.AccountName() {
+		t.Fatalf("Extracting Issuer and Account Name")
+	}
+	if 30 != k.Period() || otp.DigitsSix != k.Digits() {
+		t.Fatalf("Defaults were not applied")
+	}
+
+	k, err = ParseOnePassword("JBSW Y3DP EHPK 3PXP?digits=8&period=60&algorithm=SHA256", "Example", "alice")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 60 != k.Period() || otp.DigitsEight != k.Digits() || otp.AlgorithmSHA256 != k.Algorithm() {
+		t.Fatalf("Parameters were not applied")
+	}
+
+	k, err = ParseOnePassword("JBSWY3DPEHPK3PXP?issuer=Other", "", "alice")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "Other" != k.Issuer() {
+		t.Fatalf("Issuer parameter should be used when no issuer is given")
+	}
+
+	k, err = ParseOnePassword("otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP", "Ignored", "ignored")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "Example" != k.Issuer() || "alice@google.com" != k.AccountName() {
+		t.Fatalf("URL values should be kept")
+	}
+
+	if _, err := ParseOnePassword("1111?digits=8", "Example", "alice"); otp.ErrValidateSecretInvalidBase32 != err {
+		t.Fatalf("Expected invalid base32 error.")
+	}
+}
@@ -0,0 +1,206 @@
+package interop
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/internal"
+)
+
+// TextDiagnostic describes how a line of a text import was understood. Messages are in English and
+// are intended for support staff, not for end users.
+type TextDiagnostic struct {
+	// Line is the 1-based line number.
+	Line int
+	// Imported is true if a key was imported from the line, possibly with a warning in Message.
+	Imported bool
+	// Message describes the problem or warning.
+	Message string
+}
+
+func (d TextDiagnostic) String() string {
+	return fmt.Sprintf("line %d: %s", d.Line, d.Message)
+}
+
+// minSecretSize is the shortest secret, in bytes, accepted without a warning. RFC 4226 requires at
+// least 128 bits and recommends 160, but many services issue 80 bit secrets.
+const minSecretSize = 10
+
+// ReadText imports keys from a loosely formatted text dump, such as one pasted into a support ticket.
+// Each line may hold an otpauth URL (possibly surrounded by other text), an "issuer:account secret"
+// pair, an "account secret" pair or a bare base32 secret. Secrets may be split into groups by spaces
+// or dashes. Blank lines and lines starting with "#" are ignored.
+//
+// A diagnostic is returned for every line that could not be imported and every line that was
+// imported with a guess or warning. err is only set if r cannot be read. Use StreamText for dumps too
+// large to hold in memory.
+func ReadText(r io.Reader) (keys []*otp.Key, diagnostics []TextDiagnostic, err error) {
+	err = StreamText(r, func(k *otp.Key, diagnostic *TextDiagnostic) error {
+		if k != nil {
+			keys = append(keys, k)
+		}
+		if diagnostic != nil {
+			diagnostics = append(diagnostics, *diagnostic)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return keys, diagnostics, nil
+}
+
+// StreamText imports keys from r like ReadText, calling fn for each line as it is read so that dumps
+// of any size are imported in constant memory. k is nil if the line could not be imported, and
+// diagnostic is nil if the line was imported without a warning. If fn returns an error reading stops
+// and the error is returned.
+func StreamText(r io.Reader, fn func(k *otp.Key, diagnostic *TextDiagnostic) error) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		k, warning, err := parseTextLine(text, line)
+		if err != nil {
+			err = fn(nil, &TextDiagnostic{Line: line, Message: err.Error()})
+		} else if warning != "" {
+			err = fn(k, &TextDiagnostic{Line: line, Imported: true, Message: warning})
+		} else {
+			err = fn(k, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseTextLine imports a single line of ReadText, returning the key and an optional warning.
+func parseTextLine(text string, line int) (*otp.Key, string, error) {
+	if i := strings.Index(strings.ToLower(text), "otpauth://"); i != -1 {
+		return parseTextURL(text, i)
+	}
+
+	fields := strings.Fields(text)
+	label := fields[0]
+	rest := strings.Join(fields[1:], "")
+
+	// A label holding a colon or @ is never part of the secret.
+	if len(fields) > 1 && strings.ContainsAny(label, ":@") {
+		if _, err := internal.DecodeSecret(compactSecret(rest)); err == nil {
+			return textLabelKey(label, rest)
+		}
+		return nil, "", errors.New("Found a label but the secret after it is not valid base32")
+	}
+
+	if secret := compactSecret(text); isLikelySecret(secret) {
+		account := fmt.Sprintf("Imported key (line %d)", line)
+		k, warning, err := textKey("", account, secret)
+		if err != nil {
+			return nil, "", err
+		}
+		return k, joinWarnings("Found only a secret, the account name is unknown", warning), nil
+	}
+
+	if len(fields) > 1 {
+		if _, err := internal.DecodeSecret(compactSecret(rest)); err == nil {
+			return textLabelKey(label, rest)
+		}
+	}
+
+	return nil, "", errors.New("Line is not an otpauth URL, a base32 secret or an \"issuer:account secret\" pair")
+}
+
+// parseTextURL imports the otpauth URL starting at index i of text.
+func parseTextURL(text string, i int) (*otp.Key, string, error) {
+	u := text[i:]
+	if end := strings.IndexAny(u, " \t\"'<>"); end != -1 {
+		u = u[:end]
+	}
+	u = strings.TrimRight(u, ".,;)]")
+
+	k, err := importURL(u)
+	if err != nil {
+		return nil, "", errors.New("Found an otpauth URL but it could not be parsed")
+	}
+	if k.Type() != "totp" && k.Type() != "hotp" {
+		return nil, "", fmt.Errorf("Found an otpauth URL but its type %q is not totp or hotp", k.Type())
+	}
+	if k.Secret() == "" {
+		return nil, "", errors.New("Found an otpauth URL but it has no secret")
+	}
+	b, err := k.SecretAlphabet().DecodeSecret(k.Secret())
+	if err != nil {
+		return nil, "", errors.New("Found an otpauth URL but its secret is not valid base32")
+	}
+
+	warning := secretSizeWarning(b)
+	if strings.TrimSpace(text[:i]) != "" || len(u) < len(strings.TrimSpace(text[i:])) {
+		warning = joinWarnings("Ignored text around the otpauth URL", warning)
+	}
+	return k, warning, nil
+}
+
+// textLabelKey imports a TOTP key from an "issuer:account" or "account" label and its secret.
+func textLabelKey(label string, secret string) (*otp.Key, string, error) {
+	issuer := ""
+	account := label
+	if i := strings.Index(label, ":"); i != -1 {
+		issuer = label[:i]
+		account = label[i+1:]
+	}
+	if account == "" {
+		return nil, "", errors.New("Found a label but the account name is empty")
+	}
+	return textKey(issuer, account, secret)
+}
+
+func textKey(issuer string, account string, secret string) (*otp.Key, string, error) {
+	secret = compactSecret(secret)
+	b, err := internal.DecodeSecret(secret)
+	if err != nil {
+		return nil, "", errors.New("Secret is not valid base32")
+	}
+	k, err := newKey("totp", issuer, account, secret, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return k, secretSizeWarning(b), nil
+}
+
+// isLikelySecret returns true if s decodes as base32 and is long enough that it is unlikely to be a
+// word that happens to only use base32 letters.
+func isLikelySecret(s string) bool {
+	if len(s) < 16 {
+		return false
+	}
+	_, err := internal.DecodeSecret(s)
+	return err == nil
+}
+
+func secretSizeWarning(secret []byte) string {
+	if len(secret) < minSecretSize {
+		return fmt.Sprintf("Secret is only %d bits, which is weaker than recommended", len(secret)*8)
+	}
+	return ""
+}
+
+func joinWarnings(a string, b string) string {
+	if b == "" {
+		return a
+	}
+	return a + ". " + b
+}
@@ -0,0 +1,88 @@
+package interop
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestReadText(t *testing.T) {
+	text := strings.Join([]string{
+		"\ufeff# exported from my old phone",
+		"otpauth://totp/SnakeOil:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil",
+		"backup: <otpauth://hotp/Other:bob?secret=GEZDGNBVGY3TQOJQ&counter=3>",
+		"",
+		"Example:carol@example.com jbsw y3dp ehpk 3pxp",
+		"dave@example.com GEZD-GNBV-GY3T-QOJQ",
+		"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		"erin NBSWY3DP",
+		"this is not a key",
+		"Example:frank not!base32",
+	}, "\n")
+
+	keys, diagnostics, err := ReadText(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 6 != len(keys) {
+		t.Fatalf("Expected 6 keys, got %d: %v", len(keys), diagnostics)
+	}
+
+	if "SnakeOil" != keys[0].Issuer() || "alice@example.com" != keys[0].AccountName() {
+		t.Fatalf("Unexpected first key '%s'", keys[0].String())
+	}
+	if "hotp" != keys[1].Type() || 3 != keys[1].Counter() {
+		t.Fatalf("Unexpected second key '%s'", keys[1].String())
+	}
+	if "Example" != keys[2].Issuer() || "carol@example.com" != keys[2].AccountName() || "JBSWY3DPEHPK3PXP" != keys[2].Secret() {
+		t.Fatalf("Unexpected third key '%s'", keys[2].String())
+	}
+	if "" != keys[3].Issuer() || "dave@example.com" != keys[3].AccountName() || "GEZDGNBVGY3TQOJQ" != keys[3].Secret() {
+		t.Fatalf("Unexpected fourth key '%s'", keys[3].String())
+	}
+	if "Imported key (line 7)" != keys[4].AccountName() {
+		t.Fatalf("Unexpected fifth key '%s'", keys[4].String())
+	}
+	if "erin" != keys[5].AccountName() {
+		t.Fatalf("Unexpected sixth key '%s'", keys[5].String())
+	}
+	for _, k := range keys {
+		if !k.HasRiskFlag(otp.RiskImported) {
+			t.Fatalf("Key '%s' should be marked as imported", k.String())
+		}
+	}
+
+	expected := map[int]bool{3: true, 7: true, 8: true, 9: false, 10: false}
+	if len(expected) != len(diagnostics) {
+		t.Fatalf("Unexpected diagnostics %v", diagnostics)
+	}
+	for _, d := range diagnostics {
+		imported, ok := expected[d.Line]
+		if !ok || imported != d.Imported {
+			t.Fatalf("Unexpected diagnostic %s", d.String())
+		}
+	}
+}
+
+func TestStreamText(t *testing.T) {
+	text := "alice@example.com JBSWY3DPEHPK3PXP\ncarol@example.com !!!\nbob@example.com JBSWY3DP\n"
+
+	imported := 0
+	lines := []int{}
+	err := StreamText(strings.NewReader(text), func(k *otp.Key, diagnostic *TextDiagnostic) error {
+		if k != nil {
+			imported++
+		}
+		if diagnostic != nil {
+			lines = append(lines, diagnostic.Line)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 2 != imported || 2 != len(lines) || 2 != lines[0] || 3 != lines[1] {
+		t.Fatalf("Unexpected %d keys and diagnostics for lines %v", imported, lines)
+	}
+}
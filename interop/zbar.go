@@ -0,0 +1,67 @@
+//go:build otp_zbar
+
+package interop
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ZBarDecoder is an ImageDecoder that runs the zbarimg command from the zbar project, so that
+// programs can read QR codes from images without linking a QR library. It is only built with the
+// otp_zbar build tag.
+type ZBarDecoder struct {
+	// Path of the zbarimg command. Defaults to "zbarimg", found in PATH.
+	Path string
+}
+
+// zbarNoCodes is the exit status of zbarimg when the image has no codes.
+const zbarNoCodes = 4
+
+// DecodeImage returns the text of every QR code in the image read from r.
+func (d ZBarDecoder) DecodeImage(r io.Reader) ([]string, error) {
+	path := d.Path
+	if path == "" {
+		path = "zbarimg"
+	}
+
+	// zbarimg reads images from files, so r is copied to a temporary one.
+	f, err := os.CreateTemp("", "otp-image-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(path, "--quiet", "--raw", "-Sdisable", "-Sqrcode.enable", f.Name())
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		exitErr := &exec.ExitError{}
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == zbarNoCodes {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("zbarimg: %s: %s", err.Error(), strings.TrimSpace(stderr.String()))
+	}
+
+	codes := []string{}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			codes = append(codes, line)
+		}
+	}
+	return codes, nil
+}
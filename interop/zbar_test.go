@@ -0,0 +1,39 @@
+//go:build otp_zbar && unix
+
+package interop
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeZBar writes a script that behaves like zbarimg, printing output and exiting with status.
+func fakeZBar(t *testing.T, output string, status int) string {
+	path := filepath.Join(t.TempDir(), "zbarimg")
+	script := "#!/bin/sh\nprintf '%s' '" + output + "'\nexit " + strconv.Itoa(status) + "\n"
+	if err := os.WriteFile(path, []byte(script), 0700); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	return path
+}
+
+func TestZBarDecoder(t *testing.T) {
+	dec := ZBarDecoder{Path: fakeZBar(t, "otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example\n", 0)}
+	keys, diagnostics, err := ReadImage(strings.NewReader("image"), dec)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 1 != len(keys) || "alice@example.com" != keys[0].AccountName() || 0 != len(diagnostics) {
+		t.Fatalf("Unexpected keys %v", keys)
+	}
+
+	if _, _, err := ReadImage(strings.NewReader("image"), ZBarDecoder{Path: fakeZBar(t, "", zbarNoCodes)}); ErrImageNoCodes != err {
+		t.Fatalf("Expected no codes error")
+	}
+	if _, _, err := ReadImage(strings.NewReader("image"), ZBarDecoder{Path: fakeZBar(t, "", 2)}); err == nil {
+		t.Fatalf("Expected an error when zbarimg fails")
+	}
+}
@@ -0,0 +1,242 @@
+// Package kiosk validates TOTP passcodes on devices without network access, such as door-access
+// kiosks. A server with the keys exports a rolling file holding a short keyed hash of every passcode
+// of a set of accounts for the next few hours, and the kiosk validates passcodes against the file
+// without ever holding the secrets. A new file must be exported before the current one runs out.
+//
+// Each hash is keyed with a kiosk key that is provisioned on the kiosk separately from the file, so a
+// copied file alone does not reveal any passcodes. Anyone with both the file and the kiosk key can
+// recover every passcode it covers by trying each possible passcode, so both must be protected for as
+// long as the file is valid.
+package kiosk
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+// ErrInvalidFile is returned when reading something that is not a rolling code file.
+var ErrInvalidFile = otp.NewCodedError("OTP_KIOSK_INVALID_FILE", "File is not a rolling code file")
+
+// ErrUnknownAccount is returned when validating a passcode for an account that is not in the file.
+var ErrUnknownAccount = otp.NewCodedError("OTP_KIOSK_UNKNOWN_ACCOUNT", "Account is not in the rolling code file")
+
+// ErrFileExpired is returned when validating a passcode at a time the file does not cover.
+var ErrFileExpired = otp.NewCodedError("OTP_KIOSK_FILE_EXPIRED", "Rolling code file does not cover this time")
+
+// ErrDurationTooLong is returned when exporting a file that would cover more than MaxDuration.
+var ErrDurationTooLong = otp.NewCodedError("OTP_KIOSK_DURATION_TOO_LONG", "Rolling code file can't cover more than a week")
+
+// MaxDuration is the longest time a rolling code file can cover. Files that claim to cover more are
+// rejected by Read, so that a corrupt file can't make the kiosk allocate more than a few megabytes per
+// account.
+const MaxDuration = 7 * 24 * time.Hour
+
+// Account is a staff member whose passcodes are exported.
+type Account struct {
+	// Name the account is looked up by on the kiosk, such as a badge number.
+	Name string
+	// The TOTP key of the account.
+	Key *otp.Key
+}
+
+const (
+	magic   = "OTPK\x01"
+	tagSize = 4
+	// Skew of time steps accepted either side of the kiosk's clock.
+	skew = 1
+)
+
+// Export writes a rolling code file to w covering accounts from start for duration d, with the hashes
+// keyed by kioskKey. HOTP keys are skipped, as a kiosk can't keep their counters in sync. d must not
+// be longer than MaxDuration.
+func Export(w io.Writer, kioskKey []byte, accounts []Account, start time.Time, d time.Duration) error {
+	if d > MaxDuration {
+		return ErrDurationTooLong
+	}
+
+	totpAccounts := []Account{}
+	for _, account := range accounts {
+		if account.Key.Type() == "totp" {
+			totpAccounts = append(totpAccounts, account)
+		}
+	}
+	accounts = totpAccounts
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString(magic)
+	writeUint(bw, uint64(len(accounts)), 4)
+
+	for _, account := range accounts {
+		opts := totp.ValidateOpts{}
+		totp.FromKey(account.Key)(nil, &opts)
+		if opts.Period == 0 {
+			opts.Period = 30
+		}
+		period := time.Duration(opts.Period) * time.Second
+
+		// Cover the skew either side of the requested range.
+		first := uint64(start.Unix()/int64(opts.Period)) - skew
+		steps := maxSteps(period, d)
+
+		writeUint(bw, uint64(len(account.Name)), 2)
+		bw.WriteString(account.Name)
+		writeUint(bw, uint64(opts.Period), 4)
+		writeUint(bw, first, 8)
+		writeUint(bw, steps, 4)
+
+		for step := first; step < first+steps; step++ {
+			passcode, err := totp.GenerateCodeCustom(account.Key.Secret(), time.Unix(int64(step)*int64(opts.Period), 0), opts)
+			if err != nil {
+				return err
+			}
+			bw.Write(tag(kioskKey, account.Name, step, passcode))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// maxSteps returns the number of time steps of period in a file covering d, including the skew
+// either side.
+func maxSteps(period time.Duration, d time.Duration) uint64 {
+	return uint64(d/period) + 1 + 2*skew
+}
+
+// tag returns the truncated hash of the passcode for an account at a step.
+func tag(kioskKey []byte, name string, step uint64, passcode string) []byte {
+	mac := hmac.New(sha256.New, kioskKey)
+	mac.Write([]byte("otp-kiosk\x00"))
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, step)
+	mac.Write(b)
+	mac.Write([]byte(passcode))
+	return mac.Sum(nil)[:tagSize]
+}
+
+func writeUint(w *bufio.Writer, v uint64, size int) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	w.Write(b[8-size:])
+}
+
+func readUint(r io.Reader, size int) (uint64, error) {
+	b := make([]byte, 8)
+	if _, err := io.ReadFull(r, b[8-size:]); err != nil {
+		return 0, ErrInvalidFile
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// File is a rolling code file loaded by a kiosk. It is safe for concurrent use.
+type File struct {
+	kioskKey []byte
+	accounts map[string]*fileAccount
+}
+
+type fileAccount struct {
+	period uint64
+	first  uint64
+	tags   []byte
+
+	lock sync.Mutex
+	// Last step a passcode was accepted for, so that a passcode can't be used twice.
+	used uint64
+}
+
+// Read loads a rolling code file written by Export, using the same kioskKey.
+func Read(r io.Reader, kioskKey []byte) (*File, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, header); err != nil || string(header) != magic {
+		return nil, ErrInvalidFile
+	}
+	count, err := readUint(br, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{kioskKey: kioskKey, accounts: map[string]*fileAccount{}}
+	for i := uint64(0); i < count; i++ {
+		length, err := readUint(br, 2)
+		if err != nil {
+			return nil, err
+		}
+		name := make([]byte, length)
+		if _, err := io.ReadFull(br, name); err != nil {
+			return nil, ErrInvalidFile
+		}
+
+		account := &fileAccount{}
+		if account.period, err = readUint(br, 4); err != nil {
+			return nil, err
+		}
+		if account.first, err = readUint(br, 8); err != nil {
+			return nil, err
+		}
+		steps, err := readUint(br, 4)
+		if err != nil {
+			return nil, err
+		}
+		if account.period == 0 || steps > maxSteps(time.Duration(account.period)*time.Second, MaxDuration) {
+			return nil, ErrInvalidFile
+		}
+		account.tags = make([]byte, steps*tagSize)
+		if _, err := io.ReadFull(br, account.tags); err != nil {
+			return nil, ErrInvalidFile
+		}
+
+		f.accounts[string(name)] = account
+	}
+
+	return f, nil
+}
+
+// Accounts returns the number of accounts in the file.
+func (f *File) Accounts() int {
+	return len(f.accounts)
+}
+
+// Validate checks passcode for the account name at t, accepting one time step either side. A passcode
+// is only accepted once, and once a passcode is accepted no earlier passcode for the account is.
+func (f *File) Validate(name string, passcode string, t time.Time) (bool, error) {
+	account, ok := f.accounts[name]
+	if !ok {
+		return false, ErrUnknownAccount
+	}
+
+	passcode = strings.TrimSpace(passcode)
+	steps := uint64(len(account.tags) / tagSize)
+	current := uint64(t.Unix()) / account.period
+	if current < account.first+skew || current+skew >= account.first+steps {
+		return false, ErrFileExpired
+	}
+
+	account.lock.Lock()
+	defer account.lock.Unlock()
+
+	for step := current - skew; step <= current+skew; step++ {
+		i := (step - account.first) * tagSize
+		if subtle.ConstantTimeCompare(tag(f.kioskKey, name, step, passcode), account.tags[i:i+tagSize]) == 1 {
+			if step <= account.used {
+				return false, nil
+			}
+			account.used = step
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
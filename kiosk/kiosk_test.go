@@ -0,0 +1,105 @@
+package kiosk
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+var kioskKey = []byte("kiosk key")
+
+func testAccounts(t *testing.T) []Account {
+	alice, _ := otp.NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil")
+	bob, _ := otp.NewKeyFromURL("otpauth://totp/SnakeOil:bob?secret=GEZDGNBVGY3TQOJQ&issuer=SnakeOil&period=60&digits=8")
+	carol, _ := otp.NewKeyFromURL("otpauth://hotp/SnakeOil:carol?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil")
+	return []Account{{Name: "1001", Key: alice}, {Name: "1002", Key: bob}, {Name: "1003", Key: carol}}
+}
+
+func TestExportRead(t *testing.T) {
+	accounts := testAccounts(t)
+	start := time.Unix(1700000000, 0)
+
+	buf := &bytes.Buffer{}
+	if err := Export(buf, kioskKey, accounts, start, 8*time.Hour); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	// 8 hours of 30 and 60 second periods, plus the skew, at 4 bytes per passcode.
+	if buf.Len() > (963+483)*4+100 {
+		t.Fatalf("File is larger than expected: %d bytes", buf.Len())
+	}
+	if bytes.Contains(buf.Bytes(), []byte("JBSWY3DPEHPK3PXP")) {
+		t.Fatalf("File must not contain secrets")
+	}
+
+	f, err := Read(bytes.NewReader(buf.Bytes()), kioskKey)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 2 != f.Accounts() {
+		t.Fatalf("Unexpected number of accounts %d", f.Accounts())
+	}
+
+	now := start.Add(5 * time.Hour)
+	for _, account := range accounts[:2] {
+		code, _ := totp.GenerateCodeCustom(account.Key.Secret(), now, totp.ValidateOpts{
+			Period: uint(account.Key.Period()),
+			Digits: account.Key.Digits(),
+		})
+		valid, err := f.Validate(account.Name, code, now)
+		if err != nil {
+			t.Fatalf("Error: %s", err.Error())
+		}
+		if !valid {
+			t.Fatalf("Valid should be true for account %s.", account.Name)
+		}
+		if valid, _ := f.Validate(account.Name, code, now); valid {
+			t.Fatalf("A passcode must only be accepted once.")
+		}
+	}
+
+	code, _ := totp.GenerateCode(accounts[0].Key.Secret(), now)
+	if valid, _ := f.Validate("1002", code, now); valid {
+		t.Fatalf("Valid should be false for another account's passcode.")
+	}
+	if _, err := f.Validate("1003", code, now); ErrUnknownAccount != err {
+		t.Fatalf("Expected unknown account error.")
+	}
+	if _, err := f.Validate("1001", code, start.Add(9*time.Hour)); ErrFileExpired != err {
+		t.Fatalf("Expected file expired error.")
+	}
+
+	other, _ := Read(bytes.NewReader(buf.Bytes()), []byte("another key"))
+	code, _ = totp.GenerateCode(accounts[0].Key.Secret(), start)
+	if valid, _ := other.Validate("1001", code, start); valid {
+		t.Fatalf("Valid should be false with another kiosk key.")
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	buf := &bytes.Buffer{}
+	Export(buf, kioskKey, testAccounts(t), time.Unix(1700000000, 0), time.Hour)
+
+	for _, b := range [][]byte{nil, []byte("not a file"), buf.Bytes()[:buf.Len()-1]} {
+		if _, err := Read(bytes.NewReader(b), kioskKey); ErrInvalidFile != err {
+			t.Fatalf("Expected invalid file error.")
+		}
+	}
+
+	// One account with a period of 1 second and the most steps a file can claim to have.
+	huge := []byte(magic + "\x00\x00\x00\x01\x00\x01a\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\xff\xff\xff\xff")
+	if _, err := Read(bytes.NewReader(huge), kioskKey); ErrInvalidFile != err {
+		t.Fatalf("Expected invalid file error for too many steps.")
+	}
+}
+
+func TestExportTooLong(t *testing.T) {
+	if err := Export(&bytes.Buffer{}, kioskKey, testAccounts(t), time.Unix(1700000000, 0), MaxDuration+time.Second); ErrDurationTooLong != err {
+		t.Fatalf("Expected duration too long error.")
+	}
+	if err := Export(&bytes.Buffer{}, kioskKey, testAccounts(t), time.Unix(1700000000, 0), MaxDuration); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+}
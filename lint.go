@@ -0,0 +1,16 @@
+package otp
+
+import "fmt"
+
+// LintWarning is an insecure or risky setting found by the LintOpts functions of the hotp and totp
+// packages. Services can log warnings at startup, or refuse to start in production when there are any.
+type LintWarning struct {
+	// Option is the name of the setting, such as "Skew".
+	Option string
+	// Message explains the risk, in English.
+	Message string
+}
+
+func (w LintWarning) String() string {
+	return fmt.Sprintf("%s: %s", w.Option, w.Message)
+}
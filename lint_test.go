@@ -0,0 +1,10 @@
+package otp
+
+import "testing"
+
+func TestLintWarningString(t *testing.T) {
+	w := LintWarning{Option: "Skew", Message: "too large"}
+	if "Skew: too large" != w.String() {
+		t.Fatalf("Unexpected warning '%s'", w.String())
+	}
+}
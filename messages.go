@@ -0,0 +1,119 @@
+package otp
+
+import (
+	"errors"
+)
+
+// Translator returns the text to show for a message in the user's language. id is a stable
+// identifier for the message, such as "otp.key_expired", and english is its default text. A
+// Translator should return english when it has no translation for id.
+type Translator func(id string, english string) string
+
+type catalogEntry struct {
+	err     error
+	code    string
+	id      string
+	english string
+}
+
+// catalog maps every error of this package to its code, and the errors returned while validating to
+// messages that can be shown to users. Errors that are caused by the application rather than the
+// user, such as a missing issuer, have no message of their own and share the generic message.
+var catalog = [...]catalogEntry{
+	{ErrValidateInputInvalidLength, "OTP_INVALID_LENGTH", "otp.invalid_length", "The code has the wrong number of digits. Check that you entered the whole code."},
+	{ErrValidateKeyExpired, "OTP_KEY_EXPIRED", "otp.key_expired", "This authenticator has expired. Set up a new one to continue."},
+	{ErrValidateKeyNotYetValid, "OTP_KEY_NOT_YET_VALID", "otp.key_not_yet_valid", "This authenticator is not active yet. Try again later, or check that your device's clock is correct."},
+	{ErrValidateCounterExhausted, "OTP_COUNTER_EXHAUSTED", "otp.counter_exhausted", "This authenticator has been used too many times. Set up a new one to continue."},
+	{ErrValidateChallengeInvalid, "OTP_CHALLENGE_INVALID", "otp.challenge_invalid", "The challenge must be 8 digits."},
+	{ErrValidateScopeNotPermitted, "OTP_SCOPE_NOT_PERMITTED", "otp.scope_not_permitted", "This authenticator can't be used for this action."},
+	{ErrValidateRiskFlagged, "OTP_RISK_FLAGGED", "otp.risk_flagged", "This authenticator needs to be verified again before it can be used."},
+	{ErrValidateReplayed, "OTP_REPLAYED", "otp.replayed", "This code has already been used. Wait for a new code and try again."},
+	{ErrValidateSecretInvalidBase32, "OTP_SECRET_INVALID", "", ""},
+	{ErrValidateClockBackwards, "OTP_CLOCK_BACKWARDS", "", ""},
+	{ErrValidateTestKeyNotAllowed, "OTP_TEST_KEY_NOT_ALLOWED", "", ""},
+	{ErrValidateScopeMissing, "OTP_SCOPE_MISSING", "", ""},
+	{ErrGenerateInvalidLabel, "OTP_INVALID_LABEL", "", ""},
+	{ErrGenerateMissingIssuer, "OTP_MISSING_ISSUER", "", ""},
+	{ErrGenerateMissingAccountName, "OTP_MISSING_ACCOUNT_NAME", "", ""},
+	{ErrDeriveMissingContext, "OTP_MISSING_CONTEXT", "", ""},
+	{ErrDeriveSizeTooLarge, "OTP_DERIVE_SIZE_TOO_LARGE", "", ""},
+	{ErrSplitSecretMismatch, "OTP_SPLIT_SECRET_MISMATCH", "", ""},
+	{ErrKeyRevisionMismatch, "OTP_KEY_MODIFIED", "", ""},
+	{ErrProfileInvalid, "OTP_PROFILE_INVALID", "", ""},
+	{ErrProfileExists, "OTP_PROFILE_EXISTS", "", ""},
+	{ErrKeyURLMalformed, "OTP_KEY_URL_MALFORMED", "", ""},
+}
+
+// ErrorCodeUnknown is the code of errors that did not come from this package or from NewCodedError.
+const ErrorCodeUnknown = "OTP_ERROR"
+
+type codedError struct {
+	code    string
+	message string
+}
+
+func (e *codedError) Error() string {
+	return e.message
+}
+
+// NewCodedError returns an error with the given message whose ErrorCode is code, for the packages
+// that build on this one to declare their own errors with stable codes. Declare the result as a
+// package-level error value. A coded error may reuse one of this package's codes, such as
+// "OTP_REPLAYED" for a code that was already used, and then also shares its user message.
+func NewCodedError(code string, message string) error {
+	return &codedError{code: code, message: message}
+}
+
+// ErrorCode returns a stable, machine-readable code for err, such as "OTP_INVALID_LENGTH", for APIs
+// to return in error bodies. Codes never change once released. Wrapped errors are recognised, errors
+// declared with NewCodedError return their own code, other errors return ErrorCodeUnknown and a nil
+// error returns "".
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, entry := range catalog {
+		if errors.Is(err, entry.err) {
+			return entry.code
+		}
+	}
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return ErrorCodeUnknown
+}
+
+const (
+	messageGenericID      = "otp.generic"
+	messageGenericEnglish = "The code could not be checked. Please try again."
+)
+
+// UserMessage returns a friendly reason for err that can be shown to users instead of the error
+// string, translated with translate. If translate is nil the English message is returned. Errors
+// that users can't act on all get the same generic message, and a nil error returns "".
+func UserMessage(err error, translate Translator) string {
+	if err == nil {
+		return ""
+	}
+	if translate == nil {
+		translate = func(id string, english string) string {
+			return english
+		}
+	}
+
+	id, english := MessageID(err)
+	return translate(id, english)
+}
+
+// MessageID returns the stable identifier and English text of the user message for err, for
+// applications that keep their own message catalog.
+func MessageID(err error) (id string, english string) {
+	code := ErrorCode(err)
+	for _, entry := range catalog {
+		if entry.code == code && entry.id != "" {
+			return entry.id, entry.english
+		}
+	}
+	return messageGenericID, messageGenericEnglish
+}
@@ -0,0 +1,82 @@
+package otp
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestUserMessage(t *testing.T) {
+	if "" != UserMessage(nil, nil) {
+		t.Fatalf("Expected no message without an error")
+	}
+
+	english := UserMessage(ErrValidateKeyExpired, nil)
+	if "This authenticator has expired. Set up a new one to continue." != english {
+		t.Fatalf("Unexpected message '%s'", english)
+	}
+
+	wrapped := fmt.Errorf("validating: %w", ErrValidateInputInvalidLength)
+	if id, _ := MessageID(wrapped); "otp.invalid_length" != id {
+		t.Fatalf("Unexpected id '%s' for wrapped error", id)
+	}
+
+	if id, _ := MessageID(ErrGenerateMissingIssuer); "otp.generic" != id {
+		t.Fatalf("Expected the generic message for application errors, got '%s'", id)
+	}
+
+	german := map[string]string{"otp.key_expired": "Dieser Authenticator ist abgelaufen."}
+	translate := func(id string, english string) string {
+		if text, ok := german[id]; ok {
+			return text
+		}
+		return english
+	}
+	if "Dieser Authenticator ist abgelaufen." != UserMessage(ErrValidateKeyExpired, translate) {
+		t.Fatalf("Expected translated message")
+	}
+	if UserMessage(ErrValidateKeyNotYetValid, nil) != UserMessage(ErrValidateKeyNotYetValid, translate) {
+		t.Fatalf("Expected English message without a translation")
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	if "" != ErrorCode(nil) {
+		t.Fatalf("Expected no code without an error")
+	}
+	if "OTP_INVALID_LENGTH" != ErrorCode(ErrValidateInputInvalidLength) {
+		t.Fatalf("Unexpected code '%s'", ErrorCode(ErrValidateInputInvalidLength))
+	}
+	if "OTP_MISSING_ISSUER" != ErrorCode(fmt.Errorf("generating: %w", ErrGenerateMissingIssuer)) {
+		t.Fatalf("Expected wrapped errors to be recognised")
+	}
+	if ErrorCodeUnknown != ErrorCode(fmt.Errorf("something else")) {
+		t.Fatalf("Expected the unknown code for other errors")
+	}
+
+	// Every code must be unique.
+	seen := map[string]bool{}
+	for _, entry := range catalog {
+		if seen[entry.code] {
+			t.Fatalf("Duplicate code '%s'", entry.code)
+		}
+		seen[entry.code] = true
+	}
+}
+
+func TestNewCodedError(t *testing.T) {
+	errFrozen := NewCodedError("OTP_ACCOUNT_FROZEN", "Account is frozen")
+	if "OTP_ACCOUNT_FROZEN" != ErrorCode(fmt.Errorf("validating: %w", errFrozen)) {
+		t.Fatalf("Unexpected code '%s'", ErrorCode(errFrozen))
+	}
+	if "Account is frozen" != errFrozen.Error() {
+		t.Fatalf("Unexpected message '%s'", errFrozen.Error())
+	}
+	if id, _ := MessageID(errFrozen); messageGenericID != id {
+		t.Fatalf("Expected the generic message for a new code, got '%s'", id)
+	}
+
+	errUsed := NewCodedError("OTP_REPLAYED", "Recovery code has already been used")
+	if UserMessage(ErrValidateReplayed, nil) != UserMessage(errUsed, nil) {
+		t.Fatalf("Expected a coded error to share the message of its code")
+	}
+}
@@ -0,0 +1,137 @@
+// Package ndef wraps OTP provisioning URLs in NFC Data Exchange Format (NDEF) URI records, so keys
+// can be enrolled by tapping an NFC tag, and parses those records back in to keys.
+package ndef
+
+import (
+	"encoding/binary"
+	"strings"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// ErrInvalidMessage is returned when the data is not a well-formed NDEF message.
+var ErrInvalidMessage = otp.NewCodedError("OTP_NDEF_INVALID_MESSAGE", "Invalid NDEF message")
+
+// ErrNotURIRecord is returned when the first record of the message is not a URI record.
+var ErrNotURIRecord = otp.NewCodedError("OTP_NDEF_NOT_URI_RECORD", "NDEF record is not a URI record")
+
+// ErrNotOTPURI is returned when the URI record does not contain an otpauth URL.
+var ErrNotOTPURI = otp.NewCodedError("OTP_NDEF_NOT_OTP_URI", "NDEF URI is not an otpauth URL")
+
+const (
+	flagMB  = 0x80 // Message begin
+	flagME  = 0x40 // Message end
+	flagSR  = 0x10 // Short record
+	flagIL  = 0x08 // ID length present
+	tnfMask = 0x07
+
+	tnfWellKnown = 0x01
+	uriType      = 'U'
+)
+
+// uriPrefixes are the abbreviations defined by the NFC Forum URI Record Type Definition, indexed
+// by their identifier code.
+var uriPrefixes = [...]string{
+	"", "http://www.", "https://www.", "http://", "https://", "tel:", "mailto:",
+	"ftp://anonymous:anonymous@", "ftp://ftp.", "ftps://", "sftp://", "smb://", "nfs://", "ftp://",
+	"dav://", "news:", "telnet://", "imap:", "rtsp://", "urn:", "pop:", "sip:", "sips:", "tftp:",
+	"btspp://", "btl2cap://", "btgoep://", "tcpobex://", "irdaobex://", "file://", "urn:epc:id:",
+	"urn:epc:tag:", "urn:epc:pat:", "urn:epc:raw:", "urn:epc:", "urn:nfc:",
+}
+
+// EncodeKey returns an NDEF message containing a single URI record for the key's URL.
+func EncodeKey(k *otp.Key) []byte {
+	return EncodeURI(k.String())
+}
+
+// DecodeKey parses an NDEF message produced by EncodeKey, or by any other tool writing an otpauth URL
+// as the first URI record of the message.
+func DecodeKey(msg []byte) (*otp.Key, error) {
+	uri, err := DecodeURI(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(strings.ToLower(uri), "otpauth://") {
+		return nil, ErrNotOTPURI
+	}
+
+	return otp.NewKeyFromURL(uri)
+}
+
+// EncodeURI returns an NDEF message containing a single URI record for uri, using the identifier code
+// of the longest matching prefix so that the record is as short as possible.
+func EncodeURI(uri string) []byte {
+	var code byte
+	for i, prefix := range uriPrefixes {
+		if prefix != "" && strings.HasPrefix(uri, prefix) && len(prefix) > len(uriPrefixes[code]) {
+			code = byte(i)
+		}
+	}
+
+	payload := make([]byte, 0, len(uri)+1)
+	payload = append(payload, code)
+	payload = append(payload, uri[len(uriPrefixes[code]):]...)
+
+	header := byte(flagMB | flagME | tnfWellKnown)
+	msg := make([]byte, 0, len(payload)+7)
+	if len(payload) < 256 {
+		msg = append(msg, header|flagSR, 1, byte(len(payload)))
+	} else {
+		msg = append(msg, header, 1)
+		msg = binary.BigEndian.AppendUint32(msg, uint32(len(payload)))
+	}
+	msg = append(msg, uriType)
+	msg = append(msg, payload...)
+
+	return msg
+}
+
+// DecodeURI returns the URI from the first record of an NDEF message.
+func DecodeURI(msg []byte) (string, error) {
+	if len(msg) < 3 {
+		return "", ErrInvalidMessage
+	}
+
+	header := msg[0]
+	typeLength := int(msg[1])
+	offset := 2
+
+	var payloadLength int
+	if header&flagSR != 0 {
+		payloadLength = int(msg[offset])
+		offset++
+	} else {
+		if len(msg) < offset+4 {
+			return "", ErrInvalidMessage
+		}
+		payloadLength = int(binary.BigEndian.Uint32(msg[offset:]))
+		offset += 4
+	}
+
+	idLength := 0
+	if header&flagIL != 0 {
+		if len(msg) < offset+1 {
+			return "", ErrInvalidMessage
+		}
+		idLength = int(msg[offset])
+		offset++
+	}
+
+	if len(msg) < offset+typeLength+idLength+payloadLength || payloadLength < 0 {
+		return "", ErrInvalidMessage
+	}
+
+	recordType := msg[offset : offset+typeLength]
+	offset += typeLength + idLength
+	payload := msg[offset : offset+payloadLength]
+
+	if header&tnfMask != tnfWellKnown || len(recordType) != 1 || recordType[0] != uriType {
+		return "", ErrNotURIRecord
+	}
+	if len(payload) == 0 || int(payload[0]) >= len(uriPrefixes) {
+		return "", ErrInvalidMessage
+	}
+
+	return uriPrefixes[payload[0]] + string(payload[1:]), nil
+}
@@ -0,0 +1,83 @@
+package ndef
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestEncodeDecodeKey(t *testing.T) {
+	k, err := otp.NewKeyFromURL(`otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example`)
+	if err != nil {
+		t.Fatalf("failed to parse url")
+	}
+
+	msg := EncodeKey(k)
+	if msg[0] != 0xD1 {
+		t.Fatalf("Expected a single short well-known record, got header %x", msg[0])
+	}
+	if msg[3] != 'U' || msg[4] != 0x00 {
+		t.Fatalf("Expected a URI record without an abbreviation")
+	}
+
+	decoded, err := DecodeKey(msg)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if k.String() != decoded.String() {
+		t.Fatalf("'%s' does not equal '%s'", k.String(), decoded.String())
+	}
+}
+
+func TestEncodeURIAbbreviation(t *testing.T) {
+	msg := EncodeURI("https://www.example.com")
+	expected := append([]byte{0xD1, 0x01, 0x0C, 'U', 0x02}, []byte("example.com")...)
+	if !bytes.Equal(expected, msg) {
+		t.Fatalf("Unexpected record %x", msg)
+	}
+
+	uri, err := DecodeURI(msg)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "https://www.example.com" != uri {
+		t.Fatalf("'%s' does not equal '%s'", "https://www.example.com", uri)
+	}
+
+	if _, err := DecodeKey(msg); ErrNotOTPURI != err {
+		t.Fatalf("Expected not otpauth URI error.")
+	}
+}
+
+func TestLongRecord(t *testing.T) {
+	uri := "otpauth://totp/Example:alice@google.com?secret=" + strings.Repeat("A", 300)
+	msg := EncodeURI(uri)
+	if msg[0]&flagSR != 0 {
+		t.Fatalf("Expected a long record")
+	}
+
+	decoded, err := DecodeURI(msg)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if uri != decoded {
+		t.Fatalf("Long URI was not decoded correctly")
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	if _, err := DecodeURI([]byte{0xD1}); ErrInvalidMessage != err {
+		t.Fatalf("Expected invalid message error.")
+	}
+	if _, err := DecodeURI([]byte{0xD1, 0x01, 0x10, 'U', 0x00}); ErrInvalidMessage != err {
+		t.Fatalf("Expected invalid message error for truncated payload.")
+	}
+	if _, err := DecodeURI([]byte{0xD1, 0x01, 0x02, 'T', 0x00, 'a'}); ErrNotURIRecord != err {
+		t.Fatalf("Expected not URI record error.")
+	}
+	if _, err := DecodeURI([]byte{0xD1, 0x01, 0x02, 'U', 0xFF, 'a'}); ErrInvalidMessage != err {
+		t.Fatalf("Expected invalid message error for unknown identifier code.")
+	}
+}
@@ -22,12 +22,14 @@ import (
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"hash"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Error when attempting to convert the secret from base32 to raw bytes.
@@ -36,25 +38,109 @@ var ErrValidateSecretInvalidBase32 = errors.New("Decoding of secret as base32 fa
 // The user provided passcode length was not expected.
 var ErrValidateInputInvalidLength = errors.New("Input length unexpected")
 
+// The challenge used for a challenge-response passcode was not 8 numeric digits.
+var ErrValidateChallengeInvalid = errors.New("Challenge must be 8 numeric digits")
+
+// The HOTP counter is too close to overflowing and the key must be enrolled again.
+var ErrValidateCounterExhausted = errors.New("Counter is exhausted")
+
+// The server clock went backwards since the last validation, see totp.ClockGuard.
+var ErrValidateClockBackwards = errors.New("Clock went backwards")
+
+// The key has passed its expiration time and can no longer be used.
+var ErrValidateKeyExpired = errors.New("Key has expired")
+
+// The key has not reached its activation time and cannot be used yet.
+var ErrValidateKeyNotYetValid = errors.New("Key is not valid yet")
+
+// The key is a test key and the validation does not allow test keys.
+var ErrValidateTestKeyNotAllowed = errors.New("Test keys are not allowed")
+
+// The key is restricted to specific scopes but the validation did not state one.
+var ErrValidateScopeMissing = errors.New("Scope must be set")
+
+// The key is not permitted to be used for the requested scope.
+var ErrValidateScopeNotPermitted = errors.New("Key is not valid for this scope")
+
+// When generating a Key, the Issuer and Account Name must be representable in the URL label.
+var ErrGenerateInvalidLabel = errors.New("Label must not contain a colon except between issuer and account name")
+
+// When deriving a secret, the context must be set.
+var ErrDeriveMissingContext = errors.New("Context must be set")
+
+// When deriving a secret, the size must be at most MaxDeriveSize.
+var ErrDeriveSizeTooLarge = errors.New("Derived secret size is too large")
+
+// When combining a split secret, both halves must be the same length.
+var ErrSplitSecretMismatch = errors.New("Secret halves are not the same length")
+
 // When generating a Key, the Issuer must be set.
 var ErrGenerateMissingIssuer = errors.New("Issuer must be set")
 
 // When generating a Key, the Account Name must be set.
 var ErrGenerateMissingAccountName = errors.New("AccountName must be set")
 
+// The Key was changed since the expected revision was read.
+var ErrKeyRevisionMismatch = errors.New("Key has been modified")
+
+// A profile must have a name and digits to be registered.
+var ErrProfileInvalid = errors.New("Profile must have a name and digits")
+
+// A profile with the same name is already registered.
+var ErrProfileExists = errors.New("Profile is already registered")
+
+// In strict mode, the URL of a Key had surrounding whitespace, quotes or line breaks.
+var ErrKeyURLMalformed = errors.New("Key URL contains extra characters")
+
+// The Key has risk flags that the risk policy of the validator does not accept.
+var ErrValidateRiskFlagged = errors.New("Key requires extra verification")
+
+// The passcode was valid but has already been used.
+var ErrValidateReplayed = errors.New("Passcode has already been used")
+
 // Key represents an TOTP or HTOP key.
 type Key struct {
 	orig string
 	url  *url.URL
 }
 
-// NewKeyFromURL creates a new Key from an TOTP or HOTP url.
+// NewKeyFromURL creates a new Key from an TOTP or HOTP url. It is ParseKeyURL in lenient mode.
 //
 // The URL format is documented here:
 //
 //	https://github.com/google/google-authenticator/wiki/Key-Uri-Format
 func NewKeyFromURL(orig string) (*Key, error) {
-	s := strings.TrimSpace(orig)
+	return ParseKeyURL(orig, ParseLenient)
+}
+
+// ParseMode controls how ParseKeyURL treats characters around and within a URL that are commonly
+// introduced by copying it from other tools.
+type ParseMode int
+
+const (
+	// ParseLenient ignores a byte order mark, surrounding whitespace and quotes, and line breaks
+	// within the URL, as added by Windows tools, spreadsheets and CSV exports.
+	ParseLenient ParseMode = iota
+	// ParseStrict rejects URLs with any of these with ErrKeyURLMalformed.
+	ParseStrict
+)
+
+func (m ParseMode) String() string {
+	switch m {
+	case ParseLenient:
+		return "Lenient"
+	case ParseStrict:
+		return "Strict"
+	}
+	panic("unreached")
+}
+
+// ParseKeyURL creates a new Key from an TOTP or HOTP url, using mode to handle extra characters.
+func ParseKeyURL(orig string, mode ParseMode) (*Key, error) {
+	s := cleanKeyURL(orig)
+	if mode == ParseStrict && s != orig {
+		return nil, ErrKeyURLMalformed
+	}
 
 	u, err := url.Parse(s)
 	if err != nil {
@@ -67,6 +153,18 @@ func NewKeyFromURL(orig string) (*Key, error) {
 	}, nil
 }
 
+// cleanKeyURL removes a byte order mark, surrounding whitespace and quotes, and line breaks from s.
+func cleanKeyURL(s string) string {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "\ufeff"))
+	for _, quote := range []string{`"`, `'`, "`"} {
+		if len(s) >= 2 && strings.HasPrefix(s, quote) && strings.HasSuffix(s, quote) {
+			s = strings.TrimSpace(s[1 : len(s)-1])
+			break
+		}
+	}
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
 func (k *Key) String() string {
 	return k.orig
 }
@@ -115,6 +213,21 @@ func (k *Key) Secret() string {
 	return q.Get("secret")
 }
 
+// Fingerprint returns a short, stable identifier for the secret of this Key that can be safely
+// included in logs or support tickets. It is the hex encoded first 8 bytes of the SHA-256 hash of
+// the decoded secret, so differently formatted copies of the same secret share a fingerprint.
+func (k *Key) Fingerprint() string {
+	secret := k.Secret()
+
+	b, err := k.SecretAlphabet().DecodeSecret(secret)
+	if err != nil {
+		b = []byte(strings.ToUpper(strings.TrimSpace(secret)))
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
 // Period returns a tiny int representing the rotation time in seconds.
 func (k *Key) Period() uint64 {
 	q := k.url.Query()
@@ -127,12 +240,30 @@ func (k *Key) Period() uint64 {
 	return 30
 }
 
+// Counter returns the initial counter value of an HOTP key, or 0 if it is not set.
+func (k *Key) Counter() uint64 {
+	q := k.url.Query()
+
+	if u, err := strconv.ParseUint(q.Get("counter"), 10, 64); err == nil {
+		return u
+	}
+
+	return 0
+}
+
 // Digits returns a tiny int representing the number of OTP digits.
 func (k *Key) Digits() Digits {
 	q := k.url.Query()
 
+	// Steam Guard codes are always five characters.
+	if k.Encoder() == EncoderSteam {
+		return DigitsSteam
+	}
+
 	if u, err := strconv.ParseUint(q.Get("digits"), 10, 64); err == nil {
 		switch u {
+		case 7:
+			return DigitsSeven
 		case 8:
 			return DigitsEight
 		default:
@@ -161,6 +292,101 @@ func (k *Key) Algorithm() Algorithm {
 	}
 }
 
+// ExpiresAt returns the time after which this key is no longer valid, or the zero time if the key
+// does not expire.
+func (k *Key) ExpiresAt() time.Time {
+	q := k.url.Query()
+
+	if i, err := strconv.ParseInt(q.Get("expires"), 10, 64); err == nil {
+		return time.Unix(i, 0).UTC()
+	}
+
+	return time.Time{}
+}
+
+// NotBefore returns the time before which this key may not be used, or the zero time if the key is
+// valid immediately.
+func (k *Key) NotBefore() time.Time {
+	q := k.url.Query()
+
+	if i, err := strconv.ParseInt(q.Get("notbefore"), 10, 64); err == nil {
+		return time.Unix(i, 0).UTC()
+	}
+
+	return time.Time{}
+}
+
+// Scopes returns the operations this key is restricted to, or nil if the key may be used for any
+// operation.
+func (k *Key) Scopes() []string {
+	q := k.url.Query()
+
+	scope := q.Get("scope")
+	if scope == "" {
+		return nil
+	}
+
+	return strings.Split(scope, ",")
+}
+
+// Profile returns the name of the profile the key was generated with, or "" if none was recorded.
+// See ProfileRegistry.
+func (k *Key) Profile() string {
+	q := k.url.Query()
+
+	return q.Get("profile")
+}
+
+// ProfileVersion returns the version of the profile the key was generated with, or 0 if none was
+// recorded. See Profile.Version.
+func (k *Key) ProfileVersion() uint {
+	q := k.url.Query()
+
+	version, err := strconv.ParseUint(q.Get("profile_version"), 10, 0)
+	if err != nil {
+		return 0
+	}
+	return uint(version)
+}
+
+// SecretAlphabet returns the base32 alphabet the secret of this key is written in.
+func (k *Key) SecretAlphabet() SecretAlphabet {
+	q := k.url.Query()
+
+	switch strings.ToLower(q.Get("alphabet")) {
+	case "zbase32":
+		return SecretAlphabetZBase32
+	case "crockford":
+		return SecretAlphabetCrockford
+	default:
+		return SecretAlphabetRFC4648
+	}
+}
+
+// Encoder returns how passcodes for this key are rendered, or EncoderDefault for decimal digits.
+func (k *Key) Encoder() Encoder {
+	q := k.url.Query()
+
+	switch strings.ToLower(q.Get("encoder")) {
+	case "steam":
+		return EncoderSteam
+	default:
+		return EncoderDefault
+	}
+}
+
+// TestKeyIssuerPrefix is the prefix required on the issuer of every test key.
+const TestKeyIssuerPrefix = "TEST-"
+
+// IsTestKey returns true if this key is a deterministic test key, whose secret can be derived by anyone
+// who knows its label. Test keys are marked with a "test" parameter and an issuer beginning with
+// TestKeyIssuerPrefix, and a key with either is treated as a test key.
+func (k *Key) IsTestKey() bool {
+	q := k.url.Query()
+
+	return q.Get("test") == "1" || strings.HasPrefix(k.Issuer(), TestKeyIssuerPrefix)
+}
+
 // URL returns the OTP URL as a string
 func (k *Key) URL() string {
 	return k.url.String()
@@ -214,13 +440,25 @@ type Digits int
 
 const (
 	DigitsSix   Digits = 6
+	DigitsSeven Digits = 7
 	DigitsEight Digits = 8
+	// DigitsSteam is the length of Steam Guard passcodes, see EncoderSteam.
+	DigitsSteam Digits = 5
 )
 
 // Format converts an integer into the zero-filled size for this Digits.
 func (d Digits) Format(in int32) string {
-	f := fmt.Sprintf("%%0%dd", d)
-	return fmt.Sprintf(f, in)
+	if in < 0 {
+		f := fmt.Sprintf("%%0%dd", d)
+		return fmt.Sprintf(f, in)
+	}
+
+	// Passcodes are formatted on every generate and validate, so avoid the cost of fmt here.
+	s := strconv.FormatInt(int64(in), 10)
+	if n := d.Length() - len(s); n > 0 {
+		s = strings.Repeat("0", n) + s
+	}
+	return s
 }
 
 // Length returns the number of characters for this Digits.
@@ -231,3 +469,13 @@ func (d Digits) Length() int {
 func (d Digits) String() string {
 	return fmt.Sprintf("%d", d)
 }
+
+// Encoder represents how the truncated HMAC value is rendered as a passcode.
+type Encoder string
+
+const (
+	// EncoderDefault renders passcodes as zero-filled decimal digits, as described by RFC 4226.
+	EncoderDefault Encoder = ""
+	// EncoderSteam renders passcodes using the alphanumeric alphabet used by Steam Guard.
+	EncoderSteam Encoder = "steam"
+)
@@ -18,7 +18,10 @@
 package otp
 
 import (
+	"hash"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestKeyAllThere(t *testing.T) {
@@ -83,3 +86,180 @@ func TestKeyWithNewLine(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestParseKeyURL(t *testing.T) {
+	const u = "otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example"
+	pasted := []string{
+		"\ufeff" + u,
+		" \"" + u + "\"\r\n",
+		"'" + u + "'",
+		"`" + u + "`",
+		"otpauth://totp/Example:alice@google.com?secret=\r\nJBSWY3DPEHPK3PXP&issuer=Example",
+	}
+
+	for _, s := range pasted {
+		k, err := NewKeyFromURL(s)
+		if err != nil {
+			t.Fatalf("Error: %s", err.Error())
+		}
+		if u != k.String() || "JBSWY3DPEHPK3PXP" != k.Secret() || "Example" != k.Issuer() {
+			t.Fatalf("Unexpected key '%s' from %q", k.String(), s)
+		}
+
+		if _, err := ParseKeyURL(s, ParseStrict); ErrKeyURLMalformed != err {
+			t.Fatalf("Expected malformed error in strict mode for %q", s)
+		}
+	}
+
+	if _, err := ParseKeyURL(u, ParseStrict); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+}
+
+func TestKeyExpiresAt(t *testing.T) {
+	k, err := NewKeyFromURL(`otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&expires=1700000000`)
+	if err != nil {
+		t.Fatalf("failed to parse url")
+	}
+	if !k.ExpiresAt().Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("Extracting ExpiresAt")
+	}
+
+	k, err = NewKeyFromURL(`otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP`)
+	if err != nil {
+		t.Fatalf("failed to parse url")
+	}
+	if !k.ExpiresAt().IsZero() {
+		t.Fatalf("ExpiresAt should be zero when not set")
+	}
+}
+
+func TestKeyScopes(t *testing.T) {
+	k, err := NewKeyFromURL(`otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&scope=login,withdrawal`)
+	if err != nil {
+		t.Fatalf("failed to parse url")
+	}
+	scopes := k.Scopes()
+	if len(scopes) != 2 || scopes[0] != "login" || scopes[1] != "withdrawal" {
+		t.Fatalf("Extracting Scopes")
+	}
+
+	k, err = NewKeyFromURL(`otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP`)
+	if err != nil {
+		t.Fatalf("failed to parse url")
+	}
+	if k.Scopes() != nil {
+		t.Fatalf("Scopes should be nil when not set")
+	}
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	a, _ := NewKeyFromURL(`otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP`)
+	b, _ := NewKeyFromURL(`otpauth://totp/Other:bob@google.com?secret=jbswy3dpehpk3pxp`)
+	c, _ := NewKeyFromURL(`otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXQ`)
+
+	if 16 != len(a.Fingerprint()) {
+		t.Fatalf("Fingerprint should be 16 characters.")
+	}
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Fatalf("Fingerprint should only depend on the secret.")
+	}
+	if a.Fingerprint() == c.Fingerprint() {
+		t.Fatalf("Fingerprint should differ for different secrets.")
+	}
+	if strings.Contains(a.Fingerprint(), a.Secret()) {
+		t.Fatalf("Fingerprint should not contain the secret.")
+	}
+}
+
+func BenchmarkNewKeyFromURL(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewKeyFromURL(`otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example&algorithm=sha256&digits=8`)
+	}
+}
+
+func BenchmarkDigitsFormat(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		DigitsSix.Format(int32(i % 1000000))
+	}
+}
+
+func TestDigitsFormat(t *testing.T) {
+	tests := []struct {
+		Digits   Digits
+		In       int32
+		Expected string
+	}{
+		{DigitsSix, 0, "000000"},
+		{DigitsSix, 42, "000042"},
+		{DigitsSix, 123456, "123456"},
+		{DigitsEight, 1234, "00001234"},
+		{DigitsEight, 12345678, "12345678"},
+		{DigitsSix, -42, "-00042"},
+	}
+	for _, tx := range tests {
+		if out := tx.Digits.Format(tx.In); tx.Expected != out {
+			t.Fatalf("'%s' does not equal '%s'", tx.Expected, out)
+		}
+	}
+}
+
+func TestKeyNotBefore(t *testing.T) {
+	k, err := NewKeyFromURL(`otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&notbefore=1700000000`)
+	if err != nil {
+		t.Fatalf("failed to parse url")
+	}
+	if !k.NotBefore().Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("Extracting NotBefore")
+	}
+
+	k, err = NewKeyFromURL(`otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP`)
+	if err != nil {
+		t.Fatalf("failed to parse url")
+	}
+	if !k.NotBefore().IsZero() {
+		t.Fatalf("NotBefore should be zero when not set")
+	}
+}
+
+func TestKeyCounter(t *testing.T) {
+	k, err := NewKeyFromURL(`otpauth://hotp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&counter=42`)
+	if err != nil {
+		t.Fatalf("failed to parse url")
+	}
+	if 42 != k.Counter() {
+		t.Fatalf("Extracting Counter")
+	}
+
+	k, err = NewKeyFromURL(`otpauth://hotp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP`)
+	if err != nil {
+		t.Fatalf("failed to parse url")
+	}
+	if 0 != k.Counter() {
+		t.Fatalf("Counter should be 0 when not set")
+	}
+}
+
+// The v1 API of github.com/pquerna/otp must keep compiling after switching imports.
+func TestPquernaCompatibility(t *testing.T) {
+	var k *Key
+	var _ func(string) (*Key, error) = NewKeyFromURL
+	var _ func() string = k.String
+	var _ func() string = k.Type
+	var _ func() string = k.Issuer
+	var _ func() string = k.AccountName
+	var _ func() string = k.Secret
+	var _ func() string = k.URL
+	var _ func() uint64 = k.Period
+	var _ func() Digits = k.Digits
+	var _ func() Algorithm = k.Algorithm
+	var _ func() Encoder = k.Encoder
+	var _ func(int32) string = DigitsSix.Format
+	var _ func() int = DigitsEight.Length
+	var _ func() hash.Hash = AlgorithmSHA1.Hash
+	var _ []Algorithm = []Algorithm{AlgorithmSHA1, AlgorithmSHA256, AlgorithmSHA512, AlgorithmMD5}
+	var _ []Encoder = []Encoder{EncoderDefault, EncoderSteam}
+	var _ []error = []error{ErrValidateSecretInvalidBase32, ErrValidateInputInvalidLength, ErrGenerateMissingIssuer, ErrGenerateMissingAccountName}
+}
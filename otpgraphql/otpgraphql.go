@@ -0,0 +1,146 @@
+// Package otpgraphql adapts enrollment and validation to GraphQL resolvers. Resolvers receive a context rather than
+// an HTTP request, and report errors in the response's "errors" list, with machine-readable details
+// in the error's "extensions". The functions here take the time and the user's language from the
+// context, and return errors as *Error, whose Extensions method is recognised by the common Go
+// GraphQL servers.
+package otpgraphql
+
+import (
+	"context"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/hotp"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+// ErrConfirmFailed is returned by ConfirmTOTP and ConfirmHOTP when the passcode is not valid.
+var ErrConfirmFailed = otp.NewCodedError("OTP_GRAPHQL_CONFIRM_FAILED", "Passcode does not confirm the key")
+
+// Error is a validation error for a GraphQL response.
+type Error struct {
+	// Message shown to the user, see otp.UserMessage.
+	Message string
+	// Code is the stable error code, see otp.ErrorCode.
+	Code string
+	// MessageID identifies Message for clients with their own translations, see otp.MessageID.
+	MessageID string
+	// Err is the original error.
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Extensions returns the "extensions" of the GraphQL error.
+func (e *Error) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":      e.Code,
+		"messageId": e.MessageID,
+	}
+}
+
+// NewError returns err as an *Error, with its message translated by the translator in ctx. It returns
+// nil if err is nil.
+func NewError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	translate, _ := ctx.Value(translatorKey{}).(otp.Translator)
+	id, _ := otp.MessageID(err)
+	return &Error{
+		Message:   otp.UserMessage(err, translate),
+		Code:      otp.ErrorCode(err),
+		MessageID: id,
+		Err:       err,
+	}
+}
+
+type timeKey struct{}
+type translatorKey struct{}
+
+// WithTime returns a context in which validation happens at t instead of the current time, for tests.
+func WithTime(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, timeKey{}, t)
+}
+
+// WithTranslator returns a context in which error messages are translated by translate.
+func WithTranslator(ctx context.Context, translate otp.Translator) context.Context {
+	return context.WithValue(ctx, translatorKey{}, translate)
+}
+
+// Now returns the time validation happens at in ctx (see WithTime).
+func Now(ctx context.Context) time.Time {
+	if t, ok := ctx.Value(timeKey{}).(time.Time); ok {
+		return t
+	}
+	return time.Now()
+}
+
+// ValidateTOTP validates a TOTP passcode for k, as totp.ValidateKey does. It returns the context's
+// error if it is already done.
+func ValidateTOTP(ctx context.Context, passcode string, k *otp.Key, options ...totp.Option) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	valid, err := totp.ValidateKey(passcode, k, Now(ctx), options...)
+	return valid, NewError(ctx, err)
+}
+
+// ValidateHOTP validates a HOTP passcode for k at counter, as hotp.ValidateKey does. It returns the
+// context's error if it is already done.
+func ValidateHOTP(ctx context.Context, passcode string, counter uint64, k *otp.Key, options ...hotp.Option) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	valid, err := hotp.ValidateKey(passcode, counter, k, options...)
+	return valid, NewError(ctx, err)
+}
+
+// EnrollTOTP generates a new TOTP key, as totp.GenerateWith does. Store the key as pending until a
+// passcode from the user's device confirms it (see ConfirmTOTP). It returns the context's error if it
+// is already done.
+func EnrollTOTP(ctx context.Context, options ...totp.Option) (*otp.Key, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	k, err := totp.GenerateWith(options...)
+	return k, NewError(ctx, err)
+}
+
+// EnrollHOTP generates a new HOTP key, as hotp.GenerateWith does. Store the key as pending until a
+// passcode from the user's device confirms it (see ConfirmHOTP). It returns the context's error if it
+// is already done.
+func EnrollHOTP(ctx context.Context, options ...hotp.Option) (*otp.Key, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	k, err := hotp.GenerateWith(options...)
+	return k, NewError(ctx, err)
+}
+
+// ConfirmTOTP checks the first passcode from a newly enrolled device, as ValidateTOTP does, so that a
+// key is only activated once the user has shown that their authenticator has it. Unlike ValidateTOTP,
+// an incorrect passcode is an error, ErrConfirmFailed, so that a confirm mutation fails with it.
+func ConfirmTOTP(ctx context.Context, passcode string, k *otp.Key, options ...totp.Option) error {
+	valid, err := ValidateTOTP(ctx, passcode, k, options...)
+	if err == nil && !valid {
+		err = NewError(ctx, ErrConfirmFailed)
+	}
+	return err
+}
+
+// ConfirmHOTP checks the first passcode from a newly enrolled device at counter, as ValidateHOTP
+// does. An incorrect passcode is an error, ErrConfirmFailed, like for ConfirmTOTP.
+func ConfirmHOTP(ctx context.Context, passcode string, counter uint64, k *otp.Key, options ...hotp.Option) error {
+	valid, err := ValidateHOTP(ctx, passcode, counter, k, options...)
+	if err == nil && !valid {
+		err = NewError(ctx, ErrConfirmFailed)
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package otpgraphql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/hotp"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+func TestValidateTOTP(t *testing.T) {
+	k, _ := otp.NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil")
+	now := time.Unix(1700000000, 0)
+	ctx := WithTime(context.Background(), now)
+	code, _ := totp.GenerateCode(k.Secret(), now)
+
+	valid, err := ValidateTOTP(ctx, code, k)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatalf("Valid should be true at the time in the context.")
+	}
+
+	ctx = WithTranslator(ctx, func(id string, english string) string {
+		return "translated " + id
+	})
+	_, err = ValidateTOTP(ctx, "123", k)
+	gqlErr := &Error{}
+	if !errors.As(err, &gqlErr) || !errors.Is(err, otp.ErrValidateInputInvalidLength) {
+		t.Fatalf("Expected a GraphQL error wrapping the invalid length error.")
+	}
+	if "translated otp.invalid_length" != gqlErr.Message || "OTP_INVALID_LENGTH" != gqlErr.Extensions()["code"] {
+		t.Fatalf("Unexpected error %+v", gqlErr)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := ValidateTOTP(cancelled, code, k); context.Canceled != err {
+		t.Fatalf("Expected the context error.")
+	}
+}
+
+func TestValidateHOTP(t *testing.T) {
+	k, _ := otp.NewKeyFromURL("otpauth://hotp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil")
+	code, _ := hotp.GenerateCode(k.Secret(), 5)
+
+	valid, err := ValidateHOTP(context.Background(), code, 5, k)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatalf("Valid should be true.")
+	}
+
+	_, err = ValidateHOTP(context.Background(), code, hotp.CounterLimit, k)
+	if "OTP_COUNTER_EXHAUSTED" != otp.ErrorCode(err) {
+		t.Fatalf("Expected counter exhausted error, got %v", err)
+	}
+}
+
+func TestEnrollConfirmTOTP(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	ctx := WithTime(context.Background(), now)
+
+	k, err := EnrollTOTP(ctx, totp.WithIssuer("SnakeOil"), totp.WithAccountName("alice"))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "totp" != k.Type() || "alice" != k.AccountName() {
+		t.Fatalf("Unexpected key %s", k.String())
+	}
+	if _, err := EnrollTOTP(ctx, totp.WithIssuer("SnakeOil")); "OTP_MISSING_ACCOUNT_NAME" != otp.ErrorCode(err) {
+		t.Fatalf("Expected missing account name error, got %v", err)
+	}
+
+	code, _ := totp.GenerateCode(k.Secret(), now)
+	if err := ConfirmTOTP(ctx, code, k); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	err = ConfirmTOTP(ctx, wrong, k)
+	gqlErr := &Error{}
+	if !errors.As(err, &gqlErr) || !errors.Is(err, ErrConfirmFailed) || "OTP_GRAPHQL_CONFIRM_FAILED" != gqlErr.Code {
+		t.Fatalf("Expected a GraphQL error wrapping the confirm failed error, got %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := EnrollTOTP(cancelled); context.Canceled != err {
+		t.Fatalf("Expected the context error.")
+	}
+}
+
+func TestEnrollConfirmHOTP(t *testing.T) {
+	k, err := EnrollHOTP(context.Background(), hotp.WithIssuer("SnakeOil"), hotp.WithAccountName("alice"))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "hotp" != k.Type() {
+		t.Fatalf("Unexpected key %s", k.String())
+	}
+
+	k, _ = otp.NewKeyFromURL("otpauth://hotp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil")
+	code, _ := hotp.GenerateCode(k.Secret(), 0)
+	if err := ConfirmHOTP(context.Background(), code, 0, k); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := ConfirmHOTP(context.Background(), code, 1, k); !errors.Is(err, ErrConfirmFailed) {
+		t.Fatalf("Expected confirm failed error, got %v", err)
+	}
+}
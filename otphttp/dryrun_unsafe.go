@@ -0,0 +1,17 @@
+//go:build otp_unsafe
+
+package otphttp
+
+import (
+	"net/http"
+	"time"
+)
+
+// ValidateDryRun runs the same checks as Validate, including the key lookup and the replay cache,
+// without recording the passcode as used. It is meant for canary testing and for support tooling,
+// and must never be used to decide whether to authenticate a request, because the passcode can be
+// accepted again. It is only built with the otp_unsafe build tag.
+func (h *AuthRequest) ValidateDryRun(r *http.Request, t time.Time) (bool, error) {
+	_, _, ok, err := h.validate(r, t, false)
+	return ok, err
+}
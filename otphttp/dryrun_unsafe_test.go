@@ -0,0 +1,39 @@
+//go:build otp_unsafe
+
+package otphttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+func TestAuthRequestValidateDryRun(t *testing.T) {
+	h := NewAuthRequest(testKeys)
+	now := time.Unix(1111111109, 0)
+	code, _ := totp.GenerateCode("JBSWY3DPEHPK3PXP", now)
+	r := httptest.NewRequest(http.MethodGet, "/otp", nil)
+	r.Header.Set("X-OTP-Account", "alice")
+	r.Header.Set("X-OTP-Code", code)
+
+	for i := 0; i < 2; i++ {
+		valid, err := h.ValidateDryRun(r, now)
+		if err != nil {
+			t.Fatalf("Error: %s", err.Error())
+		}
+		if !valid {
+			t.Fatalf("Valid should be true.")
+		}
+	}
+	// The dry runs left the passcode unused.
+	if valid, err := h.Validate(r, now); err != nil || !valid {
+		t.Fatalf("Expected the passcode to be accepted after dry runs: %v", err)
+	}
+	if _, err := h.ValidateDryRun(r, now); otp.ErrValidateReplayed != err {
+		t.Fatalf("Unexpected error for a replayed passcode: %v", err)
+	}
+}
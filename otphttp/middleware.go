@@ -0,0 +1,94 @@
+package otphttp
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+// ErrConfigNoAccounts is returned by NewMiddleware when the configuration has no accounts.
+var ErrConfigNoAccounts = otp.NewCodedError("OTP_HTTP_NO_ACCOUNTS", "Configuration must have at least one account")
+
+// ErrConfigInvalidKey is returned by NewMiddleware when an account's URL is not a TOTP key.
+var ErrConfigInvalidKey = otp.NewCodedError("OTP_HTTP_INVALID_KEY", "Account must have a TOTP key with a secret")
+
+// Config configures a middleware created by NewMiddleware. It can be unmarshalled from JSON, or from
+// the configuration of a reverse proxy plugin (see the traefik package).
+type Config struct {
+	// Accounts maps account names to otpauth URLs of their TOTP keys. Required.
+	Accounts map[string]string `json:"accounts"`
+	// Request header holding the account name. Defaults to DefaultAccountHeader.
+	AccountHeader string `json:"accountHeader,omitempty"`
+	// Request header holding the passcode. Defaults to DefaultCodeHeader.
+	CodeHeader string `json:"codeHeader,omitempty"`
+	// Periods before or after the current time to allow. Defaults to 0, use DefaultConfig for the
+	// usual skew of 1.
+	Skew uint `json:"skew"`
+	// Seconds during which a retry with the same idempotency key is accepted (see
+	// AuthRequest.IdempotencyWindow). Defaults to 0, rejecting every retry.
+	IdempotencySeconds uint `json:"idempotencySeconds,omitempty"`
+}
+
+// DefaultConfig returns a Config with the default headers and a skew of 1, to which accounts must be
+// added.
+func DefaultConfig() *Config {
+	return &Config{
+		Accounts:      map[string]string{},
+		AccountHeader: DefaultAccountHeader,
+		CodeHeader:    DefaultCodeHeader,
+		Skew:          1,
+	}
+}
+
+// NewMiddleware returns a handler that only passes requests with a valid passcode on to next, as
+// checked by AuthRequest, and responds 401 Unauthorized to all others.
+func NewMiddleware(next http.Handler, config Config) (http.Handler, error) {
+	if len(config.Accounts) == 0 {
+		return nil, ErrConfigNoAccounts
+	}
+
+	keys := map[string]*otp.Key{}
+	names := []string{}
+	for name := range config.Accounts {
+		names = append(names, name)
+	}
+	// Check accounts in a stable order so that errors are reproducible.
+	sort.Strings(names)
+	for _, name := range names {
+		k, err := otp.NewKeyFromURL(config.Accounts[name])
+		if err != nil {
+			return nil, err
+		}
+		if _, err := k.SecretAlphabet().DecodeSecret(k.Secret()); err != nil || k.Type() != "totp" || k.Secret() == "" {
+			return nil, ErrConfigInvalidKey
+		}
+		keys[name] = k
+	}
+
+	auth := NewAuthRequest(func(account string) (*otp.Key, error) {
+		return keys[account], nil
+	})
+	auth.AccountHeader = config.AccountHeader
+	auth.CodeHeader = config.CodeHeader
+	auth.Options = []totp.Option{totp.WithSkew(config.Skew)}
+	auth.IdempotencyWindow = time.Duration(config.IdempotencySeconds) * time.Second
+
+	return &middleware{auth: auth, next: next}, nil
+}
+
+type middleware struct {
+	auth *AuthRequest
+	next http.Handler
+}
+
+func (m *middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ok, err := m.auth.Validate(r, time.Now())
+	if err != nil || !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	m.next.ServeHTTP(w, r)
+}
@@ -0,0 +1,57 @@
+package otphttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp/totp"
+)
+
+func TestNewMiddleware(t *testing.T) {
+	config := Config{}
+	if err := json.Unmarshal([]byte(`{"accounts":{"alice":"`+testURL+`"},"codeHeader":"X-Passcode","skew":1}`), &config); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	h, err := NewMiddleware(next, config)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	request := func(code string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-OTP-Account", "alice")
+		r.Header.Set("X-Passcode", code)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	code, _ := totp.GenerateCode("JBSWY3DPEHPK3PXP", time.Now())
+	if status := request(code); http.StatusNoContent != status {
+		t.Fatalf("Unexpected status %d for a valid passcode", status)
+	}
+	if status := request(code); http.StatusUnauthorized != status {
+		t.Fatalf("Unexpected status %d for a replayed passcode", status)
+	}
+}
+
+func TestNewMiddlewareInvalid(t *testing.T) {
+	if _, err := NewMiddleware(nil, *DefaultConfig()); ErrConfigNoAccounts != err {
+		t.Fatalf("Expected no accounts error.")
+	}
+
+	for _, url := range []string{"otpauth://hotp/Example:alice?secret=JBSWY3DPEHPK3PXP", "otpauth://totp/Example:alice?secret=1", "otpauth://totp/Example:alice"} {
+		config := DefaultConfig()
+		config.Accounts["alice"] = url
+		if _, err := NewMiddleware(nil, *config); ErrConfigInvalidKey != err {
+			t.Fatalf("Expected invalid key error for '%s'.", url)
+		}
+	}
+}
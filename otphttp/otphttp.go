@@ -0,0 +1,175 @@
+// Package otphttp gates HTTP services with TOTP passcodes.
+//
+// AuthRequest implements the subrequest contract of the nginx auth_request module (and Apache's
+// equivalents), so a reverse proxy can require a passcode for an internal tool without changing the
+// tool. For example, with nginx:
+//
+//	location / {
+//		auth_request /otp;
+//		proxy_pass http://tool;
+//	}
+//	location = /otp {
+//		internal;
+//		proxy_pass http://127.0.0.1:8081;
+//		proxy_pass_request_body off;
+//		proxy_set_header Content-Length "";
+//	}
+package otphttp
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/assertion"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+// KeyFunc returns the TOTP key for an account, or nil if there is no such account.
+type KeyFunc func(account string) (*otp.Key, error)
+
+const (
+	// DefaultAccountHeader is the request header holding the account name.
+	DefaultAccountHeader = "X-OTP-Account"
+	// DefaultCodeHeader is the request header holding the passcode.
+	DefaultCodeHeader = "X-OTP-Code"
+	// DefaultIdempotencyHeader is the request header holding the idempotency key of a request.
+	DefaultIdempotencyHeader = "Idempotency-Key"
+	// AssertionHeader is the response header holding the assertion minted for a valid passcode.
+	AssertionHeader = "X-OTP-Assertion"
+)
+
+// AuthRequest is an http.Handler that responds 200 OK if the request carries a valid passcode for
+// its account, and 401 Unauthorized otherwise. Each passcode is only accepted once.
+type AuthRequest struct {
+	// Keys looks up the key of an account.
+	Keys KeyFunc
+	// Replay remembers accepted passcodes.
+	Replay *ReplayCache
+	// Request header holding the account name. Defaults to DefaultAccountHeader.
+	AccountHeader string
+	// Request header holding the passcode. Defaults to DefaultCodeHeader.
+	CodeHeader string
+	// How long after a passcode is accepted a retry of the request with the same idempotency key is
+	// accepted too, for mobile clients on unreliable networks. Requests without an idempotency key, or
+	// with a different one, are always rejected as replays. Defaults to 0, rejecting every retry.
+	IdempotencyWindow time.Duration
+	// Request header holding the idempotency key. Defaults to DefaultIdempotencyHeader.
+	IdempotencyHeader string
+	// Options applied after the settings recorded in the key, such as totp.WithSkew.
+	Options []totp.Option
+	// Assertions, if set, mints an assertion for every valid passcode and sends it in the
+	// AssertionHeader response header, which the proxy can forward to the upstream service (with
+	// nginx, using auth_request_set). Defaults to none.
+	Assertions *assertion.Signer
+}
+
+// NewAuthRequest returns an AuthRequest that looks up keys with keys and has its own ReplayCache.
+func NewAuthRequest(keys KeyFunc) *AuthRequest {
+	return &AuthRequest{
+		Keys:   keys,
+		Replay: NewReplayCache(),
+	}
+}
+
+// ServeHTTP validates the passcode of the request.
+func (h *AuthRequest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	now := time.Now()
+	account, k, ok, err := h.validate(r, now, true)
+	if err != nil && err != otp.ErrValidateReplayed {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if h.Assertions != nil {
+		token, err := h.Assertions.Mint(account, k.Fingerprint(), now)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(AssertionHeader, token)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Validate returns true if r carries a passcode for its account that is valid at t and has not been
+// used before. err is otp.ErrValidateReplayed if the passcode was valid but already used, and is
+// otherwise only set if the key could not be looked up.
+func (h *AuthRequest) Validate(r *http.Request, t time.Time) (bool, error) {
+	_, _, ok, err := h.validate(r, t, true)
+	return ok, err
+}
+
+// validate checks the passcode in r at t, and records it in the replay cache if record is true.
+func (h *AuthRequest) validate(r *http.Request, t time.Time, record bool) (string, *otp.Key, bool, error) {
+	accountHeader := h.AccountHeader
+	if accountHeader == "" {
+		accountHeader = DefaultAccountHeader
+	}
+	codeHeader := h.CodeHeader
+	if codeHeader == "" {
+		codeHeader = DefaultCodeHeader
+	}
+
+	account := strings.TrimSpace(r.Header.Get(accountHeader))
+	passcode := strings.TrimSpace(r.Header.Get(codeHeader))
+	if account == "" || passcode == "" {
+		return "", nil, false, nil
+	}
+
+	k, err := h.Keys(account)
+	if err != nil {
+		return "", nil, false, err
+	}
+	if k == nil || k.Type() != "totp" {
+		return "", nil, false, nil
+	}
+
+	opts := totp.ValidateOpts{
+		Period:    30,
+		Skew:      1,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	}
+	totp.FromKey(k)(nil, &opts)
+	for _, option := range h.Options {
+		option(nil, &opts)
+	}
+
+	drift, valid, err := totp.ValidateDrift(passcode, k.Secret(), t, opts)
+	if err != nil || !valid {
+		return "", nil, false, nil
+	}
+
+	// The passcode is remembered for as long as it could still be accepted.
+	period := int64(opts.Period)
+	step := t.Unix()/period + drift
+	expires := time.Unix((step+1)*period, 0).Add(time.Duration(opts.Skew)*time.Duration(period)*time.Second + opts.ClockUncertainty)
+	idempotencyHeader := h.IdempotencyHeader
+	if idempotencyHeader == "" {
+		idempotencyHeader = DefaultIdempotencyHeader
+	}
+	idempotencyKey := ""
+	if h.IdempotencyWindow > 0 {
+		idempotencyKey = r.Header.Get(idempotencyHeader)
+	}
+	id := account + "\x00" + k.Fingerprint() + "\x00" + strconv.FormatInt(step, 10)
+	if !record {
+		if h.Replay.seen(id, t) {
+			return "", nil, false, otp.ErrValidateReplayed
+		}
+		return account, k, true, nil
+	}
+	if !h.Replay.UseIdempotent(id, idempotencyKey, t, expires, h.IdempotencyWindow) {
+		return "", nil, false, otp.ErrValidateReplayed
+	}
+	return account, k, true, nil
+}
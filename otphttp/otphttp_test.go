@@ -0,0 +1,158 @@
+package otphttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/assertion"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+const testURL = "otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil"
+
+func testKeys(account string) (*otp.Key, error) {
+	switch account {
+	case "alice":
+		return otp.NewKeyFromURL(testURL)
+	case "broken":
+		return nil, errors.New("store is unavailable")
+	}
+	return nil, nil
+}
+
+func authRequest(h http.Handler, account string, code string) int {
+	r := httptest.NewRequest(http.MethodGet, "/otp", nil)
+	if account != "" {
+		r.Header.Set("X-OTP-Account", account)
+	}
+	if code != "" {
+		r.Header.Set("X-OTP-Code", code)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec.Code
+}
+
+func TestAuthRequest(t *testing.T) {
+	h := NewAuthRequest(testKeys)
+	code, _ := totp.GenerateCode("JBSWY3DPEHPK3PXP", time.Now())
+
+	if status := authRequest(h, "alice", code); http.StatusOK != status {
+		t.Fatalf("Unexpected status %d for a valid passcode", status)
+	}
+	if status := authRequest(h, "alice", code); http.StatusUnauthorized != status {
+		t.Fatalf("Unexpected status %d for a replayed passcode", status)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/otp", nil)
+	r.Header.Set("X-OTP-Account", "alice")
+	r.Header.Set("X-OTP-Code", code)
+	if _, err := h.Validate(r, time.Now()); otp.ErrValidateReplayed != err {
+		t.Fatalf("Unexpected error for a replayed passcode: %v", err)
+	}
+
+	for _, test := range [][2]string{{"alice", "000000"}, {"bob", code}, {"", code}, {"alice", ""}} {
+		if status := authRequest(h, test[0], test[1]); http.StatusUnauthorized != status {
+			t.Fatalf("Unexpected status %d for %v", status, test)
+		}
+	}
+	if status := authRequest(h, "broken", code); http.StatusInternalServerError != status {
+		t.Fatalf("Unexpected status %d when the key can't be looked up", status)
+	}
+}
+
+func TestAuthRequestHeaders(t *testing.T) {
+	h := NewAuthRequest(testKeys)
+	h.AccountHeader = "Remote-User"
+	h.CodeHeader = "X-Passcode"
+
+	now := time.Unix(1700000000, 0)
+	code, _ := totp.GenerateCode("JBSWY3DPEHPK3PXP", now.Add(-30*time.Second))
+	r := httptest.NewRequest(http.MethodGet, "/otp", nil)
+	r.Header.Set("Remote-User", "alice")
+	r.Header.Set("X-Passcode", code)
+
+	valid, err := h.Validate(r, now)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatalf("Valid should be true within the default skew.")
+	}
+
+	if valid, _ := h.Validate(r, now); valid {
+		t.Fatalf("Valid should be false for a replay at a past time.")
+	}
+
+	h.Options = []totp.Option{totp.WithSkew(0)}
+	h.Replay = NewReplayCache()
+	if valid, _ := h.Validate(r, now); valid {
+		t.Fatalf("Valid should be false without skew.")
+	}
+}
+
+func TestAuthRequestAssertion(t *testing.T) {
+	h := NewAuthRequest(testKeys)
+	h.Assertions = &assertion.Signer{Key: []byte("01234567890123456789012345678901")}
+
+	code, _ := totp.GenerateCode("JBSWY3DPEHPK3PXP", time.Now())
+	r := httptest.NewRequest(http.MethodGet, "/otp", nil)
+	r.Header.Set("X-OTP-Account", "alice")
+	r.Header.Set("X-OTP-Code", code)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if http.StatusOK != rec.Code {
+		t.Fatalf("Unexpected status %d", rec.Code)
+	}
+
+	claims, err := h.Assertions.Verify(rec.Header().Get(AssertionHeader), time.Now())
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	k, _ := otp.NewKeyFromURL(testURL)
+	if "alice" != claims.Subject || k.Fingerprint() != claims.KeyFingerprint {
+		t.Fatalf("Unexpected claims %+v", claims)
+	}
+
+	// No assertion is sent for a rejected passcode.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if http.StatusUnauthorized != rec.Code || "" != rec.Header().Get(AssertionHeader) {
+		t.Fatalf("Expected a replayed passcode to be rejected without an assertion")
+	}
+}
+
+func TestAuthRequestIdempotency(t *testing.T) {
+	code, _ := totp.GenerateCode("JBSWY3DPEHPK3PXP", time.Now())
+	request := func(h http.Handler, idempotencyKey string) int {
+		r := httptest.NewRequest(http.MethodGet, "/otp", nil)
+		r.Header.Set("X-OTP-Account", "alice")
+		r.Header.Set("X-OTP-Code", code)
+		r.Header.Set("Idempotency-Key", idempotencyKey)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	h := NewAuthRequest(testKeys)
+	h.IdempotencyWindow = 10 * time.Second
+	if status := request(h, "abc"); http.StatusOK != status {
+		t.Fatalf("Unexpected status %d", status)
+	}
+	if status := request(h, "abc"); http.StatusOK != status {
+		t.Fatalf("Unexpected status %d for a retry", status)
+	}
+	if status := request(h, "def"); http.StatusUnauthorized != status {
+		t.Fatalf("Unexpected status %d for a replay", status)
+	}
+
+	// Retries are rejected unless a window is set.
+	h = NewAuthRequest(testKeys)
+	request(h, "abc")
+	if status := request(h, "abc"); http.StatusUnauthorized != status {
+		t.Fatalf("Unexpected status %d for a retry without a window", status)
+	}
+}
@@ -0,0 +1,58 @@
+package otphttp
+
+import (
+	"sync"
+	"time"
+)
+
+// ReplayCache remembers passcodes that have been accepted so that each can only be used once. It is
+// only suitable for a single process. It is safe for concurrent use.
+type ReplayCache struct {
+	lock sync.Mutex
+	used map[string]replayEntry
+}
+
+type replayEntry struct {
+	expires        time.Time
+	idempotencyKey string
+	first          time.Time
+}
+
+// NewReplayCache returns an empty ReplayCache.
+func NewReplayCache() *ReplayCache {
+	return &ReplayCache{used: map[string]replayEntry{}}
+}
+
+// Use records id as used at t until expires, discarding any records that have expired at t. It
+// returns false if id was already used.
+func (c *ReplayCache) Use(id string, t time.Time, expires time.Time) bool {
+	return c.UseIdempotent(id, "", t, expires, 0)
+}
+
+// UseIdempotent is Use for requests that carry an idempotency key, so that a client retrying a request
+// whose response was lost isn't rejected. If id was first used at most window before t with the same
+// non-empty idempotency key, it is accepted again.
+func (c *ReplayCache) UseIdempotent(id string, idempotencyKey string, t time.Time, expires time.Time, window time.Duration) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// id is looked up before anything is discarded, so that its own record can't be lost first.
+	if e, used := c.used[id]; used && t.Before(e.expires) {
+		return idempotencyKey != "" && e.idempotencyKey == idempotencyKey && t.Sub(e.first) <= window
+	}
+	for i, e := range c.used {
+		if !t.Before(e.expires) {
+			delete(c.used, i)
+		}
+	}
+	c.used[id] = replayEntry{expires: expires, idempotencyKey: idempotencyKey, first: t}
+	return true
+}
+
+// seen returns true if id was used and its record has not expired at t, without recording anything.
+func (c *ReplayCache) seen(id string, t time.Time) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, used := c.used[id]
+	return used && t.Before(e.expires)
+}
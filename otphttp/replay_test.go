@@ -0,0 +1,62 @@
+package otphttp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReplayCache(t *testing.T) {
+	c := NewReplayCache()
+	// Records expire by the time of the caller, not the clock of the machine.
+	now := time.Unix(1111111109, 0)
+	expires := now.Add(time.Minute)
+
+	if !c.Use("a", now, expires) {
+		t.Fatalf("Expected the first use to be accepted")
+	}
+	if c.Use("a", now, expires) {
+		t.Fatalf("Expected the second use to be rejected")
+	}
+	if !c.Use("b", now, expires) {
+		t.Fatalf("Expected another id to be accepted")
+	}
+
+	// Expired records are discarded.
+	c.Use("c", now, now.Add(-time.Second))
+	if !c.Use("c", now, expires) {
+		t.Fatalf("Expected an expired id to be accepted again")
+	}
+	if c.Use("b", now.Add(59*time.Second), expires) {
+		t.Fatalf("Expected an id to be rejected until it expires")
+	}
+	if !c.Use("b", expires, expires.Add(time.Minute)) {
+		t.Fatalf("Expected an id to be accepted once it has expired")
+	}
+}
+
+func TestReplayCacheIdempotent(t *testing.T) {
+	c := NewReplayCache()
+	now := time.Now()
+	expires := now.Add(time.Minute)
+
+	if !c.UseIdempotent("a", "retry-1", now, expires, 10*time.Second) {
+		t.Fatalf("Expected the first use to be accepted")
+	}
+	if !c.UseIdempotent("a", "retry-1", now.Add(5*time.Second), expires, 10*time.Second) {
+		t.Fatalf("Expected a retry within the window to be accepted")
+	}
+	if c.UseIdempotent("a", "retry-1", now.Add(11*time.Second), expires, 10*time.Second) {
+		t.Fatalf("Expected a retry after the window to be rejected")
+	}
+	if c.UseIdempotent("a", "retry-2", now, expires, 10*time.Second) {
+		t.Fatalf("Expected a different idempotency key to be rejected")
+	}
+	if c.UseIdempotent("a", "", now, expires, 10*time.Second) {
+		t.Fatalf("Expected a request without an idempotency key to be rejected")
+	}
+
+	c.UseIdempotent("b", "", now, expires, 10*time.Second)
+	if c.UseIdempotent("b", "", now, expires, 10*time.Second) {
+		t.Fatalf("Expected an empty idempotency key to never match")
+	}
+}
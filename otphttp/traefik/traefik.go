@@ -0,0 +1,34 @@
+// Package traefik exposes the otphttp middleware with the constructor and configuration functions
+// that Traefik expects of a middleware plugin, so proxies can require a passcode for every request:
+//
+//	http:
+//	  middlewares:
+//	    otp:
+//	      plugin:
+//	        otp:
+//	          accounts:
+//	            alice: "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP"
+//
+// Caddy modules must register themselves with the caddy package, which this module does not depend
+// on. A Caddy module can wrap otphttp.NewMiddleware in the same way as New does here.
+package traefik
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ecnepsnai/otp/otphttp"
+)
+
+// Config is the plugin configuration, see otphttp.Config.
+type Config = otphttp.Config
+
+// CreateConfig returns the default plugin configuration.
+func CreateConfig() *Config {
+	return otphttp.DefaultConfig()
+}
+
+// New creates the middleware named name, which passes requests with a valid passcode on to next.
+func New(ctx context.Context, next http.Handler, config *Config, name string) (http.Handler, error) {
+	return otphttp.NewMiddleware(next, *config)
+}
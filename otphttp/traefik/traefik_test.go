@@ -0,0 +1,34 @@
+package traefik
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp/totp"
+)
+
+func TestNew(t *testing.T) {
+	config := CreateConfig()
+	config.Accounts["alice"] = "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP"
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h, err := New(context.Background(), next, config, "otp")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	code, _ := totp.GenerateCode("JBSWY3DPEHPK3PXP", time.Now())
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-OTP-Account", "alice")
+	r.Header.Set("X-OTP-Code", code)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if http.StatusTeapot != rec.Code {
+		t.Fatalf("Unexpected status %d", rec.Code)
+	}
+}
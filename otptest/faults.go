@@ -0,0 +1,167 @@
+package otptest
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/bypass"
+	"github.com/ecnepsnai/otp/handoff"
+	"github.com/ecnepsnai/otp/store"
+)
+
+// ErrInjected is returned by operations failed by a Faults that doesn't set Err.
+var ErrInjected = otp.NewCodedError("OTP_TEST_INJECTED_FAULT", "Injected fault")
+
+// Faults describes the misbehavior of a store backend, such as a database that is slow or
+// unreachable. One Faults can be shared by the wrappers of several stores that use the same backend.
+// It is safe for concurrent use.
+type Faults struct {
+	// Latency added to every operation.
+	Latency time.Duration
+	// FailRate is the fraction of operations, from 0 to 1, that fail. Defaults to 0.
+	FailRate float64
+	// Seed for choosing which operations fail, so that a failing run can be repeated.
+	Seed uint64
+	// Fail, if set, is used instead of FailRate to decide if an operation fails. op is the name of the
+	// store method, such as "Put".
+	Fail func(op string) bool
+	// Err is returned by failed operations. Defaults to ErrInjected.
+	Err error
+	// ApplyFailedWrites applies operations that change the store before failing them, like a backend
+	// that commits a write but times out before replying.
+	ApplyFailedWrites bool
+
+	lock sync.Mutex
+	rand *rand.Rand
+}
+
+// inject applies the latency and returns the error for op, if it fails.
+func (f *Faults) inject(op string) error {
+	if f.Latency > 0 {
+		time.Sleep(f.Latency)
+	}
+	if !f.fails(op) {
+		return nil
+	}
+	if f.Err != nil {
+		return f.Err
+	}
+	return ErrInjected
+}
+
+func (f *Faults) fails(op string) bool {
+	if f.Fail != nil {
+		return f.Fail(op)
+	}
+	if f.FailRate <= 0 {
+		return false
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.rand == nil {
+		f.rand = rand.New(rand.NewPCG(f.Seed, 0))
+	}
+	return f.rand.Float64() < f.FailRate
+}
+
+// write runs apply for a write operation op, or fails it.
+func (f *Faults) write(op string, apply func() error) error {
+	err := f.inject(op)
+	if err == nil {
+		return apply()
+	}
+	if f.ApplyFailedWrites {
+		apply()
+	}
+	return err
+}
+
+// FaultyKeyStore returns a store.KeyStore that injects faults into s.
+func FaultyKeyStore(s store.KeyStore, f *Faults) store.KeyStore {
+	return &faultyKeyStore{s: s, f: f}
+}
+
+type faultyKeyStore struct {
+	s store.KeyStore
+	f *Faults
+}
+
+func (s *faultyKeyStore) Get(name string) (*otp.Key, error) {
+	if err := s.f.inject("Get"); err != nil {
+		return nil, err
+	}
+	return s.s.Get(name)
+}
+
+func (s *faultyKeyStore) Put(name string, k *otp.Key) error {
+	return s.f.write("Put", func() error {
+		return s.s.Put(name, k)
+	})
+}
+
+func (s *faultyKeyStore) Delete(name string) error {
+	return s.f.write("Delete", func() error {
+		return s.s.Delete(name)
+	})
+}
+
+func (s *faultyKeyStore) List() ([]string, error) {
+	if err := s.f.inject("List"); err != nil {
+		return nil, err
+	}
+	return s.s.List()
+}
+
+// FaultyHandoffStore returns a handoff.Store that injects faults into s. Take is a write, as it
+// removes the token.
+func FaultyHandoffStore(s handoff.Store, f *Faults) handoff.Store {
+	return &faultyHandoffStore{s: s, f: f}
+}
+
+type faultyHandoffStore struct {
+	s handoff.Store
+	f *Faults
+}
+
+func (s *faultyHandoffStore) Put(token string, url string, expires time.Time) error {
+	return s.f.write("Put", func() error {
+		return s.s.Put(token, url, expires)
+	})
+}
+
+func (s *faultyHandoffStore) Take(token string) (url string, ok bool, err error) {
+	err = s.f.write("Take", func() error {
+		var err error
+		url, ok, err = s.s.Take(token)
+		return err
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return url, ok, nil
+}
+
+// FaultyBypassStore returns a bypass.Store that injects faults into s.
+func FaultyBypassStore(s bypass.Store, f *Faults) bypass.Store {
+	return &faultyBypassStore{s: s, f: f}
+}
+
+type faultyBypassStore struct {
+	s bypass.Store
+	f *Faults
+}
+
+func (s *faultyBypassStore) Use(id string, t time.Time, expires time.Time) (ok bool, err error) {
+	err = s.f.write("Use", func() error {
+		var err error
+		ok, err = s.s.Use(id, t, expires)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return ok, nil
+}
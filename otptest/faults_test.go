@@ -0,0 +1,136 @@
+package otptest
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/bypass"
+	"github.com/ecnepsnai/otp/handoff"
+	"github.com/ecnepsnai/otp/store"
+)
+
+type mapStore map[string]*otp.Key
+
+func (m mapStore) Get(name string) (*otp.Key, error) {
+	k, ok := m[name]
+	if !ok {
+		return nil, store.ErrKeyNotFound
+	}
+	return k, nil
+}
+
+func (m mapStore) Put(name string, k *otp.Key) error {
+	m[name] = k
+	return nil
+}
+
+func (m mapStore) Delete(name string) error {
+	if _, ok := m[name]; !ok {
+		return store.ErrKeyNotFound
+	}
+	delete(m, name)
+	return nil
+}
+
+func (m mapStore) List() ([]string, error) {
+	names := []string{}
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func TestFaultyKeyStore(t *testing.T) {
+	k, err := otp.NewKeyFromURL("otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	backend := mapStore{}
+	f := &Faults{Fail: func(op string) bool { return op == "Put" }}
+	s := FaultyKeyStore(backend, f)
+
+	if err := s.Put("alice", k); ErrInjected != err {
+		t.Fatalf("Expected injected error")
+	}
+	if _, err := s.Get("alice"); store.ErrKeyNotFound != err {
+		t.Fatalf("Failed write should not be applied")
+	}
+
+	f.ApplyFailedWrites = true
+	if err := s.Put("alice", k); ErrInjected != err {
+		t.Fatalf("Expected injected error")
+	}
+	if _, err := s.Get("alice"); err != nil {
+		t.Fatalf("Failed write should be applied: %s", err.Error())
+	}
+}
+
+func TestFaultsFailRate(t *testing.T) {
+	count := func() int {
+		f := &Faults{FailRate: 0.25, Seed: 42}
+		s := FaultyKeyStore(mapStore{}, f)
+		failed := 0
+		for i := 0; i < 1000; i++ {
+			if _, err := s.List(); err != nil {
+				failed++
+			}
+		}
+		return failed
+	}
+
+	failed := count()
+	if failed < 200 || failed > 300 {
+		t.Fatalf("Unexpected number of failures %d", failed)
+	}
+	if failed != count() {
+		t.Fatalf("Failures should be repeatable with the same seed")
+	}
+}
+
+func TestFaultyHandoffStore(t *testing.T) {
+	k, _ := otp.NewKeyFromURL("otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example")
+	f := &Faults{Fail: func(op string) bool { return op == "Take" }, ApplyFailedWrites: true}
+	h := &handoff.Handoff{Store: FaultyHandoffStore(handoff.NewMemoryStore(), f)}
+
+	token, err := h.Create(k)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if _, err := h.Redeem(token); ErrInjected != err {
+		t.Fatalf("Expected injected error")
+	}
+
+	// The token was taken even though the caller saw an error.
+	f.Fail = nil
+	if _, err := h.Redeem(token); handoff.ErrTokenNotFound != err {
+		t.Fatalf("Expected token not found error")
+	}
+}
+
+func TestFaultyBypassStore(t *testing.T) {
+	failed := bypass.ErrCodeInvalid
+	f := &Faults{FailRate: 1, Err: failed, Latency: time.Millisecond}
+	b := &bypass.Bypass{Key: []byte("01234567890123456789012345678901"), Store: FaultyBypassStore(bypass.NewMemoryStore(), f)}
+
+	now := time.Now()
+	code, _, err := b.Issue("alice", now)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	start := time.Now()
+	if err := b.Verify("alice", code, now); failed != err {
+		t.Fatalf("Expected the configured error")
+	}
+	if time.Since(start) < time.Millisecond {
+		t.Fatalf("Expected latency to be added")
+	}
+
+	f.FailRate = 0
+	if err := b.Verify("alice", code, now); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+}
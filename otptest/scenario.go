@@ -0,0 +1,100 @@
+// Package otptest provides helpers for testing applications that validate one-time passcodes, such as
+// scripting a sequence of logins against a validator on a virtual clock.
+package otptest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp/totp"
+)
+
+// Validator validates passcode at t. It wraps the validator under test together with any state it
+// keeps between calls, such as a replay cache, a throttler or a stored HOTP counter.
+type Validator func(passcode string, t time.Time) (bool, error)
+
+// CodeFunc returns the passcode a device shows at t.
+type CodeFunc func(t time.Time) (string, error)
+
+// TOTP returns a CodeFunc for a TOTP device with secret and opts.
+func TOTP(secret string, opts totp.ValidateOpts) CodeFunc {
+	return func(t time.Time) (string, error) {
+		return totp.GenerateCodeCustom(secret, t, opts)
+	}
+}
+
+// Step is a single passcode submitted in a Scenario.
+type Step struct {
+	// At is the time of the step from the start of the scenario.
+	At time.Duration
+	// Code is the submitted passcode. If empty, the passcode shown by the device is used.
+	Code string
+	// DeviceOffset is how far the clock of the device is ahead (or behind, if negative) of the
+	// validator when the passcode is generated. Ignored if Code is set.
+	DeviceOffset time.Duration
+	// Valid is the expected result.
+	Valid bool
+	// Err is the expected error, matched with errors.Is so that wrapped errors match. Defaults to no
+	// error.
+	Err error
+}
+
+// Scenario scripts a sequence of steps against a stateful validator. Steps run in order with a
+// virtual clock, so behaviors that depend on earlier attempts, such as replay protection, lockout and
+// drift resynchronization, can be tested without waiting.
+type Scenario struct {
+	// Start is the time of the first step. Defaults to 1,000,000,000 seconds after the Unix epoch.
+	Start time.Time
+	// Device generates the passcodes of steps that don't set a Code.
+	Device CodeFunc
+	// Clock, if set, is moved to the time of each step before it runs, for validators that read the
+	// time from it.
+	Clock *Clock
+	// Steps are the steps, in order. The time of a step may not be before the step that precedes it.
+	Steps []Step
+}
+
+// Run runs every step of the scenario against v, reporting each step that does not have the expected
+// result as an error on t.
+func (s Scenario) Run(t testing.TB, v Validator) {
+	t.Helper()
+
+	start := s.Start
+	if start.IsZero() {
+		start = time.Unix(1000000000, 0).UTC()
+	}
+
+	var last time.Duration
+	for i, step := range s.Steps {
+		if step.At < last {
+			t.Fatalf("step %d: time %s is before the previous step", i, step.At)
+		}
+		last = step.At
+
+		now := start.Add(step.At)
+		if s.Clock != nil {
+			s.Clock.Set(now)
+		}
+
+		code := step.Code
+		if code == "" {
+			if s.Device == nil {
+				t.Fatalf("step %d: no code and no device", i)
+			}
+			var err error
+			code, err = s.Device(now.Add(step.DeviceOffset))
+			if err != nil {
+				t.Fatalf("step %d: generating code: %s", i, err.Error())
+			}
+		}
+
+		valid, err := v(code, now)
+		if !errors.Is(err, step.Err) {
+			t.Errorf("step %d at %s: expected error %v but got %v", i, step.At, step.Err, err)
+		}
+		if step.Valid != valid {
+			t.Errorf("step %d at %s: expected valid %t for passcode %q", i, step.At, step.Valid, code)
+		}
+	}
+}
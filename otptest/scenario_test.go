@@ -0,0 +1,116 @@
+package otptest
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/hotp"
+	"github.com/ecnepsnai/otp/kiosk"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestScenarioKiosk(t *testing.T) {
+	start := time.Unix(1111111080, 0).UTC()
+	k, err := totp.Generate(totp.GenerateOpts{Issuer: "SnakeOil", AccountName: "1234", Secret: []byte("12345678901234567890")})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	buf := &bytes.Buffer{}
+	kioskKey := []byte("kiosk key")
+	if err := kiosk.Export(buf, kioskKey, []kiosk.Account{{Name: "1234", Key: k}}, start, time.Hour); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	f, err := kiosk.Read(buf, kioskKey)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	Scenario{
+		Start:  start,
+		Device: TOTP(k.Secret(), totp.ValidateOpts{}),
+		Steps: []Step{
+			{At: time.Minute, Valid: true},
+			// Replaying the same passcode is rejected.
+			{At: time.Minute + 10*time.Second, Valid: false},
+			// A device clock running 30 seconds fast is within the skew.
+			{At: 2 * time.Minute, DeviceOffset: 30 * time.Second, Valid: true},
+			// Once a later passcode is used, earlier ones are rejected.
+			{At: 2*time.Minute + 30*time.Second, DeviceOffset: -30 * time.Second, Valid: false},
+			{At: 3 * time.Minute, DeviceOffset: 2 * time.Minute, Valid: false},
+			{At: 2 * time.Hour, Err: kiosk.ErrFileExpired},
+		},
+	}.Run(t, func(passcode string, t time.Time) (bool, error) {
+		return f.Validate("1234", passcode, t)
+	})
+}
+
+func TestScenarioHOTPResync(t *testing.T) {
+	opts := hotp.WindowOpts{ValidateOpts: hotp.ValidateOpts{Digits: otp.DigitsSix}, Window: 10}
+	code := func(counter uint64) string {
+		c, _ := hotp.GenerateCodeCustom(secret, counter, opts.ValidateOpts)
+		return c
+	}
+
+	var stored uint64
+	Scenario{
+		Steps: []Step{
+			{Code: code(0), Valid: true},
+			// The user pressed the button a few times without logging in.
+			{At: time.Second, Code: code(5), Valid: true},
+			{At: 2 * time.Second, Code: code(3), Valid: false},
+			{At: 3 * time.Second, Code: code(40), Valid: false},
+			{At: 4 * time.Second, Code: "12345", Err: otp.ErrValidateInputInvalidLength},
+		},
+	}.Run(t, func(passcode string, t time.Time) (bool, error) {
+		counter, valid, err := hotp.ValidateWindow(passcode, stored, secret, opts)
+		if valid {
+			stored = counter + 1
+		}
+		return valid, err
+	})
+}
+
+func TestScenarioClock(t *testing.T) {
+	clock := NewClock(time.Time{})
+	start := time.Unix(1111111109, 0).UTC()
+
+	Scenario{
+		Start:  start,
+		Clock:  clock,
+		Device: TOTP(secret, totp.ValidateOpts{}),
+		Steps: []Step{
+			{Valid: true},
+			{At: 5 * time.Minute, DeviceOffset: -5 * time.Minute, Valid: false},
+		},
+	}.Run(t, func(passcode string, _ time.Time) (bool, error) {
+		return totp.ValidateWith(passcode, secret, clock.Now())
+	})
+
+	if !start.Add(5 * time.Minute).Equal(clock.Now()) {
+		t.Fatalf("Clock was not moved to the last step")
+	}
+	clock.Advance(time.Second)
+	if !start.Add(5*time.Minute + time.Second).Equal(clock.Now()) {
+		t.Fatalf("Clock was not advanced")
+	}
+}
+
+func TestScenarioWrappedError(t *testing.T) {
+	Scenario{
+		Steps: []Step{
+			{Code: "1", Err: otp.ErrValidateInputInvalidLength},
+			{Code: "123456", Valid: true},
+		},
+	}.Run(t, func(passcode string, t time.Time) (bool, error) {
+		if len(passcode) != 6 {
+			return false, fmt.Errorf("store: %w", otp.ErrValidateInputInvalidLength)
+		}
+		return true, nil
+	})
+}
@@ -0,0 +1,12 @@
+package otp
+
+// SplitPasswordCode splits a "password+code" submission, as sent by RADIUS clients and some VPNs that
+// only have a single password field, into the password and the trailing passcode of the given length.
+// ok is false if the submission is too short to hold a passcode.
+func SplitPasswordCode(submission string, digits Digits) (password string, passcode string, ok bool) {
+	n := digits.Length()
+	if n <= 0 || len(submission) < n {
+		return "", "", false
+	}
+	return submission[:len(submission)-n], submission[len(submission)-n:], true
+}
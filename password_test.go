@@ -0,0 +1,19 @@
+package otp
+
+import "testing"
+
+func TestSplitPasswordCode(t *testing.T) {
+	password, passcode, ok := SplitPasswordCode("hunter2123456", DigitsSix)
+	if !ok || "hunter2" != password || "123456" != passcode {
+		t.Fatalf("Unexpected split '%s' '%s'", password, passcode)
+	}
+
+	password, passcode, ok = SplitPasswordCode("123456", DigitsSix)
+	if !ok || "" != password || "123456" != passcode {
+		t.Fatalf("Unexpected split '%s' '%s'", password, passcode)
+	}
+
+	if _, _, ok := SplitPasswordCode("12345", DigitsSix); ok {
+		t.Fatalf("Expected a submission shorter than the passcode to be rejected")
+	}
+}
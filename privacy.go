@@ -0,0 +1,19 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"strings"
+)
+
+// AccountPseudonym returns a stable identifier for accountName that can be shown in a provisioning
+// URL in its place, so that a QR code scanned in public or a leaked screenshot does not reveal the
+// account name. The pseudonym is keyed with the raw secret so it can't be reversed by guessing
+// account names, and can be recomputed by the application to map it back to the account.
+func AccountPseudonym(secret []byte, accountName string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(accountName))
+	id := base32.StdEncoding.EncodeToString(mac.Sum(nil)[:5])
+	return "user-" + strings.ToLower(id)
+}
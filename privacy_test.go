@@ -0,0 +1,24 @@
+package otp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAccountPseudonym(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	p := AccountPseudonym(secret, "alice@example.com")
+	if !strings.HasPrefix(p, "user-") || strings.Contains(p, "alice") {
+		t.Fatalf("Unexpected pseudonym '%s'", p)
+	}
+	if p != AccountPseudonym(secret, "alice@example.com") {
+		t.Fatalf("Expected pseudonym to be stable")
+	}
+	if p == AccountPseudonym(secret, "bob@example.com") {
+		t.Fatalf("Expected different accounts to have different pseudonyms")
+	}
+	if p == AccountPseudonym([]byte("other secret"), "alice@example.com") {
+		t.Fatalf("Expected different secrets to give different pseudonyms")
+	}
+}
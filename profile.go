@@ -0,0 +1,132 @@
+package otp
+
+import (
+	"sort"
+	"sync"
+)
+
+// Profile bundles the passcode settings used by a family of tokens, so that keys can be generated and
+// validated by choosing a profile instead of setting each option separately. Apply a profile with
+// hotp.WithProfile or totp.WithProfile.
+type Profile struct {
+	// Name of the profile.
+	Name string
+	// Version of the profile. Register a new version instead of changing the settings of a profile
+	// that keys were already generated with, so that those keys keep the settings they were enrolled
+	// with. Defaults to 0.
+	Version uint
+	// Digits in a passcode.
+	Digits Digits
+	// Number of seconds a TOTP passcode is valid for. Not used for HOTP.
+	Period uint
+	// Algorithm to use for HMAC.
+	Algorithm Algorithm
+	// Encoder used to render passcodes.
+	Encoder Encoder
+}
+
+// ProfileRFCDefault returns the default of RFC 4226 and RFC 6238, used by Google Authenticator and most
+// services: 6 digits every 30 seconds with SHA1.
+func ProfileRFCDefault() Profile {
+	return Profile{Name: "rfc-default", Digits: DigitsSix, Period: 30, Algorithm: AlgorithmSHA1}
+}
+
+// Profile60sEightDigit returns the profile of hardware tokens that show 8 digits for 60 seconds.
+func Profile60sEightDigit() Profile {
+	return Profile{Name: "60s-eight-digit", Digits: DigitsEight, Period: 60, Algorithm: AlgorithmSHA1}
+}
+
+// ProfileSteam returns the profile of Steam Guard, which shows 5 alphanumeric characters every 30
+// seconds.
+func ProfileSteam() Profile {
+	return Profile{Name: "steam", Digits: DigitsSteam, Period: 30, Algorithm: AlgorithmSHA1, Encoder: EncoderSteam}
+}
+
+// ProfileAuthy10s7d returns the profile of Authy's own tokens, which show 7 digits every 10 seconds.
+func ProfileAuthy10s7d() Profile {
+	return Profile{Name: "authy-10s-7d", Digits: DigitsSeven, Period: 10, Algorithm: AlgorithmSHA1}
+}
+
+// ProfileRegistry holds named profiles, so that an application can define its own standard token
+// settings once and refer to them by name in configuration and in the "profile" parameter of
+// provisioning URLs. Each name may have several versions. A new registry holds the preset profiles.
+// It is safe for concurrent use.
+type ProfileRegistry struct {
+	lock     sync.RWMutex
+	profiles map[string]map[uint]Profile
+}
+
+// NewProfileRegistry returns a registry holding the preset profiles.
+func NewProfileRegistry() *ProfileRegistry {
+	r := &ProfileRegistry{profiles: map[string]map[uint]Profile{}}
+	for _, p := range []Profile{ProfileRFCDefault(), Profile60sEightDigit(), ProfileSteam(), ProfileAuthy10s7d()} {
+		r.profiles[p.Name] = map[uint]Profile{p.Version: p}
+	}
+	return r
+}
+
+// Register adds a profile. It returns ErrProfileInvalid if the profile has no name or digits, and
+// ErrProfileExists if the same version of a profile with the same name is already registered.
+func (r *ProfileRegistry) Register(p Profile) error {
+	if p.Name == "" || p.Digits <= 0 {
+		return ErrProfileInvalid
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	versions, ok := r.profiles[p.Name]
+	if !ok {
+		versions = map[uint]Profile{}
+		r.profiles[p.Name] = versions
+	}
+	if _, ok := versions[p.Version]; ok {
+		return ErrProfileExists
+	}
+	versions[p.Version] = p
+	return nil
+}
+
+// Lookup returns the latest version of the profile registered with name, for generating new keys.
+func (r *ProfileRegistry) Lookup(name string) (Profile, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	latest, ok := Profile{}, false
+	for _, p := range r.profiles[name] {
+		if !ok || p.Version > latest.Version {
+			latest, ok = p, true
+		}
+	}
+	return latest, ok
+}
+
+// LookupVersion returns the given version of the profile registered with name.
+func (r *ProfileRegistry) LookupVersion(name string, version uint) (Profile, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	p, ok := r.profiles[name][version]
+	return p, ok
+}
+
+// ForKey returns the version of the profile that k was generated with, see Key.Profile and
+// Key.ProfileVersion. A later version of the profile is never returned, so validating with it keeps
+// computing passcodes the way they were at enrollment. hotp.ValidateKey and totp.ValidateKey use it
+// when given the registry with WithProfileRegistry.
+func (r *ProfileRegistry) ForKey(k *Key) (Profile, bool) {
+	name := k.Profile()
+	if name == "" {
+		return Profile{}, false
+	}
+	return r.LookupVersion(name, k.ProfileVersion())
+}
+
+// Names returns the names of every registered profile, sorted.
+func (r *ProfileRegistry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	names := make([]string, 0, len(r.profiles))
+	for name := range r.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,83 @@
+package otp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProfileRegistry(t *testing.T) {
+	r := NewProfileRegistry()
+	if p, ok := r.Lookup("steam"); !ok || ProfileSteam() != p {
+		t.Fatalf("Expected preset profiles to be registered")
+	}
+
+	corp := Profile{Name: "corp-standard", Digits: DigitsEight, Period: 30, Algorithm: AlgorithmSHA256}
+	if err := r.Register(corp); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := r.Register(corp); ErrProfileExists != err {
+		t.Fatalf("Expected profile exists error")
+	}
+	if err := r.Register(Profile{Name: "empty"}); ErrProfileInvalid != err {
+		t.Fatalf("Expected invalid profile error")
+	}
+	if 5 != len(r.Names()) || "60s-eight-digit" != r.Names()[0] {
+		t.Fatalf("Unexpected names %v", r.Names())
+	}
+
+	k, _ := NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&profile=corp-standard")
+	if p, ok := r.ForKey(k); !ok || corp != p {
+		t.Fatalf("Expected profile of key")
+	}
+	k, _ = NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP")
+	if _, ok := r.ForKey(k); ok {
+		t.Fatalf("Expected no profile for key without one")
+	}
+
+	// Registries are independent of each other.
+	if _, ok := NewProfileRegistry().Lookup("corp-standard"); ok {
+		t.Fatalf("Expected a new registry to only hold presets")
+	}
+}
+
+func TestProfileVersions(t *testing.T) {
+	r := NewProfileRegistry()
+	v0 := Profile{Name: "corp-standard", Digits: DigitsSix, Period: 30, Algorithm: AlgorithmSHA1}
+	v1 := Profile{Name: "corp-standard", Version: 1, Digits: DigitsEight, Period: 60, Algorithm: AlgorithmSHA256}
+	if err := r.Register(v0); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := r.Register(v1); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := r.Register(v1); ErrProfileExists != err {
+		t.Fatalf("Expected profile exists error")
+	}
+
+	if p, ok := r.Lookup("corp-standard"); !ok || v1 != p {
+		t.Fatalf("Expected the latest version")
+	}
+	if p, ok := r.LookupVersion("corp-standard", 0); !ok || v0 != p {
+		t.Fatalf("Expected version 0")
+	}
+	if 5 != len(r.Names()) {
+		t.Fatalf("Unexpected names %v", r.Names())
+	}
+
+	// Keys enrolled before version 1 was registered keep version 0.
+	k, _ := NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&profile=corp-standard")
+	if p, ok := r.ForKey(k); !ok || v0 != p {
+		t.Fatalf("Expected version 0 for key without a version")
+	}
+	k, _ = NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&profile=corp-standard&profile_version=1")
+	if p, ok := r.ForKey(k); !ok || v1 != p || 1 != k.ProfileVersion() {
+		t.Fatalf("Expected version 1 for key")
+	}
+	k, _ = NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&profile=corp-standard&profile_version=2")
+	if _, ok := r.ForKey(k); ok {
+		t.Fatalf("Expected no profile for an unknown version")
+	}
+	if !strings.Contains(k.Describe().String(), `profile_version="2"`) {
+		t.Fatalf("Expected the profile version in '%s'", k.Describe().String())
+	}
+}
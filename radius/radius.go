@@ -0,0 +1,226 @@
+// Package radius provides a minimal RADIUS (RFC 2865) Access-Request handler backed by OTP validation,
+// so network equipment can authenticate users with a passcode without a separate RADIUS OTP product.
+//
+// Only PAP authentication is supported: the passcode, or a password and passcode combination, is sent
+// as the User-Password attribute. Accounting and CHAP requests are ignored.
+package radius
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/binary"
+	"net"
+	"unicode/utf8"
+)
+
+const (
+	codeAccessRequest = 1
+	codeAccessAccept  = 2
+	codeAccessReject  = 3
+
+	attributeUserName             = 1
+	attributeUserPassword         = 2
+	attributeReplyMessage         = 18
+	attributeMessageAuthenticator = 80
+
+	headerLength      = 20
+	maxLength         = 4096
+	maxAttributeValue = 253
+)
+
+// ValidateFunc returns true if passcode is valid for the user. When users send their password and
+// passcode together, totp.ValidatePasswordAndCode can check both.
+type ValidateFunc func(username string, passcode string) bool
+
+// Handler answers RADIUS Access-Requests.
+type Handler struct {
+	// Secret shared between this server and its RADIUS clients.
+	Secret []byte
+	// Validate is called for every well-formed Access-Request.
+	Validate ValidateFunc
+	// AllowMissingMessageAuthenticator accepts requests without a Message-Authenticator attribute
+	// (RFC 3579), for clients that can't send one. Requests that include one are always verified.
+	// Defaults to false, dropping such requests, because without the attribute responses can be
+	// forged (Blast-RADIUS, CVE-2024-3596).
+	AllowMissingMessageAuthenticator bool
+	// RejectMessage is sent with every Access-Reject, if not empty. Messages longer than a single
+	// attribute can hold are split across several Reply-Message attributes, and cut short if the
+	// packet would grow past its maximum length.
+	RejectMessage string
+}
+
+// Serve reads requests from conn and writes responses until reading from conn fails.
+func (h *Handler) Serve(conn net.PacketConn) error {
+	buf := make([]byte, maxLength)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+
+		response := h.Handle(buf[:n])
+		if response == nil {
+			continue
+		}
+		conn.WriteTo(response, addr)
+	}
+}
+
+// Handle processes a single RADIUS packet and returns the response to send, or nil if the packet
+// should be silently discarded as RFC 2865 requires for malformed or unauthenticated packets.
+func (h *Handler) Handle(packet []byte) []byte {
+	if len(packet) < headerLength || packet[0] != codeAccessRequest {
+		return nil
+	}
+	length := int(binary.BigEndian.Uint16(packet[2:4]))
+	if length < headerLength || length > len(packet) || length > maxLength {
+		return nil
+	}
+	packet = packet[:length]
+
+	attributes, ok := parseAttributes(packet[headerLength:])
+	if !ok {
+		return nil
+	}
+
+	authenticator := packet[4:20]
+	if ma, present := attributes[attributeMessageAuthenticator]; present {
+		if !h.verifyMessageAuthenticator(packet, ma) {
+			return nil
+		}
+	} else if !h.AllowMissingMessageAuthenticator {
+		return nil
+	}
+
+	username, hasUser := attributes[attributeUserName]
+	encrypted, hasPassword := attributes[attributeUserPassword]
+	if !hasUser || !hasPassword || len(encrypted) < 16 || len(encrypted)%16 != 0 {
+		return nil
+	}
+	password := decryptPassword(encrypted, h.Secret, authenticator)
+
+	code := byte(codeAccessReject)
+	if h.Validate(string(username), password) {
+		code = codeAccessAccept
+	}
+
+	return h.response(code, packet[1], authenticator)
+}
+
+// response builds a signed response packet for a request.
+func (h *Handler) response(code byte, id byte, requestAuthenticator []byte) []byte {
+	attributes := []byte{}
+	if code == codeAccessReject {
+		// Room is left for the Message-Authenticator.
+		attributes = appendReplyMessage(attributes, h.RejectMessage, maxLength-headerLength-2-md5.Size)
+	}
+	maOffset := headerLength + len(attributes) + 2
+	attributes = appendAttribute(attributes, attributeMessageAuthenticator, make([]byte, md5.Size))
+
+	packet := make([]byte, headerLength, headerLength+len(attributes))
+	packet[0] = code
+	packet[1] = id
+	binary.BigEndian.PutUint16(packet[2:4], uint16(headerLength+len(attributes)))
+	copy(packet[4:20], requestAuthenticator)
+	packet = append(packet, attributes...)
+
+	// The Message-Authenticator is computed with the request authenticator in place, and then the
+	// response authenticator covers the Message-Authenticator.
+	mac := hmac.New(md5.New, h.Secret)
+	mac.Write(packet)
+	copy(packet[maOffset:], mac.Sum(nil))
+
+	sum := md5.New()
+	sum.Write(packet)
+	sum.Write(h.Secret)
+	copy(packet[4:20], sum.Sum(nil))
+
+	return packet
+}
+
+func (h *Handler) verifyMessageAuthenticator(packet []byte, ma []byte) bool {
+	if len(ma) != md5.Size {
+		return false
+	}
+
+	// The HMAC is computed over the packet with the Message-Authenticator value set to zero.
+	zeroed := bytes.Clone(packet)
+	offset := headerLength
+	for offset+2 <= len(zeroed) {
+		t, l := zeroed[offset], int(zeroed[offset+1])
+		if t == attributeMessageAuthenticator {
+			clear(zeroed[offset+2 : offset+l])
+		}
+		offset += l
+	}
+
+	mac := hmac.New(md5.New, h.Secret)
+	mac.Write(zeroed)
+	return hmac.Equal(mac.Sum(nil), ma)
+}
+
+// parseAttributes returns the first value of each attribute type.
+func parseAttributes(data []byte) (map[byte][]byte, bool) {
+	attributes := map[byte][]byte{}
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, false
+		}
+		t, l := data[0], int(data[1])
+		if l < 2 || l > len(data) {
+			return nil, false
+		}
+		if _, seen := attributes[t]; !seen {
+			attributes[t] = data[2:l]
+		}
+		data = data[l:]
+	}
+	return attributes, true
+}
+
+func appendAttribute(attributes []byte, t byte, value []byte) []byte {
+	attributes = append(attributes, t, byte(len(value)+2))
+	return append(attributes, value...)
+}
+
+// appendReplyMessage appends message as Reply-Message attributes of at most maxAttributeValue bytes
+// each, split between characters where it can be, and stops before the attributes grow past limit bytes.
+func appendReplyMessage(attributes []byte, message string, limit int) []byte {
+	for message != "" {
+		n := len(message)
+		if n > maxAttributeValue {
+			n = maxAttributeValue
+			for n > 0 && !utf8.RuneStart(message[n]) {
+				n--
+			}
+			// Invalid UTF-8 may have no character start to split at, so it is split anywhere.
+			if n == 0 {
+				n = maxAttributeValue
+			}
+		}
+		if len(attributes)+2+n > limit {
+			return attributes
+		}
+		attributes = appendAttribute(attributes, attributeReplyMessage, []byte(message[:n]))
+		message = message[n:]
+	}
+	return attributes
+}
+
+// decryptPassword reverses the User-Password hiding described in RFC 2865 section 5.2.
+func decryptPassword(encrypted []byte, secret []byte, authenticator []byte) string {
+	password := make([]byte, len(encrypted))
+	previous := authenticator
+	for i := 0; i < len(encrypted); i += 16 {
+		h := md5.New()
+		h.Write(secret)
+		h.Write(previous)
+		b := h.Sum(nil)
+		for j := 0; j < 16; j++ {
+			password[i+j] = encrypted[i+j] ^ b[j]
+		}
+		previous = encrypted[i : i+16]
+	}
+	return string(bytes.TrimRight(password, "\x00"))
+}
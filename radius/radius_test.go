@@ -0,0 +1,206 @@
+package radius
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var testSecret = []byte("testing123")
+
+// encryptPassword hides a password as a RADIUS client would.
+func encryptPassword(password string, authenticator []byte) []byte {
+	p := []byte(password)
+	if n := len(p) % 16; n != 0 || len(p) == 0 {
+		p = append(p, make([]byte, 16-n)...)
+	}
+	out := make([]byte, len(p))
+	previous := authenticator
+	for i := 0; i < len(p); i += 16 {
+		h := md5.New()
+		h.Write(testSecret)
+		h.Write(previous)
+		b := h.Sum(nil)
+		for j := 0; j < 16; j++ {
+			out[i+j] = p[i+j] ^ b[j]
+		}
+		previous = out[i : i+16]
+	}
+	return out
+}
+
+func accessRequest(username string, password string, withMA bool) []byte {
+	authenticator := bytes.Repeat([]byte{0x42}, 16)
+	attributes := appendAttribute(nil, attributeUserName, []byte(username))
+	attributes = appendAttribute(attributes, attributeUserPassword, encryptPassword(password, authenticator))
+	maOffset := headerLength + len(attributes) + 2
+	if withMA {
+		attributes = appendAttribute(attributes, attributeMessageAuthenticator, make([]byte, 16))
+	}
+
+	packet := []byte{codeAccessRequest, 7, 0, 0}
+	binary.BigEndian.PutUint16(packet[2:4], uint16(headerLength+len(attributes)))
+	packet = append(packet, authenticator...)
+	packet = append(packet, attributes...)
+
+	if withMA {
+		mac := hmac.New(md5.New, testSecret)
+		mac.Write(packet)
+		copy(packet[maOffset:], mac.Sum(nil))
+	}
+	return packet
+}
+
+func testHandler() *Handler {
+	return &Handler{
+		Secret: testSecret,
+		Validate: func(username string, passcode string) bool {
+			return username == "alice" && passcode == "123456"
+		},
+		RejectMessage: "Invalid passcode",
+	}
+}
+
+func checkResponse(t *testing.T, request []byte, response []byte, code byte) {
+	if response == nil {
+		t.Fatalf("Expected a response.")
+	}
+	if code != response[0] || request[1] != response[1] {
+		t.Fatalf("Unexpected response code %d for id %d", response[0], response[1])
+	}
+
+	signed := bytes.Clone(response)
+	copy(signed[4:20], request[4:20])
+	h := md5.New()
+	h.Write(signed)
+	h.Write(testSecret)
+	if !bytes.Equal(h.Sum(nil), response[4:20]) {
+		t.Fatalf("Invalid response authenticator")
+	}
+}
+
+func TestHandleAccept(t *testing.T) {
+	h := testHandler()
+
+	request := accessRequest("alice", "123456", true)
+	checkResponse(t, request, h.Handle(request), codeAccessAccept)
+
+	h.AllowMissingMessageAuthenticator = true
+	request = accessRequest("alice", "123456", false)
+	checkResponse(t, request, h.Handle(request), codeAccessAccept)
+}
+
+func TestHandleReject(t *testing.T) {
+	h := testHandler()
+
+	request := accessRequest("alice", "654321", true)
+	response := h.Handle(request)
+	checkResponse(t, request, response, codeAccessReject)
+
+	attributes, ok := parseAttributes(response[headerLength:])
+	if !ok || "Invalid passcode" != string(attributes[attributeReplyMessage]) {
+		t.Fatalf("Expected a reply message")
+	}
+}
+
+func TestHandleDiscard(t *testing.T) {
+	h := testHandler()
+
+	if h.Handle(accessRequest("alice", "123456", false)) != nil {
+		t.Fatalf("Requests without a Message-Authenticator should be discarded")
+	}
+
+	request := accessRequest("alice", "123456", true)
+	request[len(request)-1] ^= 0xFF
+	if h.Handle(request) != nil {
+		t.Fatalf("Requests with an invalid Message-Authenticator should be discarded")
+	}
+
+	if h.Handle([]byte{codeAccessRequest, 1, 0}) != nil {
+		t.Fatalf("Short packets should be discarded")
+	}
+
+	request = accessRequest("alice", "123456", true)
+	request[0] = 4
+	if h.Handle(request) != nil {
+		t.Fatalf("Packets other than Access-Request should be discarded")
+	}
+}
+
+func TestServe(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Unable to listen: %s", err.Error())
+	}
+	defer server.Close()
+	go testHandler().Serve(server)
+
+	client, err := net.Dial("udp", server.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	defer client.Close()
+
+	request := accessRequest("alice", "123456", true)
+	client.Write(request)
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, maxLength)
+	n, err := client.Read(buf)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	checkResponse(t, request, buf[:n], codeAccessAccept)
+}
+
+func TestHandleRejectLongMessage(t *testing.T) {
+	h := testHandler()
+	request := accessRequest("alice", "654321", true)
+
+	// Each "é" is two bytes, so an attribute boundary falls in the middle of one.
+	h.RejectMessage = "a" + strings.Repeat("é", 300)
+	response := h.Handle(request)
+	checkResponse(t, request, response, codeAccessReject)
+
+	message := ""
+	for data := response[headerLength:]; len(data) > 0; data = data[data[1]:] {
+		if attributeReplyMessage == data[0] {
+			if !utf8.Valid(data[2:data[1]]) {
+				t.Fatalf("Reply message split within a character")
+			}
+			message += string(data[2:data[1]])
+		}
+	}
+	if h.RejectMessage != message {
+		t.Fatalf("Unexpected reply message '%s'", message)
+	}
+
+	// Invalid UTF-8 with no character boundary is still split.
+	h.RejectMessage = strings.Repeat("\x80", 600)
+	response = h.Handle(request)
+	checkResponse(t, request, response, codeAccessReject)
+	message = ""
+	for data := response[headerLength:]; len(data) > 0; data = data[data[1]:] {
+		if attributeReplyMessage == data[0] {
+			message += string(data[2:data[1]])
+		}
+	}
+	if h.RejectMessage != message {
+		t.Fatalf("Unexpected reply message of %d bytes", len(message))
+	}
+
+	h.RejectMessage = strings.Repeat("a", 10000)
+	response = h.Handle(request)
+	checkResponse(t, request, response, codeAccessReject)
+	if len(response) > maxLength || int(binary.BigEndian.Uint16(response[2:4])) != len(response) {
+		t.Fatalf("Unexpected response length %d", len(response))
+	}
+	if _, ok := parseAttributes(response[headerLength:]); !ok {
+		t.Fatalf("Invalid attributes")
+	}
+}
@@ -0,0 +1,34 @@
+package otp
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// Revision returns a content hash of every setting in this Key, for stores that need optimistic
+// concurrency when updating keys. Two keys have the same revision if they have the same type, label
+// and parameters, regardless of the order of the parameters in the URL.
+//
+// A store reads a key and remembers its revision, then when saving changes checks that the stored key
+// still has that revision using CheckRevision. If it does not, another update happened in between.
+func (k *Key) Revision() string {
+	h := sha256.New()
+	h.Write([]byte(k.url.Scheme))
+	h.Write([]byte{0})
+	h.Write([]byte(k.url.Host))
+	h.Write([]byte{0})
+	h.Write([]byte(k.url.Path))
+	h.Write([]byte{0})
+	h.Write([]byte(k.url.Query().Encode()))
+
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// CheckRevision returns ErrKeyRevisionMismatch if the revision of this Key is not revision.
+func (k *Key) CheckRevision(revision string) error {
+	if subtle.ConstantTimeCompare([]byte(k.Revision()), []byte(revision)) != 1 {
+		return ErrKeyRevisionMismatch
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package otp
+
+import (
+	"testing"
+)
+
+func TestKeyRevision(t *testing.T) {
+	k, _ := NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil&digits=6")
+	reordered, _ := NewKeyFromURL("otpauth://totp/SnakeOil:alice?digits=6&issuer=SnakeOil&secret=JBSWY3DPEHPK3PXP")
+	upgraded, _ := NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil&digits=8")
+	renamed, _ := NewKeyFromURL("otpauth://totp/SnakeOilCo:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOilCo&digits=6")
+
+	if k.Revision() != reordered.Revision() {
+		t.Fatalf("Expected the order of parameters not to change the revision")
+	}
+	if k.Revision() == upgraded.Revision() || k.Revision() == renamed.Revision() {
+		t.Fatalf("Expected a changed key to have a new revision")
+	}
+
+	if err := reordered.CheckRevision(k.Revision()); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := upgraded.CheckRevision(k.Revision()); ErrKeyRevisionMismatch != err {
+		t.Fatalf("Expected revision mismatch error")
+	}
+}
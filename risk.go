@@ -0,0 +1,84 @@
+package otp
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ecnepsnai/otp/internal"
+)
+
+const (
+	// RiskImported marks a key whose secret was imported from another application or a backup, so
+	// copies of the secret may exist outside of this system.
+	RiskImported = "imported"
+	// RiskExported marks a key whose secret was exported at least once.
+	RiskExported = "exported"
+)
+
+// RiskFlags returns the provenance flags recorded in the "risk" parameter of the key, such as
+// RiskImported, or nil if there are none.
+func (k *Key) RiskFlags() []string {
+	q := k.url.Query()
+
+	risk := q.Get("risk")
+	if risk == "" {
+		return nil
+	}
+	return strings.Split(risk, ",")
+}
+
+// HasRiskFlag returns true if flag is one of the risk flags of the key.
+func (k *Key) HasRiskFlag(flag string) bool {
+	for _, f := range k.RiskFlags() {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddRiskFlags returns a copy of k with flags added to its risk flags. Flags are kept sorted and
+// each is only recorded once. Flags must not contain commas.
+func AddRiskFlags(k *Key, flags ...string) (*Key, error) {
+	set := map[string]bool{}
+	for _, f := range append(k.RiskFlags(), flags...) {
+		if f != "" {
+			set[f] = true
+		}
+	}
+	all := make([]string, 0, len(set))
+	for f := range set {
+		all = append(all, f)
+	}
+	sort.Strings(all)
+
+	u := *k.url
+	q := u.Query()
+	q.Set("risk", strings.Join(all, ","))
+	if len(all) == 0 {
+		q.Del("risk")
+	}
+	u.RawQuery = internal.EncodeQuery(q)
+
+	return NewKeyFromURL(u.String())
+}
+
+// RiskPolicy decides whether a key with risk flags may be validated without extra verification. It
+// returns nil to accept the key, or an error such as ErrValidateRiskFlagged that is returned by the
+// validator instead of checking the passcode. It is only called for keys with at least one flag.
+type RiskPolicy func(flags []string) error
+
+// DenyRiskFlags returns a RiskPolicy that rejects keys with any of denied with
+// ErrValidateRiskFlagged, so that the application can ask for extra verification.
+func DenyRiskFlags(denied ...string) RiskPolicy {
+	return func(flags []string) error {
+		for _, f := range flags {
+			for _, d := range denied {
+				if f == d {
+					return ErrValidateRiskFlagged
+				}
+			}
+		}
+		return nil
+	}
+}
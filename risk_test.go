@@ -0,0 +1,41 @@
+package otp
+
+import (
+	"testing"
+)
+
+func TestAddRiskFlags(t *testing.T) {
+	k, _ := NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil")
+	if nil != k.RiskFlags() || k.HasRiskFlag(RiskImported) {
+		t.Fatalf("Expected no risk flags")
+	}
+
+	flagged, err := AddRiskFlags(k, RiskImported)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	flagged, err = AddRiskFlags(flagged, RiskImported, RiskExported)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	flags := flagged.RiskFlags()
+	if 2 != len(flags) || RiskExported != flags[0] || RiskImported != flags[1] {
+		t.Fatalf("Unexpected risk flags %v", flags)
+	}
+	if !flagged.HasRiskFlag(RiskImported) || k.HasRiskFlag(RiskImported) {
+		t.Fatalf("Only the copy should be flagged")
+	}
+	if k.Secret() != flagged.Secret() || k.Issuer() != flagged.Issuer() {
+		t.Fatalf("Other settings should be kept in '%s'", flagged.String())
+	}
+}
+
+func TestDenyRiskFlags(t *testing.T) {
+	policy := DenyRiskFlags(RiskExported)
+	if err := policy([]string{RiskImported}); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := policy([]string{RiskImported, RiskExported}); ErrValidateRiskFlagged != err {
+		t.Fatalf("Expected risk flagged error")
+	}
+}
@@ -0,0 +1,74 @@
+package otp
+
+import (
+	"crypto/rand"
+	"io"
+
+	"github.com/ecnepsnai/otp/internal"
+)
+
+// SplitSecret splits a secret into two halves that can be delivered over separate channels, for
+// example a QR code and a mailed letter. Each half on its own reveals nothing about the secret, which
+// is the XOR of both halves. r is the source of randomness and defaults to crypto/rand.
+//
+// secret must be base32 encoded, and both halves are returned base32 encoded without padding.
+func SplitSecret(secret string, r io.Reader) (string, string, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	b, err := internal.DecodeSecret(secret)
+	if err != nil {
+		return "", "", ErrValidateSecretInvalidBase32
+	}
+
+	first := make([]byte, len(b))
+	if _, err := io.ReadFull(r, first); err != nil {
+		return "", "", err
+	}
+	second := xorSecret(b, first)
+
+	return internal.EncodeSecret(first), internal.EncodeSecret(second), nil
+}
+
+// CombineSecrets joins the two halves produced by SplitSecret back into the original secret.
+func CombineSecrets(first string, second string) (string, error) {
+	a, err := internal.DecodeSecret(first)
+	if err != nil {
+		return "", ErrValidateSecretInvalidBase32
+	}
+	b, err := internal.DecodeSecret(second)
+	if err != nil {
+		return "", ErrValidateSecretInvalidBase32
+	}
+	if len(a) != len(b) {
+		return "", ErrSplitSecretMismatch
+	}
+
+	return internal.EncodeSecret(xorSecret(a, b)), nil
+}
+
+// CombineKey returns a copy of k, whose secret is the first half produced by SplitSecret, with its
+// secret replaced by the combination of both halves. The combined key is what should be stored when
+// the enrollment is activated.
+func CombineKey(k *Key, second string) (*Key, error) {
+	secret, err := CombineSecrets(k.Secret(), second)
+	if err != nil {
+		return nil, err
+	}
+
+	u := *k.url
+	q := u.Query()
+	q.Set("secret", secret)
+	u.RawQuery = internal.EncodeQuery(q)
+
+	return NewKeyFromURL(u.String())
+}
+
+func xorSecret(a []byte, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
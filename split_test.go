@@ -0,0 +1,52 @@
+package otp
+
+import (
+	"testing"
+)
+
+func TestSplitSecret(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	first, second, err := SplitSecret(secret, nil)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if first == secret || second == secret || first == second {
+		t.Fatalf("Halves should not reveal the secret")
+	}
+
+	combined, err := CombineSecrets(first, second)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if secret != combined {
+		t.Fatalf("Unexpected combined secret '%s'", combined)
+	}
+
+	if _, err := CombineSecrets(first, "GEZDGNBV"); ErrSplitSecretMismatch != err {
+		t.Fatalf("Expected mismatch error")
+	}
+	if _, _, err := SplitSecret("not base32!", nil); ErrValidateSecretInvalidBase32 != err {
+		t.Fatalf("Expected invalid base32 error")
+	}
+}
+
+func TestCombineKey(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	first, second, _ := SplitSecret(secret, nil)
+
+	k, _ := NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=" + first + "&issuer=SnakeOil&digits=8")
+	combined, err := CombineKey(k, second)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if secret != combined.Secret() {
+		t.Fatalf("Unexpected combined secret '%s'", combined.Secret())
+	}
+	if "SnakeOil" != combined.Issuer() || "alice" != combined.AccountName() || DigitsEight != combined.Digits() {
+		t.Fatalf("Expected the other settings of the key to be kept")
+	}
+	if first != k.Secret() {
+		t.Fatalf("Original key should not be modified")
+	}
+}
@@ -0,0 +1,128 @@
+// Package stats collects aggregate statistics about OTP validations, such as acceptance rates,
+// failure reasons, clock drift and which algorithms and digit counts are still in use. It records
+// nothing about individual users or keys.
+package stats
+
+import (
+	"sync"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// FailureInvalid is the failure reason recorded for a passcode that was checked and was wrong.
+const FailureInvalid = "OTP_INVALID"
+
+// Attempt describes a single validation.
+type Attempt struct {
+	// Algorithm of the key.
+	Algorithm otp.Algorithm
+	// Digits of the key.
+	Digits otp.Digits
+	// Accepted is true if the passcode was valid.
+	Accepted bool
+	// Err returned by the validation, if any.
+	Err error
+	// Drift in time steps of an accepted TOTP passcode, see totp.ValidateDrift.
+	Drift int64
+}
+
+// Snapshot is a copy of the statistics at a point in time.
+type Snapshot struct {
+	// Attempts is the number of validations recorded.
+	Attempts uint64
+	// Accepted is the number of validations that accepted the passcode.
+	Accepted uint64
+	// Failures counts rejected validations by reason: otp.ErrorCode of the error, or FailureInvalid.
+	Failures map[string]uint64
+	// Usage counts validations by key type, such as "SHA1/6".
+	Usage map[string]uint64
+	// AverageDrift is the mean drift, in time steps, of accepted passcodes.
+	AverageDrift float64
+	// AbsoluteDrift is the mean of the absolute drift, in time steps, of accepted passcodes.
+	AbsoluteDrift float64
+}
+
+// AcceptanceRate returns the fraction of validations that accepted the passcode, from 0 to 1.
+func (s Snapshot) AcceptanceRate() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return float64(s.Accepted) / float64(s.Attempts)
+}
+
+// Collector aggregates attempts. The zero value is ready to use, and it is safe for concurrent use.
+type Collector struct {
+	lock          sync.Mutex
+	attempts      uint64
+	accepted      uint64
+	failures      map[string]uint64
+	usage         map[string]uint64
+	driftTotal    int64
+	absDriftTotal int64
+}
+
+// Record adds an attempt to the statistics.
+func (c *Collector) Record(a Attempt) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.failures == nil {
+		c.failures = map[string]uint64{}
+		c.usage = map[string]uint64{}
+	}
+
+	c.attempts++
+	c.usage[a.Algorithm.String()+"/"+a.Digits.String()]++
+
+	switch {
+	case a.Err != nil:
+		c.failures[otp.ErrorCode(a.Err)]++
+	case !a.Accepted:
+		c.failures[FailureInvalid]++
+	default:
+		c.accepted++
+		c.driftTotal += a.Drift
+		if a.Drift < 0 {
+			c.absDriftTotal -= a.Drift
+		} else {
+			c.absDriftTotal += a.Drift
+		}
+	}
+}
+
+// Snapshot returns a copy of the statistics.
+func (c *Collector) Snapshot() Snapshot {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	s := Snapshot{
+		Attempts: c.attempts,
+		Accepted: c.accepted,
+		Failures: make(map[string]uint64, len(c.failures)),
+		Usage:    make(map[string]uint64, len(c.usage)),
+	}
+	for reason, n := range c.failures {
+		s.Failures[reason] = n
+	}
+	for kind, n := range c.usage {
+		s.Usage[kind] = n
+	}
+	if c.accepted > 0 {
+		s.AverageDrift = float64(c.driftTotal) / float64(c.accepted)
+		s.AbsoluteDrift = float64(c.absDriftTotal) / float64(c.accepted)
+	}
+	return s
+}
+
+// Reset clears the statistics, for collectors that report per interval.
+func (c *Collector) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.attempts = 0
+	c.accepted = 0
+	c.failures = nil
+	c.usage = nil
+	c.driftTotal = 0
+	c.absDriftTotal = 0
+}
@@ -0,0 +1,49 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+const secSha1 = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCollector(t *testing.T) {
+	c := &Collector{}
+	now := time.Unix(1111111109, 0).UTC()
+	opts := totp.ValidateOpts{Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1, Skew: 1}
+
+	for _, offset := range []time.Duration{0, -30 * time.Second, 30 * time.Second} {
+		code, _ := totp.GenerateCodeCustom(secSha1, now.Add(offset), opts)
+		drift, valid, err := totp.ValidateDrift(code, secSha1, now, opts)
+		c.Record(Attempt{Algorithm: opts.Algorithm, Digits: opts.Digits, Accepted: valid, Err: err, Drift: drift})
+	}
+	valid, err := totp.ValidateCustom("000000", secSha1, now, opts)
+	c.Record(Attempt{Algorithm: opts.Algorithm, Digits: opts.Digits, Accepted: valid, Err: err})
+	valid, err = totp.ValidateCustom("0", secSha1, now, opts)
+	c.Record(Attempt{Algorithm: otp.AlgorithmSHA256, Digits: otp.DigitsEight, Accepted: valid, Err: err})
+
+	s := c.Snapshot()
+	if 5 != s.Attempts || 3 != s.Accepted {
+		t.Fatalf("Unexpected counts %+v", s)
+	}
+	if 0.6 != s.AcceptanceRate() {
+		t.Fatalf("Unexpected acceptance rate %v", s.AcceptanceRate())
+	}
+	if 1 != s.Failures[FailureInvalid] || 1 != s.Failures["OTP_INVALID_LENGTH"] {
+		t.Fatalf("Unexpected failures %v", s.Failures)
+	}
+	if 4 != s.Usage["SHA1/6"] || 1 != s.Usage["SHA256/8"] {
+		t.Fatalf("Unexpected usage %v", s.Usage)
+	}
+	if 0 != s.AverageDrift || float64(2)/3 != s.AbsoluteDrift {
+		t.Fatalf("Unexpected drift %v %v", s.AverageDrift, s.AbsoluteDrift)
+	}
+
+	c.Reset()
+	if 0 != c.Snapshot().Attempts {
+		t.Fatalf("Expected reset statistics")
+	}
+}
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// ConflictPolicy decides what Import does when a key is already stored with the same name.
+type ConflictPolicy int
+
+const (
+	// ConflictSkip keeps the stored key and does not import the new one.
+	ConflictSkip ConflictPolicy = iota
+	// ConflictOverwrite replaces the stored key with the new one.
+	ConflictOverwrite
+	// ConflictKeepBoth stores the new key under the name with a numbered suffix, such as "name (2)".
+	ConflictKeepBoth
+)
+
+// ImportAction is what Import did with a key.
+type ImportAction int
+
+const (
+	// ImportAdded means the key was stored under its own name.
+	ImportAdded ImportAction = iota
+	// ImportUnchanged means an identical key was already stored under the name.
+	ImportUnchanged
+	// ImportSkipped means a different key was already stored and was kept.
+	ImportSkipped
+	// ImportOverwritten means a different key was already stored and was replaced.
+	ImportOverwritten
+	// ImportRenamed means the key was stored under a new name.
+	ImportRenamed
+	// ImportFailed means the entry could not be decoded, validated or stored, see ImportReport.
+	ImportFailed
+)
+
+func (a ImportAction) String() string {
+	switch a {
+	case ImportAdded:
+		return "added"
+	case ImportUnchanged:
+		return "unchanged"
+	case ImportSkipped:
+		return "skipped"
+	case ImportOverwritten:
+		return "overwritten"
+	case ImportRenamed:
+		return "renamed"
+	case ImportFailed:
+		return "failed"
+	}
+	panic("unreached")
+}
+
+// Entry is a key to import and the name to store it under.
+type Entry struct {
+	Name string
+	Key  *otp.Key
+}
+
+// ImportResult describes what happened to one entry.
+type ImportResult struct {
+	// Name the entry asked for.
+	Name string
+	// StoredAs is the name the key was stored under, or "" if it was not stored.
+	StoredAs string
+	// Action taken.
+	Action ImportAction
+}
+
+// Import stores entries in s in order, applying policy when a name is already taken. Stored keys are
+// marked with otp.RiskImported. A key that is identical to the stored one is never imported again,
+// whatever the policy. Entries earlier in the batch count as stored, so the same name twice in one
+// batch is also a conflict. Import stops at the first error from s and returns the results so far.
+func Import(s KeyStore, entries []Entry, policy ConflictPolicy) ([]ImportResult, error) {
+	results := make([]ImportResult, 0, len(entries))
+	for _, e := range entries {
+		result, err := importEntry(s, e, policy)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func importEntry(s KeyStore, e Entry, policy ConflictPolicy) (ImportResult, error) {
+	result := ImportResult{Name: e.Name}
+
+	// Imported keys are marked so that validators can apply a risk policy to them. A key identical to
+	// the stored one apart from the mark is still unchanged.
+	k, err := otp.AddRiskFlags(e.Key, otp.RiskImported)
+	if err != nil {
+		return result, err
+	}
+	e.Key = k
+	unchanged := func(existing *otp.Key) bool {
+		marked, err := otp.AddRiskFlags(existing, otp.RiskImported)
+		return err == nil && marked.Revision() == e.Key.Revision()
+	}
+
+	existing, err := s.Get(e.Name)
+	if err == ErrKeyNotFound {
+		result.StoredAs = e.Name
+		result.Action = ImportAdded
+		return result, s.Put(e.Name, e.Key)
+	}
+	if err != nil {
+		return result, err
+	}
+	if unchanged(existing) {
+		result.StoredAs = e.Name
+		result.Action = ImportUnchanged
+		return result, nil
+	}
+
+	switch policy {
+	case ConflictOverwrite:
+		result.StoredAs = e.Name
+		result.Action = ImportOverwritten
+		return result, s.Put(e.Name, e.Key)
+	case ConflictKeepBoth:
+		for n := 2; ; n++ {
+			name := fmt.Sprintf("%s (%d)", e.Name, n)
+			existing, err := s.Get(name)
+			if err == ErrKeyNotFound {
+				result.StoredAs = name
+				result.Action = ImportRenamed
+				return result, s.Put(name, e.Key)
+			}
+			if err != nil {
+				return result, err
+			}
+			if unchanged(existing) {
+				result.StoredAs = name
+				result.Action = ImportUnchanged
+				return result, nil
+			}
+		}
+	default:
+		result.Action = ImportSkipped
+		return result, nil
+	}
+}
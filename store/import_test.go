@@ -0,0 +1,92 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func testEntries() []Entry {
+	a, _ := otp.NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP")
+	b, _ := otp.NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=GEZDGNBVGY3TQOJQ")
+	return []Entry{
+		{Name: "snakeoil", Key: a},
+		{Name: "snakeoil", Key: a},
+		{Name: "snakeoil", Key: b},
+	}
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		policy   ConflictPolicy
+		actions  []ImportAction
+		storedAs string
+		secret   string
+	}{
+		{ConflictSkip, []ImportAction{ImportAdded, ImportUnchanged, ImportSkipped}, "", "JBSWY3DPEHPK3PXP"},
+		{ConflictOverwrite, []ImportAction{ImportAdded, ImportUnchanged, ImportOverwritten}, "snakeoil", "GEZDGNBVGY3TQOJQ"},
+		{ConflictKeepBoth, []ImportAction{ImportAdded, ImportUnchanged, ImportRenamed}, "snakeoil (2)", "JBSWY3DPEHPK3PXP"},
+	}
+
+	for _, test := range tests {
+		s, _ := testStore(t, "hunter2")
+		results, err := Import(s, testEntries(), test.policy)
+		if err != nil {
+			t.Fatalf("Error: %s", err.Error())
+		}
+		for i, result := range results {
+			if test.actions[i] != result.Action {
+				t.Fatalf("Unexpected action %s for entry %d with policy %d", result.Action, i, test.policy)
+			}
+		}
+		if test.storedAs != results[2].StoredAs {
+			t.Fatalf("Unexpected name '%s' with policy %d", results[2].StoredAs, test.policy)
+		}
+		k, _ := s.Get("snakeoil")
+		if test.secret != k.Secret() {
+			t.Fatalf("Unexpected stored secret '%s' with policy %d", k.Secret(), test.policy)
+		}
+	}
+}
+
+func TestImportKeepBothAgain(t *testing.T) {
+	s, _ := testStore(t, "hunter2")
+	Import(s, testEntries(), ConflictKeepBoth)
+
+	// Importing the same batch again changes nothing.
+	results, err := Import(s, testEntries(), ConflictKeepBoth)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if ImportUnchanged != results[2].Action || "snakeoil (2)" != results[2].StoredAs {
+		t.Fatalf("Unexpected result %+v", results[2])
+	}
+	names, _ := s.List()
+	if 2 != len(names) {
+		t.Fatalf("Unexpected names %v", names)
+	}
+}
+
+func TestImportRiskFlag(t *testing.T) {
+	s, _ := testStore(t, "hunter2")
+	k, _ := otp.NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP")
+	s.Put("enrolled", k)
+
+	results, err := Import(s, []Entry{{Name: "imported", Key: k}, {Name: "enrolled", Key: k}}, ConflictOverwrite)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	stored, _ := s.Get("imported")
+	if !stored.HasRiskFlag(otp.RiskImported) {
+		t.Fatalf("Expected imported key to be flagged '%s'", stored.String())
+	}
+
+	// A key that was already stored is unchanged and keeps its provenance.
+	if ImportUnchanged != results[1].Action {
+		t.Fatalf("Unexpected action %s", results[1].Action)
+	}
+	stored, _ = s.Get("enrolled")
+	if stored.HasRiskFlag(otp.RiskImported) {
+		t.Fatalf("Unchanged key should not be flagged")
+	}
+}
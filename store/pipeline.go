@@ -0,0 +1,226 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// RawEntry is an otpauth URL to import and the name to store it under.
+type RawEntry struct {
+	Name string
+	URL  string
+}
+
+// PipelineOpts configures ImportPipeline.
+type PipelineOpts struct {
+	// Number of entries decoded and validated at the same time. Defaults to GOMAXPROCS.
+	Workers int
+	// What to do when a name is already taken. Defaults to ConflictSkip.
+	Policy ConflictPolicy
+	// Validate is called for every decoded key, and the entry is rejected if it returns an error.
+	// It may be called concurrently. Defaults to checking that the key is a TOTP or HOTP key with a
+	// secret that can be decoded.
+	Validate func(k *otp.Key) error
+	// Progress is called after each group of entries is stored. Defaults to no reporting.
+	Progress func(p Progress)
+	// Checkpoint from the last Progress of an interrupted import of the same entries, to continue
+	// from where it stopped instead of starting again. Defaults to the start.
+	Checkpoint string
+}
+
+// Progress describes how far ImportPipeline has got.
+type Progress struct {
+	// Number of entries that have been imported or have failed, including any before the
+	// checkpoint the import was resumed from.
+	Done int
+	// Total number of entries.
+	Total int
+	// Checkpoint can be saved and passed in PipelineOpts to resume the import. It never moves past an
+	// entry that could not be stored, so that resuming retries it, and is empty if the import must
+	// start again from the beginning. Entries that were rejected by decoding or Validate would be
+	// rejected again, so the checkpoint moves past them.
+	Checkpoint string
+}
+
+// ErrInvalidKeyType is returned by the default PipelineOpts.Validate for keys that are neither TOTP
+// nor HOTP keys.
+var ErrInvalidKeyType = otp.NewCodedError("OTP_STORE_INVALID_KEY_TYPE", "Key is not a TOTP or HOTP key")
+
+// ErrCheckpointMismatch is returned when resuming from a checkpoint that was not made for the
+// entries being imported.
+var ErrCheckpointMismatch = otp.NewCodedError("OTP_STORE_CHECKPOINT_MISMATCH", "Checkpoint does not match the entries")
+
+// EntryError describes an entry that could not be imported.
+type EntryError struct {
+	// Index of the entry in the batch.
+	Index int
+	// Name the entry asked for.
+	Name string
+	// Err is the reason the entry was rejected.
+	Err error
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("entry %d (%s): %s", e.Index, e.Name, e.Err.Error())
+}
+
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// ImportReport is the outcome of ImportPipeline.
+type ImportReport struct {
+	// Resumed is the number of entries that were skipped because they were imported before the
+	// checkpoint.
+	Resumed int
+	// Results has one result for each entry, in order. Entries in Errors have the ImportFailed action,
+	// and the first Resumed results are empty.
+	Results []ImportResult
+	// Errors has every entry that could not be imported, in order.
+	Errors []*EntryError
+}
+
+// Err returns all of the errors in the report joined together, or nil if every entry was imported.
+func (r ImportReport) Err() error {
+	errs := make([]error, len(r.Errors))
+	for i, err := range r.Errors {
+		errs[i] = err
+	}
+	return errors.Join(errs...)
+}
+
+// ImportPipeline imports a large batch of entries into s, for provisioning many tokens at once. Entries
+// are decoded and validated by a bounded pool of workers, then stored in order following the same
+// rules as Import. Unlike Import, a failed entry doesn't stop the import: every failure is reported in
+// the ImportReport. err is only set if the checkpoint is invalid.
+func ImportPipeline(s KeyStore, entries []RawEntry, opts PipelineOpts) (ImportReport, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	validate := opts.Validate
+	if validate == nil {
+		validate = validateKey
+	}
+
+	resumed := 0
+	if opts.Checkpoint != "" {
+		var err error
+		if resumed, err = parseCheckpoint(opts.Checkpoint, entries); err != nil {
+			return ImportReport{}, err
+		}
+	}
+
+	report := ImportReport{Resumed: resumed, Results: make([]ImportResult, len(entries))}
+	// Entries from the first one that failed to be stored have to be retried when resuming.
+	unstored := -1
+	fail := func(i int, err error) {
+		report.Results[i] = ImportResult{Name: entries[i].Name, Action: ImportFailed}
+		report.Errors = append(report.Errors, &EntryError{Index: i, Name: entries[i].Name, Err: err})
+	}
+
+	// Entries are handled in chunks so that only one chunk of decoded keys is held at a time, and so
+	// that keys are stored in the same order as entries.
+	chunk := workers * 64
+	keys := make([]*otp.Key, chunk)
+	errs := make([]error, chunk)
+	for start := resumed; start < len(entries); start += chunk {
+		end := min(start+chunk, len(entries))
+
+		next := make(chan int)
+		wg := sync.WaitGroup{}
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					keys[i-start], errs[i-start] = decodeEntry(entries[i], validate)
+				}
+			}()
+		}
+		for i := start; i < end; i++ {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+
+		for i := start; i < end; i++ {
+			if err := errs[i-start]; err != nil {
+				fail(i, err)
+				continue
+			}
+			result, err := importEntry(s, Entry{Name: entries[i].Name, Key: keys[i-start]}, opts.Policy)
+			if err != nil {
+				if unstored < 0 {
+					unstored = i
+				}
+				fail(i, err)
+				continue
+			}
+			report.Results[i] = result
+		}
+
+		if opts.Progress != nil {
+			safe := end
+			if unstored >= 0 {
+				safe = unstored
+			}
+			opts.Progress(Progress{Done: end, Total: len(entries), Checkpoint: checkpoint(entries, safe)})
+		}
+	}
+
+	return report, nil
+}
+
+// checkpoint returns a token for resuming after the first done entries. It holds the number of
+// entries along with a hash of the last one, so that it can't be used with a different batch by
+// mistake. Resuming after no entries is the same as starting again, so that returns "".
+func checkpoint(entries []RawEntry, done int) string {
+	if done == 0 {
+		return ""
+	}
+	return strconv.Itoa(done) + "-" + entryHash(entries[done-1])
+}
+
+func parseCheckpoint(token string, entries []RawEntry) (int, error) {
+	count, hash, ok := strings.Cut(token, "-")
+	done, err := strconv.Atoi(count)
+	if !ok || err != nil || done < 1 || done > len(entries) || entryHash(entries[done-1]) != hash {
+		return 0, ErrCheckpointMismatch
+	}
+	return done, nil
+}
+
+func entryHash(e RawEntry) string {
+	sum := sha256.Sum256([]byte(e.Name + "\x00" + e.URL))
+	return hex.EncodeToString(sum[:8])
+}
+
+func decodeEntry(e RawEntry, validate func(k *otp.Key) error) (*otp.Key, error) {
+	k, err := otp.NewKeyFromURL(e.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func validateKey(k *otp.Key) error {
+	if k.Type() != "totp" && k.Type() != "hotp" {
+		return ErrInvalidKeyType
+	}
+	if _, err := k.SecretAlphabet().DecodeSecret(k.Secret()); err != nil || k.Secret() == "" {
+		return otp.ErrValidateSecretInvalidBase32
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// mapStore is a KeyStore kept in memory.
+type mapStore struct {
+	lock sync.Mutex
+	keys map[string]*otp.Key
+}
+
+func newMapStore() *mapStore {
+	return &mapStore{keys: map[string]*otp.Key{}}
+}
+
+func (s *mapStore) Get(name string) (*otp.Key, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	k, ok := s.keys[name]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return k, nil
+}
+
+func (s *mapStore) Put(name string, k *otp.Key) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.keys[name] = k
+	return nil
+}
+
+func (s *mapStore) Delete(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.keys[name]; !ok {
+		return ErrKeyNotFound
+	}
+	delete(s.keys, name)
+	return nil
+}
+
+func (s *mapStore) List() ([]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	names := []string{}
+	for name := range s.keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func TestImportPipeline(t *testing.T) {
+	entries := []RawEntry{}
+	for i := 0; i < 5000; i++ {
+		entries = append(entries, RawEntry{
+			Name: fmt.Sprintf("user%d", i),
+			URL:  fmt.Sprintf("otpauth://totp/SnakeOil:user%d?secret=JBSWY3DPEHPK3PXP", i),
+		})
+	}
+	entries[10].URL = "otpauth://totp/SnakeOil:bad?secret=not-base32!"
+	entries[20].URL = "otpauth://steam/SnakeOil:bad?secret=JBSWY3DPEHPK3PXP"
+	entries[30].Name = "user0"
+
+	s := newMapStore()
+	report, err := ImportPipeline(s, entries, PipelineOpts{Workers: 4})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 5000 != len(report.Results) || 2 != len(report.Errors) {
+		t.Fatalf("Unexpected %d results and %d errors", len(report.Results), len(report.Errors))
+	}
+	if 10 != report.Errors[0].Index || otp.ErrValidateSecretInvalidBase32 != report.Errors[0].Err {
+		t.Fatalf("Unexpected error %s", report.Errors[0])
+	}
+	if 20 != report.Errors[1].Index || !errors.Is(report.Err(), ErrInvalidKeyType) {
+		t.Fatalf("Unexpected error %s", report.Errors[1])
+	}
+	if ImportFailed != report.Results[10].Action || ImportSkipped != report.Results[30].Action || ImportAdded != report.Results[4999].Action {
+		t.Fatalf("Unexpected results")
+	}
+
+	names, _ := s.List()
+	if 4997 != len(names) {
+		t.Fatalf("Unexpected %d stored keys", len(names))
+	}
+
+	// Importing again changes nothing, and a custom validator is applied.
+	report, _ = ImportPipeline(s, entries[:100], PipelineOpts{Validate: func(k *otp.Key) error {
+		if k.AccountName() == "user50" {
+			return otp.ErrGenerateMissingIssuer
+		}
+		return nil
+	}})
+	if ImportUnchanged != report.Results[0].Action || ImportFailed != report.Results[50].Action {
+		t.Fatalf("Unexpected results")
+	}
+}
+
+func TestImportPipelineResume(t *testing.T) {
+	entries := []RawEntry{}
+	for i := 0; i < 1000; i++ {
+		entries = append(entries, RawEntry{
+			Name: fmt.Sprintf("user%d", i),
+			URL:  fmt.Sprintf("otpauth://totp/SnakeOil:user%d?secret=JBSWY3DPEHPK3PXP", i),
+		})
+	}
+
+	// Interrupt the import after the first progress report.
+	s := newMapStore()
+	stop := errors.New("interrupted")
+	var saved Progress
+	func() {
+		defer func() { recover() }()
+		ImportPipeline(s, entries, PipelineOpts{Workers: 2, Progress: func(p Progress) {
+			saved = p
+			panic(stop)
+		}})
+	}()
+	if 128 != saved.Done || 1000 != saved.Total {
+		t.Fatalf("Unexpected progress %+v", saved)
+	}
+
+	reports := 0
+	report, err := ImportPipeline(s, entries, PipelineOpts{Workers: 2, Checkpoint: saved.Checkpoint, Progress: func(p Progress) {
+		reports++
+	}})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 128 != report.Resumed || ImportAdded != report.Results[128].Action || 7 != reports {
+		t.Fatalf("Unexpected report after resuming")
+	}
+	names, _ := s.List()
+	if 1000 != len(names) {
+		t.Fatalf("Unexpected %d stored keys", len(names))
+	}
+
+	for _, checkpoint := range []string{"garbage", "5000-00", saved.Checkpoint[:4] + "0"} {
+		if _, err := ImportPipeline(s, entries, PipelineOpts{Checkpoint: checkpoint}); ErrCheckpointMismatch != err {
+			t.Fatalf("Expected checkpoint mismatch error for '%s'.", checkpoint)
+		}
+	}
+	if _, err := ImportPipeline(s, entries[1:], PipelineOpts{Checkpoint: saved.Checkpoint}); ErrCheckpointMismatch != err {
+		t.Fatalf("Expected checkpoint mismatch error for other entries.")
+	}
+}
+
+// flakyStore fails to store the key named broken.
+type flakyStore struct {
+	*mapStore
+	broken string
+}
+
+func (s *flakyStore) Put(name string, k *otp.Key) error {
+	if name == s.broken {
+		return errors.New("store unavailable")
+	}
+	return s.mapStore.Put(name, k)
+}
+
+func TestImportPipelineCheckpointStoreFailure(t *testing.T) {
+	entries := []RawEntry{}
+	for i := 0; i < 1000; i++ {
+		entries = append(entries, RawEntry{
+			Name: fmt.Sprintf("user%d", i),
+			URL:  fmt.Sprintf("otpauth://totp/SnakeOil:user%d?secret=JBSWY3DPEHPK3PXP", i),
+		})
+	}
+	entries[50].URL = "otpauth://totp/SnakeOil:bad?secret=not-base32!"
+
+	s := &flakyStore{mapStore: newMapStore(), broken: "user200"}
+	var last Progress
+	report, _ := ImportPipeline(s, entries, PipelineOpts{Workers: 2, Progress: func(p Progress) {
+		last = p
+	}})
+	if 2 != len(report.Errors) || 1000 != last.Done {
+		t.Fatalf("Unexpected %d errors and progress %+v", len(report.Errors), last)
+	}
+
+	// The invalid entry is not retried, but the one that failed to be stored is.
+	s.broken = ""
+	report, err := ImportPipeline(s, entries, PipelineOpts{Checkpoint: last.Checkpoint})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 200 != report.Resumed || ImportAdded != report.Results[200].Action || ImportUnchanged != report.Results[201].Action {
+		t.Fatalf("Unexpected report after resuming")
+	}
+
+	// A failure before the first checkpoint means starting again.
+	s = &flakyStore{mapStore: newMapStore(), broken: "user0"}
+	ImportPipeline(s, entries, PipelineOpts{Progress: func(p Progress) {
+		last = p
+	}})
+	if "" != last.Checkpoint {
+		t.Fatalf("Unexpected checkpoint '%s'", last.Checkpoint)
+	}
+}
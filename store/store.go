@@ -0,0 +1,270 @@
+// Package store keeps OTP keys in a single passphrase-encrypted file, for command line tools and
+// daemons that must not store secrets in plaintext configuration.
+//
+// The file is JSON holding the key derivation parameters and the AES-256-GCM encrypted set of keys.
+// The encryption key is derived from the passphrase with PBKDF2-HMAC-SHA256. Every change rewrites
+// the whole file atomically, and on unix systems changes are serialized with an advisory lock so
+// several processes can share one store.
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/internal"
+)
+
+// ErrKeyNotFound is returned when no key is stored with the requested name.
+var ErrKeyNotFound = otp.NewCodedError("OTP_STORE_KEY_NOT_FOUND", "Key not found")
+
+// ErrWrongPassphrase is returned when the store cannot be decrypted with the passphrase.
+var ErrWrongPassphrase = otp.NewCodedError("OTP_STORE_WRONG_PASSPHRASE", "Passphrase is incorrect or store is corrupt")
+
+// ErrInvalidStore is returned when the file is not a key store.
+var ErrInvalidStore = otp.NewCodedError("OTP_STORE_INVALID", "File is not a key store")
+
+// KeyStore is a collection of named keys.
+type KeyStore interface {
+	// Get returns the key stored with name, or ErrKeyNotFound.
+	Get(name string) (*otp.Key, error)
+	// Put stores k with name, replacing any key already stored with that name.
+	Put(name string, k *otp.Key) error
+	// Delete removes the key stored with name, or returns ErrKeyNotFound.
+	Delete(name string) error
+	// List returns the names of every stored key, sorted.
+	List() ([]string, error)
+}
+
+// DefaultIterations is the number of PBKDF2 iterations used for new stores.
+const DefaultIterations = 600000
+
+// MaxIterations is the largest number of PBKDF2 iterations a store may use. Files that ask for more
+// are rejected with ErrInvalidStore, so that a tampered file can't make opening it take forever.
+const MaxIterations = 10000000
+
+// FileStore is a KeyStore kept in a single encrypted file. It is safe for concurrent use.
+type FileStore struct {
+	// Number of PBKDF2 iterations used when creating the file, at most MaxIterations. Defaults to
+	// DefaultIterations. Existing files keep the number they were created with.
+	Iterations int
+
+	path       string
+	passphrase []byte
+	lock       sync.Mutex
+	salt       []byte
+	key        []byte
+}
+
+type fileFormat struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+const kdfPBKDF2 = "pbkdf2-sha256"
+
+// NewFileStore returns a store kept at path, encrypted with passphrase. The file is created when the
+// first key is stored.
+func NewFileStore(path string, passphrase []byte) *FileStore {
+	return &FileStore{
+		path:       path,
+		passphrase: passphrase,
+	}
+}
+
+// Get returns the key stored with name.
+func (s *FileStore) Get(name string) (*otp.Key, error) {
+	var k *otp.Key
+	err := s.view(func(keys map[string]string) error {
+		u, ok := keys[name]
+		if !ok {
+			return ErrKeyNotFound
+		}
+		var err error
+		k, err = otp.NewKeyFromURL(u)
+		return err
+	})
+	return k, err
+}
+
+// Put stores k with name.
+func (s *FileStore) Put(name string, k *otp.Key) error {
+	return s.update(func(keys map[string]string) error {
+		keys[name] = k.String()
+		return nil
+	})
+}
+
+// Delete removes the key stored with name.
+func (s *FileStore) Delete(name string) error {
+	return s.update(func(keys map[string]string) error {
+		if _, ok := keys[name]; !ok {
+			return ErrKeyNotFound
+		}
+		delete(keys, name)
+		return nil
+	})
+}
+
+// List returns the names of every stored key.
+func (s *FileStore) List() ([]string, error) {
+	names := []string{}
+	err := s.view(func(keys map[string]string) error {
+		for name := range keys {
+			names = append(names, name)
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names, err
+}
+
+func (s *FileStore) view(fn func(keys map[string]string) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	unlock, err := lockFile(s.path+".lock", false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	keys, _, err := s.read()
+	if err != nil {
+		return err
+	}
+	return fn(keys)
+}
+
+func (s *FileStore) update(fn func(keys map[string]string) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	unlock, err := lockFile(s.path+".lock", true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	keys, f, err := s.read()
+	if err != nil {
+		return err
+	}
+	if err := fn(keys); err != nil {
+		return err
+	}
+	return s.write(keys, f)
+}
+
+// read decrypts the stored keys. A missing file is an empty store.
+func (s *FileStore) read() (map[string]string, *fileFormat, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f := &fileFormat{}
+	if err := json.Unmarshal(data, f); err != nil || f.Version != 1 || f.KDF != kdfPBKDF2 || f.Iterations < 1 || f.Iterations > MaxIterations {
+		return nil, nil, ErrInvalidStore
+	}
+
+	aead, err := s.cipher(f.Salt, f.Iterations)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := aead.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return nil, nil, ErrWrongPassphrase
+	}
+
+	keys := map[string]string{}
+	if err := json.Unmarshal(plaintext, &keys); err != nil {
+		return nil, nil, ErrInvalidStore
+	}
+	return keys, f, nil
+}
+
+// write encrypts keys with a new nonce and atomically replaces the file. previous is the file that
+// was read, or nil if this is a new store.
+func (s *FileStore) write(keys map[string]string, previous *fileFormat) error {
+	f := previous
+	if f == nil {
+		f = &fileFormat{Version: 1, KDF: kdfPBKDF2, Iterations: s.Iterations}
+		if f.Iterations == 0 {
+			f.Iterations = DefaultIterations
+		}
+		if f.Iterations > MaxIterations {
+			f.Iterations = MaxIterations
+		}
+		f.Salt = make([]byte, 16)
+		if _, err := io.ReadFull(rand.Reader, f.Salt); err != nil {
+			return err
+		}
+	}
+
+	aead, err := s.cipher(f.Salt, f.Iterations)
+	if err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, f.Nonce); err != nil {
+		return err
+	}
+	f.Ciphertext = aead.Seal(nil, f.Nonce, plaintext, nil)
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// cipher returns the AEAD for salt, only deriving the key again when the salt changes.
+func (s *FileStore) cipher(salt []byte, iterations int) (cipher.AEAD, error) {
+	if s.key == nil || string(s.salt) != string(salt) {
+		s.key = internal.PBKDF2(s.passphrase, salt, iterations, 32)
+		s.salt = append([]byte{}, salt...)
+	}
+
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
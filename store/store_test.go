@@ -0,0 +1,117 @@
+package store
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func testStore(t *testing.T, passphrase string) (*FileStore, string) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	s := NewFileStore(path, []byte(passphrase))
+	s.Iterations = 1000
+	return s, path
+}
+
+func TestFileStore(t *testing.T) {
+	s, path := testStore(t, "hunter2")
+	k, _ := otp.NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil")
+
+	names, err := s.List()
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 0 != len(names) {
+		t.Fatalf("Expected an empty store")
+	}
+
+	if err := s.Put("snakeoil", k); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := s.Put("alpha", k); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	data, _ := os.ReadFile(path)
+	if bytes.Contains(data, []byte("JBSWY3DPEHPK3PXP")) || bytes.Contains(data, []byte("SnakeOil")) {
+		t.Fatalf("Store should be encrypted")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Fatalf("Unexpected file mode %v", info.Mode().Perm())
+	}
+
+	// A new instance reads what the first one wrote.
+	other := NewFileStore(path, []byte("hunter2"))
+	got, err := other.Get("snakeoil")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if k.String() != got.String() {
+		t.Fatalf("Unexpected key '%s'", got.String())
+	}
+	names, _ = other.List()
+	if 2 != len(names) || "alpha" != names[0] {
+		t.Fatalf("Unexpected names %v", names)
+	}
+
+	if err := other.Delete("alpha"); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if _, err := s.Get("alpha"); ErrKeyNotFound != err {
+		t.Fatalf("Expected key not found error")
+	}
+	if err := s.Delete("alpha"); ErrKeyNotFound != err {
+		t.Fatalf("Expected key not found error")
+	}
+}
+
+func TestFileStoreWrongPassphrase(t *testing.T) {
+	s, path := testStore(t, "hunter2")
+	k, _ := otp.NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP")
+	s.Put("snakeoil", k)
+
+	if _, err := NewFileStore(path, []byte("wrong")).Get("snakeoil"); ErrWrongPassphrase != err {
+		t.Fatalf("Expected wrong passphrase error")
+	}
+
+	os.WriteFile(path, []byte("not a store"), 0600)
+	if _, err := s.List(); ErrInvalidStore != err {
+		t.Fatalf("Expected invalid store error")
+	}
+
+	// The file must not be able to make opening it take forever.
+	os.WriteFile(path, []byte(`{"version":1,"kdf":"pbkdf2-sha256","iterations":2147483647,"salt":"c2FsdA==","nonce":"","ciphertext":""}`), 0600)
+	if _, err := s.List(); ErrInvalidStore != err {
+		t.Fatalf("Expected invalid store error for too many iterations")
+	}
+}
+
+func TestFileStoreConcurrent(t *testing.T) {
+	s, path := testStore(t, "hunter2")
+	k, _ := otp.NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP")
+	s.Put("first", k)
+
+	other := NewFileStore(path, []byte("hunter2"))
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store := s
+			if i%2 == 0 {
+				store = other
+			}
+			store.Put(string(rune('a'+i)), k)
+		}(i)
+	}
+	wg.Wait()
+
+	names, _ := s.List()
+	if 11 != len(names) {
+		t.Fatalf("Expected every concurrent change to be kept, got %v", names)
+	}
+}
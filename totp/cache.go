@@ -0,0 +1,85 @@
+package totp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// Codes are the passcodes of a key for the current and next time step.
+type Codes struct {
+	// Passcode for the current time step.
+	Current string
+	// Passcode for the following time step.
+	Next string
+	// Time at which Current stops being the current passcode.
+	ValidUntil time.Time
+}
+
+// CodeCache remembers the current and next passcodes of many keys, for dashboards that display
+// codes for a set of keys and refresh them more often than once per period. Passcodes are only
+// generated again once a key's period has passed. It is safe for concurrent use.
+type CodeCache struct {
+	lock    sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	step  uint64
+	codes Codes
+}
+
+// NewCodeCache returns an empty code cache.
+func NewCodeCache() *CodeCache {
+	return &CodeCache{entries: map[string]cacheEntry{}}
+}
+
+// Codes returns the passcodes of k at t, using every setting recorded in the key.
+func (c *CodeCache) Codes(k *otp.Key, t time.Time) (Codes, error) {
+	opts := ValidateOpts{}
+	FromKey(k)(nil, &opts)
+	if opts.Period == 0 {
+		opts.Period = 30
+	}
+
+	period := int64(opts.Period)
+	step := uint64(t.Unix() / period)
+	id := k.String()
+
+	c.lock.Lock()
+	entry, ok := c.entries[id]
+	c.lock.Unlock()
+	if ok && entry.step == step {
+		return entry.codes, nil
+	}
+
+	start := time.Unix(int64(step)*period, 0)
+	current, err := GenerateCodeCustom(k.Secret(), start, opts)
+	if err != nil {
+		return Codes{}, err
+	}
+	next, err := GenerateCodeCustom(k.Secret(), start.Add(time.Duration(period)*time.Second), opts)
+	if err != nil {
+		return Codes{}, err
+	}
+
+	codes := Codes{
+		Current:    current,
+		Next:       next,
+		ValidUntil: start.Add(time.Duration(period) * time.Second),
+	}
+
+	c.lock.Lock()
+	c.entries[id] = cacheEntry{step: step, codes: codes}
+	c.lock.Unlock()
+
+	return codes, nil
+}
+
+// Remove forgets the passcodes of k, for keys that are no longer displayed.
+func (c *CodeCache) Remove(k *otp.Key) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, k.String())
+}
@@ -0,0 +1,45 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestCodeCache(t *testing.T) {
+	k, _ := otp.NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=" + secSha1 + "&digits=8&period=30")
+	c := NewCodeCache()
+
+	codes, err := c.Codes(k, time.Unix(59, 0))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "94287082" != codes.Current {
+		t.Fatalf("Unexpected current code '%s'", codes.Current)
+	}
+	if 60 != codes.ValidUntil.Unix() {
+		t.Fatalf("Unexpected validity %d", codes.ValidUntil.Unix())
+	}
+
+	next, _ := GenerateCodeCustom(secSha1, time.Unix(60, 0), ValidateOpts{Digits: otp.DigitsEight})
+	if next != codes.Next {
+		t.Fatalf("Unexpected next code '%s'", codes.Next)
+	}
+
+	// The cached codes are used until the period passes.
+	again, _ := c.Codes(k, time.Unix(30, 0))
+	if codes != again {
+		t.Fatalf("Expected cached codes within the same period")
+	}
+
+	later, _ := c.Codes(k, time.Unix(60, 0))
+	if next != later.Current {
+		t.Fatalf("Expected new codes after the period passed")
+	}
+
+	c.Remove(k)
+	if 0 != len(c.entries) {
+		t.Fatalf("Expected key to be removed")
+	}
+}
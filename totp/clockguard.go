@@ -0,0 +1,74 @@
+package totp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// ClockGuardPolicy decides what a ClockGuard does when the clock has gone backwards.
+type ClockGuardPolicy int
+
+const (
+	// ClockGuardReject refuses validations while the clock is behind the latest time seen, returning
+	// otp.ErrValidateClockBackwards.
+	ClockGuardReject ClockGuardPolicy = iota
+	// ClockGuardWarn calls OnBackwards and then validates normally.
+	ClockGuardWarn
+)
+
+// ClockGuard detects the server clock going backwards between validations, for example after a bad
+// NTP step. Without it, a passcode that was accepted could be accepted again once the clock returns
+// to its time step. A guard should be shared by every validation in a process. It is safe for
+// concurrent use.
+type ClockGuard struct {
+	// What to do when the clock has gone backwards. Defaults to ClockGuardReject.
+	Policy ClockGuardPolicy
+	// Called when a validation's time step is before the time step of the latest time seen. Optional.
+	OnBackwards func(latest time.Time, now time.Time)
+
+	lock   sync.Mutex
+	latest time.Time
+}
+
+// Check records t as the time of a validation with the given period, and applies the policy if its
+// time step is before that of the latest time seen.
+func (g *ClockGuard) Check(t time.Time, period uint) error {
+	latest, backwards := g.check(t, period, true)
+	if !backwards {
+		return nil
+	}
+	if g.OnBackwards != nil {
+		g.OnBackwards(latest, t)
+	}
+	if g.Policy == ClockGuardReject {
+		return otp.ErrValidateClockBackwards
+	}
+	return nil
+}
+
+// check returns the latest time seen and whether the time step of t is before it, and records t if
+// record is true and it is the latest time seen.
+func (g *ClockGuard) check(t time.Time, period uint, record bool) (time.Time, bool) {
+	if period == 0 {
+		period = 30
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	latest := g.latest
+	backwards := !latest.IsZero() && t.Unix()/int64(period) < latest.Unix()/int64(period)
+	if record && !backwards && t.After(latest) {
+		g.latest = t
+	}
+	return latest, backwards
+}
+
+// ValidateCustom checks the clock with Check before calling ValidateCustom.
+func (g *ClockGuard) ValidateCustom(passcode string, secret string, t time.Time, opts ValidateOpts) (bool, error) {
+	if err := g.Check(t, opts.Period); err != nil {
+		return false, err
+	}
+	return ValidateCustom(passcode, secret, t, opts)
+}
@@ -0,0 +1,61 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestClockGuard(t *testing.T) {
+	g := &ClockGuard{}
+	now := time.Unix(1111111109, 0)
+
+	if err := g.Check(now, 30); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	// Going back within the same time step is harmless.
+	if err := g.Check(now.Add(-5*time.Second), 30); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := g.Check(now.Add(-time.Minute), 30); otp.ErrValidateClockBackwards != err {
+		t.Fatalf("Expected clock backwards error")
+	}
+
+	code, _ := GenerateCode(secSha1, now.Add(-time.Minute))
+	valid, err := g.ValidateCustom(code, secSha1, now.Add(-time.Minute), ValidateOpts{Digits: otp.DigitsSix})
+	if otp.ErrValidateClockBackwards != err {
+		t.Fatalf("Expected clock backwards error")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+
+	if err := g.Check(now.Add(time.Minute), 30); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+}
+
+func TestClockGuardWarn(t *testing.T) {
+	warned := 0
+	g := &ClockGuard{
+		Policy: ClockGuardWarn,
+		OnBackwards: func(latest time.Time, now time.Time) {
+			if !now.Before(latest) {
+				t.Fatalf("Expected now before latest")
+			}
+			warned++
+		},
+	}
+	now := time.Unix(1111111109, 0)
+	g.Check(now, 30)
+
+	code, _ := GenerateCode(secSha1, now.Add(-time.Minute))
+	valid, err := g.ValidateCustom(code, secSha1, now.Add(-time.Minute), ValidateOpts{Digits: otp.DigitsSix})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !valid || 1 != warned {
+		t.Fatalf("Expected validation to continue after warning")
+	}
+}
@@ -0,0 +1,29 @@
+//go:build otp_unsafe
+
+package totp
+
+import (
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// DryRun returns the error Check would return for t, without recording t or calling OnBackwards.
+// It is meant for support tooling and for canary testing, and must never be used to decide whether
+// to authenticate a user, because nothing stops the same time step from being checked again. It is
+// only built with the otp_unsafe build tag.
+func (g *ClockGuard) DryRun(t time.Time, period uint) error {
+	if _, backwards := g.check(t, period, false); backwards && g.Policy == ClockGuardReject {
+		return otp.ErrValidateClockBackwards
+	}
+	return nil
+}
+
+// ValidateDryRun checks the clock with DryRun before calling ValidateCustom, so that it leaves the
+// guard unchanged. Like DryRun, it must never be used to authenticate a user.
+func (g *ClockGuard) ValidateDryRun(passcode string, secret string, t time.Time, opts ValidateOpts) (bool, error) {
+	if err := g.DryRun(t, opts.Period); err != nil {
+		return false, err
+	}
+	return ValidateCustom(passcode, secret, t, opts)
+}
@@ -0,0 +1,39 @@
+//go:build otp_unsafe
+
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestClockGuardDryRun(t *testing.T) {
+	warned := 0
+	g := &ClockGuard{OnBackwards: func(latest time.Time, now time.Time) { warned++ }}
+	now := time.Unix(1111111109, 0)
+
+	code, _ := GenerateCode(secSha1, now)
+	valid, err := g.ValidateDryRun(code, secSha1, now, ValidateOpts{Digits: otp.DigitsSix})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatalf("Valid should be true.")
+	}
+	// The dry run did not record the time, so going back is still allowed.
+	if err := g.Check(now.Add(-time.Minute), 30); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	if err := g.Check(now, 30); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if err := g.DryRun(now.Add(-time.Minute), 30); otp.ErrValidateClockBackwards != err {
+		t.Fatalf("Expected clock backwards error")
+	}
+	if 0 != warned {
+		t.Fatalf("Expected a dry run not to call OnBackwards")
+	}
+}
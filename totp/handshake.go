@@ -0,0 +1,91 @@
+package totp
+
+import (
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// Handshake is a mutual verification between a server and a user's device that share an existing
+// enrollment, for flows such as pairing a new device. The server shows its code, which the device
+// checks before responding with its own code, which the server checks. Unlike IdentityChallenge, both
+// sides prove they hold the secret.
+//
+// The server and device codes are derived from the enrolled secret for Context using DeriveSecret, so
+// they are never valid as login passcodes, as each other, or as the codes of a handshake with another
+// context.
+type Handshake struct {
+	// Context names the purpose of the handshake, such as "pairing". Required.
+	Context string
+	// Options for the underlying TOTP codes, which should match the enrolled key (see FromKey).
+	Opts ValidateOpts
+}
+
+// ServerCode returns the code the server shows to the user at t.
+func (h Handshake) ServerCode(secret string, t time.Time) (string, error) {
+	return h.generate(secret, "server", t)
+}
+
+// DeviceCode returns the code the user's device responds with at t.
+func (h Handshake) DeviceCode(secret string, t time.Time) (string, error) {
+	return h.generate(secret, "device", t)
+}
+
+// ValidateServerCode is used by the device to check the code shown by the server.
+func (h Handshake) ValidateServerCode(passcode string, secret string, t time.Time) (bool, error) {
+	return h.validate(passcode, secret, "server", t)
+}
+
+// ValidateDeviceCode is used by the server to check the code the device responded with.
+func (h Handshake) ValidateDeviceCode(passcode string, secret string, t time.Time) (bool, error) {
+	return h.validate(passcode, secret, "device", t)
+}
+
+func (h Handshake) generate(secret string, role string, t time.Time) (string, error) {
+	derived, opts, err := h.derive(secret, role)
+	if err != nil {
+		return "", err
+	}
+	return GenerateCodeCustom(derived, t, opts)
+}
+
+func (h Handshake) validate(passcode string, secret string, role string, t time.Time) (bool, error) {
+	derived, opts, err := h.derive(secret, role)
+	if err != nil {
+		return false, err
+	}
+	return ValidateCustom(passcode, derived, t, opts)
+}
+
+// derive returns the secret for one side of the handshake, and the options to use it with.
+func (h Handshake) derive(secret string, role string) (string, ValidateOpts, error) {
+	if h.Context == "" {
+		return "", ValidateOpts{}, otp.ErrDeriveMissingContext
+	}
+	return deriveCodeSecret(secret, "otp-handshake\x00"+h.Context+"\x00"+role, h.Opts)
+}
+
+// deriveCodeSecret derives the secret of codes for purpose from the enrolled secret, so that they are
+// never valid as login passcodes or as the codes of another purpose, and returns the options to use
+// it with. The derived secret is always written in the RFC 4648 alphabet.
+func deriveCodeSecret(secret string, purpose string, opts ValidateOpts) (string, ValidateOpts, error) {
+	var b []byte
+	var err error
+	if opts.SecretDecoder != nil {
+		b, err = opts.SecretDecoder.DecodeSecret(secret)
+	} else {
+		b, err = opts.SecretAlphabet.DecodeSecret(secret)
+	}
+	if err != nil {
+		return "", ValidateOpts{}, otp.ErrValidateSecretInvalidBase32
+	}
+
+	derived, err := otp.DeriveSecret(otp.SecretAlphabetRFC4648.EncodeSecret(b), purpose, uint(len(b)))
+	if err != nil {
+		return "", ValidateOpts{}, err
+	}
+
+	opts.SecretDecoder = nil
+	opts.SecretAlphabet = otp.SecretAlphabetRFC4648
+	return derived, opts, nil
+}
@@ -0,0 +1,45 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestHandshake(t *testing.T) {
+	now := time.Unix(1111111109, 0).UTC()
+	h := Handshake{Context: "pairing", Opts: ValidateOpts{Digits: otp.DigitsSix, Skew: 1}}
+
+	server, err := h.ServerCode(secSha1, now)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	device, err := h.DeviceCode(secSha1, now)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	login, _ := GenerateCodeCustom(secSha1, now, h.Opts)
+	if server == device || server == login || device == login {
+		t.Fatalf("Handshake codes must differ from each other and the login passcode")
+	}
+
+	if valid, err := h.ValidateServerCode(server, secSha1, now); err != nil || !valid {
+		t.Fatalf("Expected the server code to be valid.")
+	}
+	if valid, err := h.ValidateDeviceCode(device, secSha1, now.Add(30*time.Second)); err != nil || !valid {
+		t.Fatalf("Expected the device code to be valid within the skew.")
+	}
+	if valid, _ := h.ValidateDeviceCode(server, secSha1, now); valid {
+		t.Fatalf("The server code must not be accepted as the device code.")
+	}
+
+	other := Handshake{Context: "recovery", Opts: h.Opts}
+	if valid, _ := other.ValidateDeviceCode(device, secSha1, now); valid {
+		t.Fatalf("Codes must not be accepted for another context.")
+	}
+
+	if _, err := (Handshake{}).ServerCode(secSha1, now); otp.ErrDeriveMissingContext != err {
+		t.Fatalf("Expected missing context error.")
+	}
+}
@@ -0,0 +1,51 @@
+package totp
+
+import (
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// IdentityChallenge lets a support agent confirm they are talking to the real account holder without
+// asking the user for a login code. The user's device shows an identity code, which the user reads
+// out to the agent, and the support tool validates it with Validate. A caller who does not hold the
+// enrolled secret can't answer the challenge, while agreeing to something the agent reads out would
+// prove nothing.
+//
+// Identity codes are derived from the enrolled secret using DeriveSecret, so they are never valid as
+// login passcodes and reading one out reveals nothing about the user's login passcodes.
+type IdentityChallenge struct {
+	// Options for the underlying TOTP codes, which should match the enrolled key (see FromKey). Set
+	// Skew to allow for the time the user takes to read the code out. Defaults to a period of 30
+	// seconds and 6 digits.
+	Opts ValidateOpts
+}
+
+// Code returns the identity code the user's device shows at t.
+func (c IdentityChallenge) Code(secret string, t time.Time) (string, error) {
+	derived, opts, err := c.derive(secret)
+	if err != nil {
+		return "", err
+	}
+	return GenerateCodeCustom(derived, t, opts)
+}
+
+// Validate is used by the support tool to check the identity code the user read out at t.
+func (c IdentityChallenge) Validate(passcode string, secret string, t time.Time) (bool, error) {
+	derived, opts, err := c.derive(secret)
+	if err != nil {
+		return false, err
+	}
+	return ValidateCustom(passcode, derived, t, opts)
+}
+
+func (c IdentityChallenge) derive(secret string) (string, ValidateOpts, error) {
+	opts := c.Opts
+	if opts.Period == 0 {
+		opts.Period = 30
+	}
+	if opts.Digits == 0 {
+		opts.Digits = otp.DigitsSix
+	}
+	return deriveCodeSecret(secret, "otp-identity", opts)
+}
@@ -0,0 +1,52 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestIdentityChallenge(t *testing.T) {
+	now := time.Unix(1111111109, 0).UTC()
+	c := IdentityChallenge{Opts: ValidateOpts{Digits: otp.DigitsEight, Skew: 1}}
+
+	code, err := c.Code(secSha1, now)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 8 != len(code) {
+		t.Fatalf("Unexpected identity code '%s'", code)
+	}
+	valid, err := c.Validate(code, secSha1, now.Add(20*time.Second))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatalf("Valid should be true for the identity code.")
+	}
+
+	// Identity codes and login passcodes are unrelated.
+	login, _ := GenerateCodeCustom(secSha1, now, c.Opts)
+	if login == code {
+		t.Fatalf("Identity code should not be the login passcode")
+	}
+	if valid, _ := c.Validate(login, secSha1, now); valid {
+		t.Fatalf("Valid should be false for a login passcode.")
+	}
+	if valid, _ := ValidateCustom(code, secSha1, now, c.Opts); valid {
+		t.Fatalf("An identity code should never be a valid login passcode.")
+	}
+	device, _ := Handshake{Context: "identity", Opts: c.Opts}.DeviceCode(secSha1, now)
+	if device == code {
+		t.Fatalf("Identity code should not be a handshake code")
+	}
+
+	c = IdentityChallenge{}
+	if code, _ := c.Code(secSha1, now); 6 != len(code) {
+		t.Fatalf("Expected a six digit identity code, got '%s'", code)
+	}
+	if _, err := c.Code("foo", now); otp.ErrValidateSecretInvalidBase32 != err {
+		t.Fatalf("Expected invalid base32 error.")
+	}
+}
@@ -0,0 +1,36 @@
+package totp
+
+import (
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/hotp"
+)
+
+// LintOpts returns a warning for every insecure or risky setting in opts, encoding the security
+// guidance for TOTP validation as code. It returns nil if there is nothing to warn about.
+func LintOpts(opts ValidateOpts) []otp.LintWarning {
+	warnings := hotp.LintOpts(hotp.WindowOpts{
+		ValidateOpts: hotp.ValidateOpts{
+			Digits:        opts.Digits,
+			Algorithm:     opts.Algorithm,
+			ExpiresAt:     opts.ExpiresAt,
+			NotBefore:     opts.NotBefore,
+			AllowTestKeys: opts.AllowTestKeys,
+		},
+	})
+
+	if opts.ClockUncertainty > 0 {
+		if opts.ClockUncertainty > 2*time.Minute {
+			warnings = append(warnings, otp.LintWarning{Option: "ClockUncertainty", Message: "accepting passcodes more than 2 minutes either side of the clock makes guessing a passcode much easier"})
+		}
+	} else if opts.Skew > 2 {
+		warnings = append(warnings, otp.LintWarning{Option: "Skew", Message: "a skew of more than 2 periods makes guessing a passcode much easier"})
+	}
+
+	if opts.Period > 120 {
+		warnings = append(warnings, otp.LintWarning{Option: "Period", Message: "periods longer than 2 minutes give attackers more time to use a stolen passcode"})
+	}
+
+	return warnings
+}
@@ -0,0 +1,25 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestLintOpts(t *testing.T) {
+	if warnings := LintOpts(ValidateOpts{Skew: 1, Algorithm: otp.AlgorithmSHA256}); 0 != len(warnings) {
+		t.Fatalf("Unexpected warnings %v", warnings)
+	}
+
+	warnings := LintOpts(ValidateOpts{Skew: 5, Period: 300, Digits: 4, Algorithm: otp.AlgorithmSHA256})
+	if 3 != len(warnings) || "Digits" != warnings[0].Option || "Skew" != warnings[1].Option || "Period" != warnings[2].Option {
+		t.Fatalf("Unexpected warnings %v", warnings)
+	}
+
+	// The skew is ignored when a clock uncertainty is set.
+	warnings = LintOpts(ValidateOpts{Skew: 5, ClockUncertainty: 5 * time.Minute, Algorithm: otp.AlgorithmSHA256})
+	if 1 != len(warnings) || "ClockUncertainty" != warnings[0].Option {
+		t.Fatalf("Unexpected warnings %v", warnings)
+	}
+}
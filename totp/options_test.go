@@ -0,0 +1,367 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestGenerateWith(t *testing.T) {
+	k, err := GenerateWith(
+		WithIssuer("SnakeOil"),
+		WithAccountName("alice@example.com"),
+		WithDigits(otp.DigitsEight),
+		WithPeriod(60),
+		WithAlgorithm(otp.AlgorithmSHA256),
+		WithScopes("login"),
+		WithSkew(5),
+	)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "SnakeOil" != k.Issuer() || "alice@example.com" != k.AccountName() {
+		t.Fatalf("Extracting Issuer and Account Name")
+	}
+	if otp.DigitsEight != k.Digits() || 60 != k.Period() || otp.AlgorithmSHA256 != k.Algorithm() {
+		t.Fatalf("Options were not applied")
+	}
+
+	if _, err := GenerateWith(WithAccountName("alice@example.com")); otp.ErrGenerateMissingIssuer != err {
+		t.Fatalf("Expected missing issuer error.")
+	}
+}
+
+func TestValidateWith(t *testing.T) {
+	valid, err := ValidateWith("94287082", secSha1, time.Unix(59, 0).UTC(), WithDigits(otp.DigitsEight))
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid {
+		t.Fatalf("Valid should be true.")
+	}
+
+	// The default skew of 1 accepts the previous period.
+	valid, _ = ValidateWith("94287082", secSha1, time.Unix(61, 0).UTC(), WithDigits(otp.DigitsEight))
+	if !valid {
+		t.Fatalf("Valid should be true within the default skew.")
+	}
+	valid, _ = ValidateWith("94287082", secSha1, time.Unix(61, 0).UTC(), WithDigits(otp.DigitsEight), WithSkew(0))
+	if valid {
+		t.Fatalf("Valid should be false without skew.")
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	k, err := GenerateWith(
+		WithIssuer("SnakeOil"),
+		WithAccountName("alice@example.com"),
+		WithDigits(otp.DigitsEight),
+		WithPeriod(60),
+		WithScopes("login"),
+	)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	now := time.Unix(1111111109, 0).UTC()
+	code, _ := GenerateCodeCustom(k.Secret(), now, ValidateOpts{Period: 60, Digits: otp.DigitsEight})
+
+	valid, err := ValidateKey(code, k, now)
+	if otp.ErrValidateScopeMissing != err {
+		t.Fatalf("Expected missing scope error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+
+	valid, err = ValidateKey(code, k, now, WithScope("login"))
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid {
+		t.Fatalf("Valid should be true.")
+	}
+}
+
+func TestSecretAlphabet(t *testing.T) {
+	k, err := GenerateWith(
+		WithIssuer("SnakeOil"),
+		WithAccountName("alice@example.com"),
+		WithSecret([]byte("12345678901234567890")),
+		WithSecretAlphabet(otp.SecretAlphabetCrockford),
+	)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if otp.SecretAlphabetCrockford != k.SecretAlphabet() {
+		t.Fatalf("Unexpected alphabet %q", k.SecretAlphabet())
+	}
+	if secSha1 == k.Secret() {
+		t.Fatalf("Secret should be written in the Crockford alphabet")
+	}
+
+	now := time.Unix(59, 0).UTC()
+	code, err := GenerateCodeCustom(secSha1, now, ValidateOpts{})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	valid, err := ValidateKey(code, k, now)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatalf("Valid should be true for the same secret in another alphabet.")
+	}
+}
+
+func TestHiddenAccountName(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	k, err := GenerateWith(
+		WithIssuer("SnakeOil"),
+		WithAccountName("alice@example.com"),
+		WithSecret(secret),
+		WithHiddenAccountName(),
+	)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if strings.Contains(k.String(), "alice") {
+		t.Fatalf("Account name should not be in the URL '%s'", k.String())
+	}
+	if otp.AccountPseudonym(secret, "alice@example.com") != k.AccountName() {
+		t.Fatalf("Unexpected account name '%s'", k.AccountName())
+	}
+}
+
+func TestClockUncertainty(t *testing.T) {
+	now := time.Unix(1111111115, 0).UTC()
+	code, _ := GenerateCodeCustom(secSha1, now.Add(-45*time.Second), ValidateOpts{})
+
+	// The code is two steps old, beyond the default skew of 1.
+	valid, _ := ValidateWith(code, secSha1, now)
+	if valid {
+		t.Fatalf("Valid should be false outside of the skew.")
+	}
+
+	valid, err := ValidateWith(code, secSha1, now, WithClockUncertainty(50*time.Second))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatalf("Valid should be true within the clock uncertainty.")
+	}
+
+	// A known-good clock tightens the window below the default skew.
+	code, _ = GenerateCodeCustom(secSha1, now.Add(-30*time.Second), ValidateOpts{})
+	valid, _ = ValidateWith(code, secSha1, now, WithClockUncertainty(5*time.Second))
+	if valid {
+		t.Fatalf("Valid should be false outside of the clock uncertainty.")
+	}
+}
+
+func TestWithProfile(t *testing.T) {
+	for _, profile := range []otp.Profile{otp.ProfileRFCDefault(), otp.Profile60sEightDigit(), otp.ProfileSteam(), otp.ProfileAuthy10s7d()} {
+		k, err := GenerateWith(WithIssuer("SnakeOil"), WithAccountName("alice@example.com"), WithProfile(profile))
+		if err != nil {
+			t.Fatalf("Error: %s", err.Error())
+		}
+		if profile.Digits != k.Digits() || profile.Period != uint(k.Period()) || profile.Algorithm != k.Algorithm() || profile.Encoder != k.Encoder() {
+			t.Fatalf("Profile %s was not applied to '%s'", profile.Name, k.String())
+		}
+
+		now := time.Unix(1111111109, 0).UTC()
+		code, _ := GenerateCodeCustom(k.Secret(), now, ValidateOpts{
+			Period:    profile.Period,
+			Digits:    profile.Digits,
+			Algorithm: profile.Algorithm,
+			Encoder:   profile.Encoder,
+		})
+		if profile.Digits.Length() != len(code) {
+			t.Fatalf("Unexpected code '%s' for profile %s", code, profile.Name)
+		}
+		valid, err := ValidateWith(code, k.Secret(), now, WithProfile(profile))
+		if err != nil {
+			t.Fatalf("Error: %s", err.Error())
+		}
+		if !valid {
+			t.Fatalf("Valid should be true for profile %s.", profile.Name)
+		}
+	}
+}
+
+func TestWithProfileRegistry(t *testing.T) {
+	r := otp.NewProfileRegistry()
+	r.Register(otp.Profile{Name: "corp-standard", Digits: otp.DigitsEight, Period: 60, Algorithm: otp.AlgorithmSHA256})
+
+	corp, _ := r.Lookup("corp-standard")
+	k, err := GenerateWith(WithIssuer("SnakeOil"), WithAccountName("alice@example.com"), WithProfile(corp))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "corp-standard" != k.Profile() {
+		t.Fatalf("Expected the profile name in the key '%s'", k.String())
+	}
+	if p, ok := r.ForKey(k); !ok || 60 != p.Period {
+		t.Fatalf("Expected the profile to be found from the key")
+	}
+
+	// A key that only records its profile is validated with the settings of the registry.
+	k, _ = otp.NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil&profile=corp-standard")
+	now := time.Unix(1111111109, 0).UTC()
+	code, _ := GenerateCodeCustom(k.Secret(), now, ValidateOpts{Period: 60, Digits: otp.DigitsEight, Algorithm: otp.AlgorithmSHA256})
+	if valid, _ := ValidateKey(code, k, now); valid {
+		t.Fatalf("Valid should be false without the registry.")
+	}
+	valid, err := ValidateKey(code, k, now, WithProfileRegistry(r))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatalf("Valid should be true with the registry.")
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	now := time.Unix(1111111109, 0).UTC()
+	code, _ := GenerateCodeCustom(secSha1, now, ValidateOpts{Context: "pairing"})
+
+	if valid, _ := ValidateWith(code, secSha1, now); valid {
+		t.Fatalf("Valid should be false without the context.")
+	}
+	if valid, _ := ValidateWith(code, secSha1, now, WithContext("login")); valid {
+		t.Fatalf("Valid should be false for another context.")
+	}
+	valid, err := ValidateWith(code, secSha1, now, WithContext("pairing"))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatalf("Valid should be true for the same context.")
+	}
+}
+
+func TestWithProfileVersion(t *testing.T) {
+	r := otp.NewProfileRegistry()
+	r.Register(otp.Profile{Name: "corp-standard", Digits: otp.DigitsSix, Period: 30, Algorithm: otp.AlgorithmSHA1})
+
+	corp, _ := r.Lookup("corp-standard")
+	k, err := GenerateWith(WithIssuer("SnakeOil"), WithAccountName("alice@example.com"), WithProfile(corp))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if strings.Contains(k.String(), "profile_version") {
+		t.Fatalf("Version 0 should not be recorded in '%s'", k.String())
+	}
+
+	// Changing the defaults later must not change how the existing key is validated.
+	r.Register(otp.Profile{Name: "corp-standard", Version: 1, Digits: otp.DigitsEight, Period: 60, Algorithm: otp.AlgorithmSHA256})
+	corp, _ = r.Lookup("corp-standard")
+	k2, _ := GenerateWith(WithIssuer("SnakeOil"), WithAccountName("bob@example.com"), WithProfile(corp))
+	if 1 != k2.ProfileVersion() {
+		t.Fatalf("Expected version 1 in '%s'", k2.String())
+	}
+
+	now := time.Unix(1111111109, 0).UTC()
+	code, _ := GenerateCode(k.Secret(), now)
+	enrolled, ok := r.ForKey(k)
+	if !ok {
+		t.Fatalf("Expected the profile of the key")
+	}
+	valid, err := ValidateWith(code, k.Secret(), now, WithProfile(enrolled))
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatalf("Valid should be true with the enrolled profile version.")
+	}
+
+	// Keys that only record their profile version are validated with the settings of that version.
+	v0, _ := otp.NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil&profile=corp-standard")
+	v1, _ := otp.NewKeyFromURL("otpauth://totp/SnakeOil:bob?secret=JBSWY3DPEHPK3PXP&issuer=SnakeOil&profile=corp-standard&profile_version=1")
+	codeV0, _ := GenerateCodeCustom(v0.Secret(), now, ValidateOpts{Period: 30, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1})
+	codeV1, _ := GenerateCodeCustom(v1.Secret(), now, ValidateOpts{Period: 60, Digits: otp.DigitsEight, Algorithm: otp.AlgorithmSHA256})
+	if valid, _ := ValidateKey(codeV0, v0, now, WithProfileRegistry(r)); !valid {
+		t.Fatalf("Valid should be true for the version 0 key with version 0 settings.")
+	}
+	if valid, _ := ValidateKey(codeV1, v1, now, WithProfileRegistry(r)); !valid {
+		t.Fatalf("Valid should be true for the version 1 key with version 1 settings.")
+	}
+	if valid, _ := ValidateKey(codeV1, v0, now, WithProfileRegistry(r)); valid {
+		t.Fatalf("Valid should be false for the version 0 key with version 1 settings.")
+	}
+	if valid, _ := ValidateKey(codeV0, v1, now, WithProfileRegistry(r)); valid {
+		t.Fatalf("Valid should be false for the version 1 key with version 0 settings.")
+	}
+}
+
+func TestWithRiskPolicy(t *testing.T) {
+	k, _ := otp.NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=" + secSha1 + "&issuer=SnakeOil")
+	imported, _ := otp.AddRiskFlags(k, otp.RiskImported)
+
+	now := time.Unix(1111111109, 0).UTC()
+	code, _ := GenerateCode(k.Secret(), now)
+	policy := WithRiskPolicy(otp.DenyRiskFlags(otp.RiskImported))
+
+	if valid, err := ValidateKey(code, k, now, policy); err != nil || !valid {
+		t.Fatalf("Valid should be true for a key without risk flags.")
+	}
+	valid, err := ValidateKey(code, imported, now, policy)
+	if otp.ErrValidateRiskFlagged != err {
+		t.Fatalf("Expected risk flagged error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+	if valid, err := ValidateKey(code, imported, now); err != nil || !valid {
+		t.Fatalf("Valid should be true without a risk policy.")
+	}
+}
+
+func TestValidateKeyRestrictions(t *testing.T) {
+	now := time.Unix(1111111109, 0).UTC()
+	k, err := GenerateWith(
+		WithIssuer("SnakeOil"),
+		WithAccountName("alice@example.com"),
+		WithNotBefore(now.Add(-time.Hour)),
+		WithExpiresAt(now.Add(-time.Minute)),
+		WithScopes("login"),
+	)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	code, _ := GenerateCode(k.Secret(), now)
+
+	// Options can't lift the restrictions recorded in the key.
+	_, err = ValidateKey(code, k, now, WithExpiresAt(time.Time{}), WithScope("login"))
+	if otp.ErrValidateKeyExpired != err {
+		t.Fatalf("Expected key expired error.")
+	}
+	_, err = ValidateKey(code, k, now, WithExpiresAt(now.Add(time.Hour)), WithScopes(), WithScope("admin"))
+	if otp.ErrValidateKeyExpired != err {
+		t.Fatalf("Expected key expired error.")
+	}
+
+	k, _ = GenerateWith(WithIssuer("SnakeOil"), WithAccountName("alice@example.com"), WithScopes("login"), WithNotBefore(now.Add(time.Minute)))
+	if _, err := ValidateKey(code, k, now, WithNotBefore(time.Time{}), WithScope("login")); otp.ErrValidateKeyNotYetValid != err {
+		t.Fatalf("Expected key not yet valid error.")
+	}
+	k, _ = GenerateWith(WithIssuer("SnakeOil"), WithAccountName("alice@example.com"), WithScopes("login"))
+	code, _ = GenerateCode(k.Secret(), now)
+	if _, err := ValidateKey(code, k, now, WithScopes(), WithScope("admin")); otp.ErrValidateScopeNotPermitted != err {
+		t.Fatalf("Expected scope not permitted error.")
+	}
+	if valid, _ := ValidateKey(code[:4], k, now, WithDigits(4), WithScope("login")); valid {
+		t.Fatalf("Valid should be false for a shortened passcode.")
+	}
+
+	// Options can still tighten them.
+	if _, err := ValidateKey(code, k, now, WithExpiresAt(now.Add(-time.Second)), WithScope("login")); otp.ErrValidateKeyExpired != err {
+		t.Fatalf("Expected key expired error.")
+	}
+	valid, err := ValidateKey(code, k, now, WithScope("login"))
+	if err != nil || !valid {
+		t.Fatalf("Valid should be true within the key's restrictions.")
+	}
+}
@@ -0,0 +1,29 @@
+package totp
+
+import (
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+// PasswordChecker returns true if password is the user's password.
+type PasswordChecker func(password string) bool
+
+// ValidatePasswordAndCode validates a "password+code" submission, as sent by RADIUS clients and some
+// VPNs, for k. The passcode is split from the end of the submission using the digits of k (see
+// otp.SplitPasswordCode), and the rest is given to checkPassword. Both parts are always checked, so
+// the time taken doesn't reveal which of them was wrong. options are applied as for ValidateKey.
+func ValidatePasswordAndCode(submission string, k *otp.Key, t time.Time, checkPassword PasswordChecker, options ...Option) (bool, error) {
+	password, passcode, ok := otp.SplitPasswordCode(submission, k.Digits())
+	if !ok {
+		return false, otp.ErrValidateInputInvalidLength
+	}
+
+	passwordValid := checkPassword(password)
+	codeValid, err := ValidateKey(passcode, k, t, options...)
+	if err != nil {
+		return false, err
+	}
+
+	return passwordValid && codeValid, nil
+}
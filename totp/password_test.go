@@ -0,0 +1,42 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestValidatePasswordAndCode(t *testing.T) {
+	k, _ := otp.NewKeyFromURL("otpauth://totp/SnakeOil:alice?secret=" + secSha1 + "&digits=8")
+	now := time.Unix(1111111109, 0).UTC()
+	code, _ := GenerateCodeCustom(secSha1, now, ValidateOpts{Digits: otp.DigitsEight})
+
+	checked := 0
+	checkPassword := func(password string) bool {
+		checked++
+		return "hunter2" == password
+	}
+
+	valid, err := ValidatePasswordAndCode("hunter2"+code, k, now, checkPassword)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatalf("Valid should be true.")
+	}
+
+	if valid, _ := ValidatePasswordAndCode("hunter3"+code, k, now, checkPassword); valid {
+		t.Fatalf("Valid should be false for the wrong password.")
+	}
+	if valid, _ := ValidatePasswordAndCode("hunter200000000", k, now, checkPassword); valid {
+		t.Fatalf("Valid should be false for the wrong passcode.")
+	}
+	if 3 != checked {
+		t.Fatalf("Expected the password to be checked every time, was checked %d times", checked)
+	}
+
+	if _, err := ValidatePasswordAndCode("123", k, now, checkPassword); otp.ErrValidateInputInvalidLength != err {
+		t.Fatalf("Expected invalid length error.")
+	}
+}
@@ -0,0 +1,33 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/internal"
+)
+
+// testKeySalt separates test key secrets from any other use of HKDF in this module.
+const testKeySalt = "github.com/ecnepsnai/otp test key"
+
+// GenerateTestKey creates a deterministic TOTP Key for staging environments and end-to-end tests.
+// The secret is derived from label, so test suites can compute passcodes for the same key without
+// storing it, and the issuer is prefixed with otp.TestKeyIssuerPrefix so the key is clearly marked.
+//
+// Anyone who knows the label can derive the secret. ValidateKey rejects test keys unless
+// WithAllowTestKeys is given, which must never be done in production. ValidateCustom only sees the
+// secret, so it can't recognize a test key unless ValidateOpts.TestKey is set. opts.Secret, opts.Rand
+// and opts.SecretSize are ignored.
+func GenerateTestKey(label string, opts GenerateOpts) (*otp.Key, error) {
+	if opts.Issuer == "" {
+		return nil, otp.ErrGenerateMissingIssuer
+	}
+	if !strings.HasPrefix(opts.Issuer, otp.TestKeyIssuerPrefix) {
+		opts.Issuer = otp.TestKeyIssuerPrefix + opts.Issuer
+	}
+
+	opts.Secret = internal.HKDF([]byte(label), []byte(testKeySalt), nil, 20)
+
+	return generate(opts, url.Values{"test": {"1"}})
+}
@@ -0,0 +1,71 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+)
+
+func TestGenerateTestKey(t *testing.T) {
+	opts := GenerateOpts{
+		Issuer:      "SnakeOil",
+		AccountName: "e2e@example.com",
+	}
+	k, err := GenerateTestKey("checkout-flow", opts)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "TEST-SnakeOil" != k.Issuer() {
+		t.Fatalf("Issuer should be prefixed, got '%s'", k.Issuer())
+	}
+	if !k.IsTestKey() {
+		t.Fatalf("Key should be marked as a test key")
+	}
+
+	again, _ := GenerateTestKey("checkout-flow", opts)
+	if k.Secret() != again.Secret() {
+		t.Fatalf("Test keys should be deterministic")
+	}
+	other, _ := GenerateTestKey("login-flow", opts)
+	if k.Secret() == other.Secret() {
+		t.Fatalf("Test keys should differ per label")
+	}
+
+	if _, err := GenerateTestKey("checkout-flow", GenerateOpts{AccountName: "e2e"}); otp.ErrGenerateMissingIssuer != err {
+		t.Fatalf("Expected missing issuer error.")
+	}
+
+	generated, _ := Generate(opts)
+	if generated.IsTestKey() {
+		t.Fatalf("Generated keys should not be test keys")
+	}
+}
+
+func TestValidateTestKey(t *testing.T) {
+	k, _ := GenerateTestKey("checkout-flow", GenerateOpts{Issuer: "SnakeOil", AccountName: "e2e@example.com"})
+	now := time.Unix(1111111109, 0).UTC()
+	code, _ := GenerateCode(k.Secret(), now)
+
+	opts := ValidateOpts{
+		Digits:    k.Digits(),
+		Algorithm: k.Algorithm(),
+		TestKey:   k.IsTestKey(),
+	}
+	valid, err := ValidateCustom(code, k.Secret(), now, opts)
+	if otp.ErrValidateTestKeyNotAllowed != err {
+		t.Fatalf("Expected test key not allowed error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+
+	opts.AllowTestKeys = true
+	valid, err = ValidateCustom(code, k.Secret(), now, opts)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid {
+		t.Fatalf("Valid should be true when test keys are allowed.")
+	}
+}
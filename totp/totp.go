@@ -19,11 +19,11 @@ package totp
 
 import (
 	"crypto/rand"
-	"encoding/base32"
 	"io"
 	"math"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ecnepsnai/otp"
@@ -69,10 +69,45 @@ type ValidateOpts struct {
 	// of either side of the specified time.  Defaults to 0 allowed skews.  Values greater
 	// than 1 are likely sketchy.
 	Skew uint
+	// Maximum error of the clock, such as the bound reported by the NTP daemon plus the expected
+	// drift of users' devices. When set, every time step within the uncertainty of t is accepted and
+	// Skew is ignored. Defaults to 0, using Skew.
+	ClockUncertainty time.Duration
 	// Digits as part of the input. Defaults to 6.
 	Digits otp.Digits
 	// Algorithm to use for HMAC. Defaults to SHA1.
 	Algorithm otp.Algorithm
+	// Encoder used to render the passcode. Defaults to decimal digits.
+	Encoder otp.Encoder
+	// Alphabet the secret is written in (see Key.SecretAlphabet). Defaults to RFC 4648.
+	SecretAlphabet otp.SecretAlphabet
+	// Decoder used to convert the secret to bytes, such as an otp.DecoderChain. Defaults to
+	// SecretAlphabet.
+	SecretDecoder otp.SecretDecoder
+	// Purpose the passcode is for, such as "login" or "pairing" (see hotp.ValidateOpts.Context).
+	// Defaults to none.
+	Context string
+	// Time after which the key is no longer valid (see Key.ExpiresAt). Defaults to never.
+	ExpiresAt time.Time
+	// Time before which the key may not be used (see Key.NotBefore). Defaults to immediately.
+	NotBefore time.Time
+	// Operations the key is restricted to (see Key.Scopes). Defaults to any operation.
+	Scopes []string
+	// Operation this passcode is being validated for. Must be one of Scopes when Scopes is set.
+	Scope string
+	// Whether the key is a deterministic test key (see Key.IsTestKey). Set by FromKey. A test key
+	// can't be recognized from its secret alone, so use ValidateKey to reject test keys by default.
+	TestKey bool
+	// Accept test keys. This must only be enabled outside of production.
+	AllowTestKeys bool
+	// Risk flags of the key (see Key.RiskFlags).
+	RiskFlags []string
+	// Decides whether a key with RiskFlags may be validated, such as otp.DenyRiskFlags. Defaults to
+	// accepting every key.
+	RiskPolicy otp.RiskPolicy
+	// Profiles that ValidateKey looks up the profile recorded in the key in (see
+	// ProfileRegistry.ForKey). Defaults to none, using the settings recorded in the key.
+	ProfileRegistry *otp.ProfileRegistry
 }
 
 // GenerateCodeCustom takes a timepoint and produces a passcode using a
@@ -84,8 +119,12 @@ func GenerateCodeCustom(secret string, t time.Time, opts ValidateOpts) (passcode
 	}
 	counter := uint64(math.Floor(float64(t.Unix()) / float64(opts.Period)))
 	passcode, err = hotp.GenerateCodeCustom(secret, counter, hotp.ValidateOpts{
-		Digits:    opts.Digits,
-		Algorithm: opts.Algorithm,
+		Digits:         opts.Digits,
+		Algorithm:      opts.Algorithm,
+		Encoder:        opts.Encoder,
+		SecretAlphabet: opts.SecretAlphabet,
+		SecretDecoder:  opts.SecretDecoder,
+		Context:        opts.Context,
 	})
 	if err != nil {
 		return "", err
@@ -96,35 +135,62 @@ func GenerateCodeCustom(secret string, t time.Time, opts ValidateOpts) (passcode
 // ValidateCustom validates a TOTP given a user specified time and custom options.
 // Most users should use Validate() to provide an interpolatable TOTP experience.
 func ValidateCustom(passcode string, secret string, t time.Time, opts ValidateOpts) (bool, error) {
+	_, rv, err := ValidateDrift(passcode, secret, t, opts)
+	return rv, err
+}
+
+// ValidateDrift is ValidateCustom that also returns the drift of a valid passcode: the number of time
+// steps between t and the step the passcode was generated for, negative if the passcode's clock
+// is behind. If the passcode is valid for more than one step, the earliest is used.
+func ValidateDrift(passcode string, secret string, t time.Time, opts ValidateOpts) (int64, bool, error) {
 	if opts.Period == 0 {
 		opts.Period = 30
 	}
 
-	counters := []uint64{}
-	counter := int64(math.Floor(float64(t.Unix()) / float64(opts.Period)))
-
-	counters = append(counters, uint64(counter))
-	for i := 1; i <= int(opts.Skew); i++ {
-		counters = append(counters, uint64(counter+int64(i)))
-		counters = append(counters, uint64(counter-int64(i)))
+	if !opts.ExpiresAt.IsZero() && !t.Before(opts.ExpiresAt) {
+		return 0, false, otp.ErrValidateKeyExpired
 	}
 
-	for _, counter := range counters {
-		rv, err := hotp.ValidateCustom(passcode, counter, secret, hotp.ValidateOpts{
-			Digits:    opts.Digits,
-			Algorithm: opts.Algorithm,
-		})
+	if !opts.NotBefore.IsZero() && t.Before(opts.NotBefore) {
+		return 0, false, otp.ErrValidateKeyNotYetValid
+	}
 
-		if err != nil {
-			return false, err
-		}
+	// The secret is decoded once and the whole skew window is scanned by hotp.ValidateWindow, rather
+	// than validating each step separately.
+	counter := uint64(math.Floor(float64(t.Unix()) / float64(opts.Period)))
+	skew := uint64(opts.Skew)
+	first := uint64(0)
+	if counter > skew {
+		first = counter - skew
+	}
+	last := counter + skew
+	if opts.ClockUncertainty > 0 {
+		first = uint64(math.Max(0, math.Floor(float64(t.Add(-opts.ClockUncertainty).Unix())/float64(opts.Period))))
+		last = uint64(math.Floor(float64(t.Add(opts.ClockUncertainty).Unix()) / float64(opts.Period)))
+	}
 
-		if rv == true {
-			return true, nil
-		}
+	match, rv, err := hotp.ValidateWindow(passcode, first, secret, hotp.WindowOpts{
+		ValidateOpts: hotp.ValidateOpts{
+			Digits:         opts.Digits,
+			Algorithm:      opts.Algorithm,
+			Encoder:        opts.Encoder,
+			SecretAlphabet: opts.SecretAlphabet,
+			SecretDecoder:  opts.SecretDecoder,
+			Context:        opts.Context,
+			Scopes:         opts.Scopes,
+			Scope:          opts.Scope,
+			TestKey:        opts.TestKey,
+			AllowTestKeys:  opts.AllowTestKeys,
+			RiskFlags:      opts.RiskFlags,
+			RiskPolicy:     opts.RiskPolicy,
+		},
+		Window: last - first,
+	})
+	if err != nil || !rv {
+		return 0, false, err
 	}
 
-	return false, nil
+	return int64(match) - int64(counter), true, nil
 }
 
 // GenerateOpts provides options for Generate().  The default values
@@ -146,12 +212,42 @@ type GenerateOpts struct {
 	Algorithm otp.Algorithm
 	// Reader to use for generating TOTP Key.
 	Rand io.Reader
+	// Encoder used to render passcodes. Defaults to decimal digits.
+	Encoder otp.Encoder
+	// Alphabet to write the secret in. Defaults to RFC 4648, which should be used unless the key is
+	// for a client that requires another alphabet.
+	SecretAlphabet otp.SecretAlphabet
+	// Time after which the key is no longer valid. Defaults to never.
+	ExpiresAt time.Time
+	// Time before which the key may not be used, for keys provisioned in advance. Defaults to immediately.
+	NotBefore time.Time
+	// Operations the key is restricted to, such as "login". Defaults to any operation.
+	Scopes []string
+	// Profile used to encode the secret and URL. Defaults to Google Authenticator compatible.
+	SecretEncoding otp.SecretEncodingProfile
+	// Show a pseudonym instead of the account name in the URL (see otp.AccountPseudonym). The
+	// application must keep the real account name itself. Defaults to false.
+	HideAccountName bool
+	// Name of the profile the key was generated with, recorded in the URL (see Key.Profile).
+	// Defaults to none.
+	ProfileName string
+	// Version of the profile the key was generated with, recorded in the URL if ProfileName is set
+	// (see Key.ProfileVersion). Defaults to 0.
+	ProfileVersion uint
+	// Maximum number of characters in the issuer, longer issuers are truncated (see otp.TruncateLabel).
+	// Defaults to no limit.
+	MaxIssuerLength uint
+	// Maximum number of characters in the account name, longer names are truncated. Defaults to no limit.
+	MaxAccountNameLength uint
 }
 
-var b32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
-
 // Generate a new TOTP Key.
 func Generate(opts GenerateOpts) (*otp.Key, error) {
+	return generate(opts, nil)
+}
+
+// generate creates a new TOTP Key, adding any extra parameters to the URL.
+func generate(opts GenerateOpts, extra url.Values) (*otp.Key, error) {
 	// url encode the Issuer/AccountName
 	if opts.Issuer == "" {
 		return nil, otp.ErrGenerateMissingIssuer
@@ -161,6 +257,13 @@ func Generate(opts GenerateOpts) (*otp.Key, error) {
 		return nil, otp.ErrGenerateMissingAccountName
 	}
 
+	opts.Issuer = otp.TruncateLabel(opts.Issuer, opts.MaxIssuerLength)
+	opts.AccountName = otp.TruncateLabel(opts.AccountName, opts.MaxAccountNameLength)
+
+	if err := otp.CheckLabel(opts.Issuer, opts.AccountName); err != nil {
+		return nil, err
+	}
+
 	if opts.Period == 0 {
 		opts.Period = 30
 	}
@@ -171,6 +274,9 @@ func Generate(opts GenerateOpts) (*otp.Key, error) {
 
 	if opts.Digits == 0 {
 		opts.Digits = otp.DigitsSix
+		if opts.Encoder == otp.EncoderSteam {
+			opts.Digits = otp.DigitsSteam
+		}
 	}
 
 	if opts.Rand == nil {
@@ -180,21 +286,49 @@ func Generate(opts GenerateOpts) (*otp.Key, error) {
 	// otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example
 
 	v := url.Values{}
-	if len(opts.Secret) != 0 {
-		v.Set("secret", b32NoPadding.EncodeToString(opts.Secret))
-	} else {
-		secret := make([]byte, opts.SecretSize)
+	secret := opts.Secret
+	if len(secret) == 0 {
+		secret = make([]byte, opts.SecretSize)
 		_, err := opts.Rand.Read(secret)
 		if err != nil {
 			return nil, err
 		}
-		v.Set("secret", b32NoPadding.EncodeToString(secret))
+	}
+	if opts.SecretAlphabet == otp.SecretAlphabetRFC4648 {
+		v.Set("secret", opts.SecretEncoding.EncodeSecret(secret))
+	} else {
+		v.Set("secret", opts.SecretAlphabet.EncodeSecret(secret))
+		v.Set("alphabet", string(opts.SecretAlphabet))
+	}
+	if opts.HideAccountName {
+		opts.AccountName = otp.AccountPseudonym(secret, opts.AccountName)
 	}
 
 	v.Set("issuer", opts.Issuer)
 	v.Set("period", strconv.FormatUint(uint64(opts.Period), 10))
 	v.Set("algorithm", opts.Algorithm.String())
 	v.Set("digits", opts.Digits.String())
+	if opts.Encoder != otp.EncoderDefault {
+		v.Set("encoder", string(opts.Encoder))
+	}
+	if !opts.ExpiresAt.IsZero() {
+		v.Set("expires", strconv.FormatInt(opts.ExpiresAt.Unix(), 10))
+	}
+	if !opts.NotBefore.IsZero() {
+		v.Set("notbefore", strconv.FormatInt(opts.NotBefore.Unix(), 10))
+	}
+	if len(opts.Scopes) != 0 {
+		v.Set("scope", strings.Join(opts.Scopes, ","))
+	}
+	if opts.ProfileName != "" {
+		v.Set("profile", opts.ProfileName)
+		if opts.ProfileVersion != 0 {
+			v.Set("profile_version", strconv.FormatUint(uint64(opts.ProfileVersion), 10))
+		}
+	}
+	for name := range extra {
+		v.Set(name, extra.Get(name))
+	}
 
 	u := url.URL{
 		Scheme:   "otpauth",
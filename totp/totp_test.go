@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/hotp"
 )
 
 type tc struct {
@@ -34,6 +35,8 @@ type tc struct {
 	Secret string
 }
 
+var b32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
 var (
 	secSha1   = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
 	secSha256 = base32.StdEncoding.EncodeToString([]byte("12345678901234567890123456789012"))
@@ -220,3 +223,235 @@ func TestGoogleLowerCaseSecret(t *testing.T) {
 		t.Fatalf("Invalid")
 	}
 }
+
+func TestValidateExpired(t *testing.T) {
+	expires := time.Unix(1111111111, 0).UTC()
+	k, err := Generate(GenerateOpts{
+		Issuer:      "SnakeOil",
+		AccountName: "alice@example.com",
+		ExpiresAt:   expires,
+	})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !k.ExpiresAt().Equal(expires) {
+		t.Fatalf("ExpiresAt was not kept")
+	}
+
+	opts := ValidateOpts{
+		Digits:    otp.DigitsEight,
+		Algorithm: otp.AlgorithmSHA1,
+		ExpiresAt: k.ExpiresAt(),
+	}
+
+	valid, err := ValidateCustom("07081804", secSha1, time.Unix(1111111109, 0).UTC(), opts)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid {
+		t.Fatalf("Valid should be true before expiry.")
+	}
+
+	valid, err = ValidateCustom("14050471", secSha1, time.Unix(1111111111, 0).UTC(), opts)
+	if otp.ErrValidateKeyExpired != err {
+		t.Fatalf("Expected key expired error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+}
+
+func TestValidateScope(t *testing.T) {
+	opts := ValidateOpts{
+		Digits:    otp.DigitsEight,
+		Algorithm: otp.AlgorithmSHA1,
+		Scopes:    []string{"login"},
+	}
+
+	valid, err := ValidateCustom("94287082", secSha1, time.Unix(59, 0).UTC(), opts)
+	if otp.ErrValidateScopeMissing != err {
+		t.Fatalf("Expected missing scope error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+
+	opts.Scope = "login"
+	valid, err = ValidateCustom("94287082", secSha1, time.Unix(59, 0).UTC(), opts)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid {
+		t.Fatalf("Valid should be true.")
+	}
+}
+
+func BenchmarkValidate(b *testing.B) {
+	opts := ValidateOpts{Digits: otp.DigitsEight, Algorithm: otp.AlgorithmSHA1}
+	ts := time.Unix(59, 0).UTC()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ValidateCustom("94287082", secSha1, ts, opts)
+	}
+}
+
+func BenchmarkValidateSkewWindow(b *testing.B) {
+	// The passcode is wrong so that the whole window is scanned.
+	opts := ValidateOpts{Digits: otp.DigitsEight, Algorithm: otp.AlgorithmSHA1, Skew: 1}
+	ts := time.Unix(59, 0).UTC()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ValidateCustom("00000000", secSha1, ts, opts)
+	}
+}
+
+// BenchmarkValidateSkewWindowPerStep validates each step of the skew window separately, as
+// ValidateCustom used to, for comparison with BenchmarkValidateSkewWindow.
+func BenchmarkValidateSkewWindowPerStep(b *testing.B) {
+	opts := hotp.ValidateOpts{Digits: otp.DigitsEight, Algorithm: otp.AlgorithmSHA1}
+	counter := uint64(59 / 30)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, c := range []uint64{counter, counter + 1, counter - 1} {
+			hotp.ValidateCustom("00000000", c, secSha1, opts)
+		}
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	opts := GenerateOpts{Issuer: "SnakeOil", AccountName: "alice@example.com"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Generate(opts)
+	}
+}
+
+// Allocations when scanning the skew window must stay within budget.
+func TestValidateSkewAllocs(t *testing.T) {
+	opts := ValidateOpts{Digits: otp.DigitsEight, Algorithm: otp.AlgorithmSHA1, Skew: 1}
+	ts := time.Unix(59, 0).UTC()
+	allocs := testing.AllocsPerRun(100, func() {
+		ValidateCustom("00000000", secSha1, ts, opts)
+	})
+	if allocs > 30 {
+		t.Fatalf("ValidateCustom allocated %v times, budget is 30", allocs)
+	}
+}
+
+// The keyed HMAC and buffers are reused across the skew window, so a wider window must not allocate
+// more.
+func TestValidateSkewAllocsConstant(t *testing.T) {
+	ts := time.Unix(1111111109, 0).UTC()
+	allocs := func(skew uint) float64 {
+		opts := ValidateOpts{Digits: otp.DigitsEight, Algorithm: otp.AlgorithmSHA1, Skew: skew}
+		return testing.AllocsPerRun(100, func() {
+			ValidateCustom("00000000", secSha1, ts, opts)
+		})
+	}
+	if allocs(1) != allocs(10) {
+		t.Fatalf("ValidateCustom allocated %v times with skew 10, but %v times with skew 1", allocs(10), allocs(1))
+	}
+}
+
+func TestValidateNotBefore(t *testing.T) {
+	notBefore := time.Unix(1111111110, 0).UTC()
+	k, err := Generate(GenerateOpts{
+		Issuer:      "SnakeOil",
+		AccountName: "alice@example.com",
+		NotBefore:   notBefore,
+	})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !k.NotBefore().Equal(notBefore) {
+		t.Fatalf("NotBefore was not kept")
+	}
+
+	opts := ValidateOpts{
+		Digits:    otp.DigitsEight,
+		Algorithm: otp.AlgorithmSHA1,
+		NotBefore: k.NotBefore(),
+	}
+
+	valid, err := ValidateCustom("07081804", secSha1, time.Unix(1111111109, 0).UTC(), opts)
+	if otp.ErrValidateKeyNotYetValid != err {
+		t.Fatalf("Expected key not yet valid error.")
+	}
+	if valid {
+		t.Fatalf("Valid should be false when we have an error.")
+	}
+
+	valid, err = ValidateCustom("14050471", secSha1, time.Unix(1111111111, 0).UTC(), opts)
+	if err != nil {
+		t.Fatalf("Expected no error.")
+	}
+	if !valid {
+		t.Fatalf("Valid should be true after activation.")
+	}
+}
+
+func TestGenerateSecretEncoding(t *testing.T) {
+	k, err := Generate(GenerateOpts{
+		Issuer:         "SnakeOil",
+		AccountName:    "alice@example.com",
+		Secret:         []byte("helloworld!"),
+		SecretEncoding: otp.SecretEncodingRFCStrict,
+	})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if "NBSWY3DPO5XXE3DEEE======" != k.Secret() {
+		t.Fatalf("Secret should be padded, got '%s'", k.Secret())
+	}
+
+	code, err := GenerateCode(k.Secret(), time.Unix(59, 0))
+	if err != nil {
+		t.Fatalf("Padded secrets should be usable: %s", err.Error())
+	}
+	expected, _ := GenerateCode("NBSWY3DPO5XXE3DEEE", time.Unix(59, 0))
+	if expected != code {
+		t.Fatalf("'%s' does not equal '%s'", expected, code)
+	}
+
+	k, err = Generate(GenerateOpts{
+		Issuer:      "Snake:Oil",
+		AccountName: "alice@example.com",
+	})
+	if otp.ErrGenerateInvalidLabel != err {
+		t.Fatalf("Expected invalid label error.")
+	}
+	if k != nil {
+		t.Fatalf("key should be nil on error.")
+	}
+}
+
+func TestValidateDrift(t *testing.T) {
+	now := time.Unix(1111111109, 0).UTC()
+	opts := ValidateOpts{Digits: otp.DigitsSix, Skew: 2}
+
+	code, _ := GenerateCodeCustom(secSha1, now.Add(-time.Minute), opts)
+	drift, valid, err := ValidateDrift(code, secSha1, now, opts)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if !valid || -2 != drift {
+		t.Fatalf("Unexpected drift %d", drift)
+	}
+
+	code, _ = GenerateCodeCustom(secSha1, now.Add(30*time.Second), opts)
+	drift, valid, _ = ValidateDrift(code, secSha1, now, opts)
+	if !valid || 1 != drift {
+		t.Fatalf("Unexpected drift %d", drift)
+	}
+}
+
+// The v1 API of github.com/pquerna/otp/totp must keep compiling after switching imports.
+func TestPquernaCompatibility(t *testing.T) {
+	var _ func(string, string) bool = Validate
+	var _ func(string, time.Time) (string, error) = GenerateCode
+	var _ func(string, time.Time, ValidateOpts) (string, error) = GenerateCodeCustom
+	var _ func(string, string, time.Time, ValidateOpts) (bool, error) = ValidateCustom
+	var _ func(GenerateOpts) (*otp.Key, error) = Generate
+	var _ = ValidateOpts{Period: 30, Skew: 1, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1, Encoder: otp.EncoderDefault}
+	var _ = GenerateOpts{Issuer: "Example", AccountName: "alice", Period: 30, SecretSize: 20, Secret: nil, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1, Rand: nil}
+}
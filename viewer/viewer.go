@@ -0,0 +1,147 @@
+// Package viewer provides a small authenticated HTTP service that lists the current passcodes of a
+// configured set of TOTP keys, for teams that share accounts with a vendor. Codes are served as an
+// HTML page, or as JSON when requested with an "Accept: application/json" header or "?format=json".
+package viewer
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+// Account is a shared key shown by the viewer.
+type Account struct {
+	// Name shown for the account. Defaults to "Issuer (AccountName)" from the key.
+	Name string
+	// The TOTP key of the account.
+	Key *otp.Key
+}
+
+// Code is the JSON representation of an account's passcodes.
+type Code struct {
+	Name      string `json:"name"`
+	Issuer    string `json:"issuer"`
+	Account   string `json:"account"`
+	Code      string `json:"code"`
+	Next      string `json:"next"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// Viewer is an http.Handler that lists the passcodes of Accounts.
+type Viewer struct {
+	// Accounts to show. HOTP keys are skipped, as showing their codes would require tracking counters.
+	Accounts []Account
+	// Authorize is called for every request and must return true for the request to be served. If it
+	// is nil every request is refused.
+	Authorize func(r *http.Request) bool
+
+	once  sync.Once
+	cache *totp.CodeCache
+}
+
+// New returns a viewer for accounts that serves requests accepted by authorize.
+func New(accounts []Account, authorize func(r *http.Request) bool) *Viewer {
+	return &Viewer{
+		Accounts:  accounts,
+		Authorize: authorize,
+	}
+}
+
+// BasicAuth returns an Authorize function that requires HTTP basic authentication with username and
+// password.
+func BasicAuth(username string, password string) func(r *http.Request) bool {
+	wantUser := sha256.Sum256([]byte(username))
+	wantPass := sha256.Sum256([]byte(password))
+	return func(r *http.Request) bool {
+		u, p, ok := r.BasicAuth()
+		if !ok {
+			return false
+		}
+		gotUser := sha256.Sum256([]byte(u))
+		gotPass := sha256.Sum256([]byte(p))
+		return subtle.ConstantTimeCompare(gotUser[:], wantUser[:])&subtle.ConstantTimeCompare(gotPass[:], wantPass[:]) == 1
+	}
+}
+
+// Codes returns the passcodes of every TOTP account at t.
+func (v *Viewer) Codes(t time.Time) ([]Code, error) {
+	v.once.Do(func() {
+		v.cache = totp.NewCodeCache()
+	})
+
+	codes := make([]Code, 0, len(v.Accounts))
+	for _, a := range v.Accounts {
+		if a.Key.Type() != "totp" {
+			continue
+		}
+
+		c, err := v.cache.Codes(a.Key, t)
+		if err != nil {
+			return nil, err
+		}
+
+		name := a.Name
+		if name == "" {
+			name = a.Key.Issuer() + " (" + a.Key.AccountName() + ")"
+		}
+		codes = append(codes, Code{
+			Name:      name,
+			Issuer:    a.Key.Issuer(),
+			Account:   a.Key.AccountName(),
+			Code:      c.Current,
+			Next:      c.Next,
+			ExpiresIn: int(c.ValidUntil.Sub(t).Round(time.Second) / time.Second),
+		})
+	}
+
+	return codes, nil
+}
+
+// page is parsed on first use, so that a mistake in the template can't panic while a program starts.
+var page = sync.OnceValue(func() *template.Template {
+	return template.Must(template.New("viewer").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta http-equiv="refresh" content="5"><title>Codes</title></head>
+<body><table>
+<tr><th>Account</th><th>Code</th><th>Expires in</th><th>Next</th></tr>
+{{range .}}<tr><td>{{.Name}}</td><td>{{.Code}}</td><td>{{.ExpiresIn}}s</td><td>{{.Next}}</td></tr>
+{{end}}</table></body></html>
+`))
+})
+
+// ServeHTTP responds with the current passcodes of every account.
+func (v *Viewer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if v.Authorize == nil || !v.Authorize(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="otp viewer"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	codes, err := v.Codes(time.Now())
+	if err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(codes)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	page().Execute(w, codes)
+}
@@ -0,0 +1,95 @@
+package viewer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ecnepsnai/otp"
+	"github.com/ecnepsnai/otp/totp"
+)
+
+func testViewer(t *testing.T) (*Viewer, *otp.Key) {
+	k, err := totp.Generate(totp.GenerateOpts{Issuer: "SnakeOil", AccountName: "team@example.com"})
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	h, _ := otp.NewKeyFromURL("otpauth://hotp/SnakeOil:hotp?secret=JBSWY3DPEHPK3PXP")
+	accounts := []Account{{Key: k}, {Name: "Counter", Key: h}}
+	return New(accounts, BasicAuth("admin", "hunter2")), k
+}
+
+func TestViewerCodes(t *testing.T) {
+	v, k := testViewer(t)
+	now := time.Unix(1111111109, 0)
+
+	codes, err := v.Codes(now)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 1 != len(codes) {
+		t.Fatalf("Expected only the TOTP account, got %d", len(codes))
+	}
+	expected, _ := totp.GenerateCode(k.Secret(), now)
+	if expected != codes[0].Code || "SnakeOil (team@example.com)" != codes[0].Name {
+		t.Fatalf("Unexpected code %+v", codes[0])
+	}
+	if 1 != codes[0].ExpiresIn {
+		t.Fatalf("Unexpected expiry %d", codes[0].ExpiresIn)
+	}
+}
+
+func TestViewerServeHTTP(t *testing.T) {
+	v, _ := testViewer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/?format=json", nil)
+	w := httptest.NewRecorder()
+	v.ServeHTTP(w, req)
+	if http.StatusUnauthorized != w.Code {
+		t.Fatalf("Expected unauthorized response, got %d", w.Code)
+	}
+
+	req.SetBasicAuth("admin", "wrong")
+	w = httptest.NewRecorder()
+	v.ServeHTTP(w, req)
+	if http.StatusUnauthorized != w.Code {
+		t.Fatalf("Expected unauthorized response for the wrong password, got %d", w.Code)
+	}
+
+	req.SetBasicAuth("admin", "hunter2")
+	w = httptest.NewRecorder()
+	v.ServeHTTP(w, req)
+	if http.StatusOK != w.Code {
+		t.Fatalf("Unexpected status %d", w.Code)
+	}
+	codes := []Code{}
+	if err := json.Unmarshal(w.Body.Bytes(), &codes); err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if 1 != len(codes) || 6 != len(codes[0].Code) {
+		t.Fatalf("Unexpected codes %+v", codes)
+	}
+	if "no-store" != w.Header().Get("Cache-Control") {
+		t.Fatalf("Expected response not to be cached")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("admin", "hunter2")
+	w = httptest.NewRecorder()
+	v.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), codes[0].Code) && !strings.Contains(w.Body.String(), codes[0].Next) {
+		t.Fatalf("Expected code in page")
+	}
+}
+
+func TestViewerNoAuthorize(t *testing.T) {
+	v := &Viewer{}
+	w := httptest.NewRecorder()
+	v.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if http.StatusUnauthorized != w.Code {
+		t.Fatalf("Expected every request to be refused without Authorize")
+	}
+}